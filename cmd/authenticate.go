@@ -142,13 +142,11 @@ func checkAuthenticateAuthParams(cmd *cobra.Command, opts *auth.AuthOptions) err
 
 		// check password and prompt
 		if len(opts.Password) == 0 {
-			// ask the user for the password -- stderr??
-			cmd.Print("Enter password: ")
-			pass, err := gopass.GetPasswd()
+			pass, err := promptSecret(cmd, "Enter password: ")
 			if err != nil {
 				return err
 			}
-			opts.Password = string(pass)
+			opts.Password = pass
 		}
 	} else {
 		if len(opts.ApplicationCredentialID) == 0 {
@@ -163,15 +161,24 @@ func checkAuthenticateAuthParams(cmd *cobra.Command, opts *auth.AuthOptions) err
 			}
 		}
 		if len(opts.ApplicationCredentialSecret) == 0 {
-			// ask the user for the password -- stderr??
-			cmd.Print("Enter application credential secret: ")
-			secret, err := gopass.GetPasswd()
+			secret, err := promptSecret(cmd, "Enter application credential secret: ")
 			if err != nil {
 				return err
 			}
-			opts.ApplicationCredentialSecret = string(secret)
+			opts.ApplicationCredentialSecret = secret
 		}
 	}
 
 	return nil
 }
+
+// promptSecret asks the user for a secret without echoing it
+func promptSecret(cmd *cobra.Command, message string) (string, error) {
+	// ask the user for the secret -- stderr??
+	cmd.Print(message)
+	secret, err := gopass.GetPasswd()
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
@@ -16,9 +16,17 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
 
+	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // removed tags since no use case yet
@@ -28,7 +36,7 @@ type Automation struct {
 	Name                            string  `json:"name"`                // required
 	Repository                      string  `json:"repository"`          // required
 	RepositoryRevision              string  `json:"repository_revision"` // required
-	RepositoryCredentials           *string `json:"repository_credentials,omitempty"`
+	RepositoryCredentials           *string `json:"repository_credentials,omitempty" sensitive:"true"`
 	RepositoryAuthenticationEnabled *bool   `json:"repository_authentication_enabled,omitempty"`
 	Timeout                         int     `json:"timeout"` // required
 }
@@ -65,6 +73,9 @@ var AutomationCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(AutomationCmd)
 	initAutomationCmdFlags()
+	// never show the secrets of the automations
+	helpers.AddSensitiveKeys(sensitiveAttributes(reflect.TypeOf(Chef{}))...)
+	helpers.AddSensitiveKeys(sensitiveAttributes(reflect.TypeOf(Script{}))...)
 }
 
 func initAutomationCmdFlags() {
@@ -107,3 +118,94 @@ func (s *Script) Marshal() (string, error) {
 	}
 	return string(body), nil
 }
+
+// sensitiveAttributes returns the JSON names of the struct fields tagged with sensitive:"true", including the
+// fields of embedded structs
+func sensitiveAttributes(structType reflect.Type) []string {
+	attributes := []string{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			attributes = append(attributes, sensitiveAttributes(field.Type)...)
+			continue
+		}
+		if field.Tag.Get("sensitive") == "true" {
+			attributes = append(attributes, strings.Split(field.Tag.Get("json"), ",")[0])
+		}
+	}
+	return attributes
+}
+
+var automationIdRegex = regexp.MustCompile(`^\d+$`)
+
+// findAutomationId returns the id of the automation with the given name. Numeric values are taken as id.
+func findAutomationId(nameOrId string) (string, error) {
+	if automationIdRegex.MatchString(nameOrId) {
+		return nameOrId, nil
+	}
+
+	automationService := RestClient.Services["automation"]
+	automations, _, err := automationService.GetList("automations", url.Values{})
+	if err != nil {
+		return "", err
+	}
+
+	ids := []string{}
+	for _, automation := range automations {
+		automationMap, ok := automation.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if automationMap["name"] == nameOrId {
+			ids = append(ids, fmt.Sprint(automationMap["id"]))
+		}
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf(locales.ErrorMessages("automation-name-not-found"), nameOrId)
+	case 1:
+		return ids[0], nil
+	}
+	return "", fmt.Errorf(locales.ErrorMessages("automation-name-ambiguous"), nameOrId, strings.Join(ids, ", "))
+}
+
+// resolveAutomationId sets the automation id under idKey. When no id is given the automation is looked up by the
+// name given under nameKey or as first positional argument.
+func resolveAutomationId(idKey, nameKey string, args []string) error {
+	if len(viper.GetString(idKey)) > 0 {
+		return nil
+	}
+	name := viper.GetString(nameKey)
+	if len(name) == 0 && len(args) > 0 {
+		name = args[0]
+	}
+	if len(name) == 0 {
+		return errors.New(locales.ErrorMessages("automation-id-missing"))
+	}
+
+	id, err := findAutomationId(name)
+	if err != nil {
+		return err
+	}
+	viper.Set(idKey, id)
+	return nil
+}
+
+// resolveAutomationIds returns the ids of the automations given by id, by name or as positional arguments
+func resolveAutomationIds(idKey, nameKey string, args []string) ([]string, error) {
+	names := commandIds(nameKey, args)
+	ids := commandIds(idKey, nil)
+	if len(ids) == 0 && len(names) == 0 {
+		return nil, errors.New(locales.ErrorMessages("automation-id-missing"))
+	}
+
+	for _, name := range names {
+		id, err := findAutomationId(name)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return uniqueIds(ids), nil
+}
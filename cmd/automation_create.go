@@ -15,8 +15,15 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // createCmd represents the create command
@@ -32,3 +39,55 @@ func init() {
 
 func initAutomationCreateCmdFlags() {
 }
+
+// readRepositoryCredentials returns the repository credentials from the flag, a file, an environment variable,
+// the standard input or the terminal prompt. The given prefix is the one used to bind the flags to viper.
+func readRepositoryCredentials(cmd *cobra.Command, prefix string) (*string, error) {
+	value := viper.GetString(fmt.Sprint(prefix, "-repository-credentials"))
+	file := viper.GetString(fmt.Sprint(prefix, "-repository-credentials-from-file"))
+	env := viper.GetString(fmt.Sprint(prefix, "-repository-credentials-from-env"))
+	prompt := viper.GetBool(fmt.Sprint(prefix, "-repository-credentials-prompt"))
+
+	// just one source is allowed
+	sources := 0
+	for _, given := range []bool{len(value) > 0, len(file) > 0, len(env) > 0, prompt} {
+		if given {
+			sources++
+		}
+	}
+	if sources == 0 {
+		return nil, nil
+	}
+	if sources > 1 {
+		return nil, errors.New(locales.ErrorMessages("repository-credentials-multiple"))
+	}
+
+	var credentials string
+	var err error
+	switch {
+	case value == "-":
+		credentials, err = helpers.ReadFromFileWithLineBreaks("-")
+	case len(value) > 0:
+		credentials = value
+	case len(file) > 0:
+		credentials, err = helpers.ReadFromFile(file)
+	case len(env) > 0:
+		credentials = os.Getenv(env)
+		if len(credentials) == 0 {
+			return nil, fmt.Errorf(locales.ErrorMessages("repository-credentials-env-missing"), env)
+		}
+	case prompt:
+		credentials, err = promptSecret(cmd, "Enter repository credentials: ")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// files and the standard input usually end with a line break
+	credentials = strings.TrimRight(credentials, "\r\n")
+	if len(credentials) == 0 {
+		return nil, errors.New(locales.ErrorMessages("repository-credentials-empty"))
+	}
+
+	return &credentials, nil
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
 	"github.com/sapcc/lyra-cli/print"
+	"github.com/sapcc/lyra-cli/restclient"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -43,19 +44,29 @@ var AutomationCreateChefCmd = &cobra.Command{
 		}
 
 		// set credentials if existing
-		if len(viper.GetString("automation-create-chef-repository-credentials")) > 0 {
-			credentials := viper.GetString("automation-create-chef-repository-credentials")
-			chef.Automation.RepositoryCredentials = &credentials
+		credentials, err := readRepositoryCredentials(cmd, "automation-create-chef")
+		if err != nil {
+			return err
 		}
+		chef.Automation.RepositoryCredentials = credentials
 
 		// setup automation create chef attributes
-		err := setupAutomationChefAttr(&chef)
+		err = setupAutomationChefAttr(&chef)
+		if err != nil {
+			return err
+		}
+
+		// validate before sending
+		err = chef.Validate()
 		if err != nil {
 			return err
 		}
 
 		// create automation
 		response, err := automationCreateChef(&chef)
+		if restclient.IsDryRun(err) {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
@@ -99,6 +110,9 @@ func initAutomationCreateChefCmdFlags() {
 	AutomationCreateChefCmd.Flags().StringP("name", "", "", locales.AttributeDescription("automation-name"))
 	AutomationCreateChefCmd.Flags().StringP("repository", "", "", locales.AttributeDescription("automation-repository"))
 	AutomationCreateChefCmd.Flags().StringP("repository-credentials", "", "", locales.AttributeDescription("automation-repository-credentials"))
+	AutomationCreateChefCmd.Flags().StringP("repository-credentials-from-file", "", "", locales.AttributeDescription("automation-repository-credentials-from-file"))
+	AutomationCreateChefCmd.Flags().StringP("repository-credentials-from-env", "", "", locales.AttributeDescription("automation-repository-credentials-from-env"))
+	AutomationCreateChefCmd.Flags().BoolP("repository-credentials-prompt", "", false, locales.AttributeDescription("automation-repository-credentials-prompt"))
 	AutomationCreateChefCmd.Flags().StringP("repository-revision", "", "master", locales.AttributeDescription("automation-repository-revision"))
 	AutomationCreateChefCmd.Flags().IntP("timeout", "", 3600, locales.AttributeDescription("automation-timeout"))
 	AutomationCreateChefCmd.Flags().BoolP("chef-debug", "", false, locales.AttributeDescription("automation-debug"))
@@ -110,6 +124,9 @@ func initAutomationCreateChefCmdFlags() {
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-chef-name", AutomationCreateChefCmd.Flags().Lookup("name")), "BindPFlag:")
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-chef-repository", AutomationCreateChefCmd.Flags().Lookup("repository")), "BindPFlag:")
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-chef-repository-credentials", AutomationCreateChefCmd.Flags().Lookup("repository-credentials")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-chef-repository-credentials-from-file", AutomationCreateChefCmd.Flags().Lookup("repository-credentials-from-file")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-chef-repository-credentials-from-env", AutomationCreateChefCmd.Flags().Lookup("repository-credentials-from-env")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-chef-repository-credentials-prompt", AutomationCreateChefCmd.Flags().Lookup("repository-credentials-prompt")), "BindPFlag:")
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-chef-repository-revision", AutomationCreateChefCmd.Flags().Lookup("repository-revision")), "BindPFlag:")
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-chef-timeout", AutomationCreateChefCmd.Flags().Lookup("timeout")), "BindPFlag:")
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-chef-debug", AutomationCreateChefCmd.Flags().Lookup("chef-debug")), "BindPFlag:")
@@ -122,7 +139,7 @@ func initAutomationCreateChefCmdFlags() {
 // private
 
 func setupAutomationChefAttr(chef *Chef) error {
-	chef.Runlist = helpers.StringToArray(viper.GetString("automation-create-chef-runlist"))
+	chef.Runlist = parseRunlist(viper.GetString("automation-create-chef-runlist"))
 
 	// read attributes
 	if len(viper.GetString("automation-create-chef-attributes")) > 0 {
@@ -225,10 +225,13 @@ func TestAutomationCreateChefShouldSetAttributesFromFile(t *testing.T) {
 
 	resetAutomationCreateChefFlagVars()
 	resulter := FullCmdTester(RootCmd,
-		fmt.Sprintf("lyra automation create chef --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --attributes-from-file=%s",
+		fmt.Sprintf("lyra automation create chef --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --name=%s --repository=%s --runlist=%s --attributes-from-file=%s",
 			server.URL,
 			server.URL,
 			"token123",
+			"chef_test",
+			"http://some_repository",
+			"recipe[nginx]",
 			file))
 
 	if resulter.Error != nil {
@@ -280,10 +283,13 @@ func TestAutomationCreateChefShouldSetAttributesFromStdInput(t *testing.T) {
 
 	resetAutomationCreateChefFlagVars()
 	resulter := FullCmdTester(RootCmd,
-		fmt.Sprintf("lyra automation create chef --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --attributes-from-file=%s",
+		fmt.Sprintf("lyra automation create chef --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --name=%s --repository=%s --runlist=%s --attributes-from-file=%s",
 			server.URL,
 			"http://some_nice_url",
 			"token123",
+			"chef_test",
+			"http://some_repository",
+			"recipe[nginx]",
 			"-"))
 
 	// flush, restore close
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sapcc/lyra-cli/locales"
+)
+
+func automationCreateCredentialsServer(t *testing.T, want string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		data, _ := io.ReadAll(r.Body)
+		defer r.Body.Close()
+
+		sent := Script{}
+		if err := sent.Unmarshal(string(data)); err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if sent.RepositoryCredentials == nil || *sent.RepositoryCredentials != want {
+			t.Errorf("Repository credentials doesn't match. \n \n %s", StringDiff(string(data), want))
+		}
+		fmt.Fprintln(w, string(data))
+	}))
+}
+
+func automationCreateCredentialsCmd(url, flags string) string {
+	return fmt.Sprintf("lyra automation create script --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --name=test --repository=http://some_repository --path=script.sh %s", url, url, "token123", flags)
+}
+
+func checkCredentialsRedacted(t *testing.T, resulter resulter, secret string) {
+	if resulter.Error != nil {
+		t.Errorf(`Command expected to not get an error: %s`, resulter.Error)
+	}
+	if strings.Contains(resulter.Output, secret) {
+		t.Errorf("Command output should not contain the repository credentials. \n \n %s", resulter.Output)
+	}
+	if !strings.Contains(resulter.Output, "[REDACTED]") {
+		t.Errorf("Command output should contain redacted credentials. \n \n %s", StringDiff(resulter.Output, "[REDACTED]"))
+	}
+}
+
+func TestAutomationCreateCredentialsFromFile(t *testing.T) {
+	server := automationCreateCredentialsServer(t, "secret_from_file")
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "credentials")
+	if err := os.WriteFile(file, []byte("secret_from_file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, automationCreateCredentialsCmd(server.URL, fmt.Sprint("--repository-credentials-from-file=", file)))
+	checkCredentialsRedacted(t, resulter, "secret_from_file")
+}
+
+func TestAutomationCreateCredentialsFromEnv(t *testing.T) {
+	server := automationCreateCredentialsServer(t, "secret_from_env")
+	defer server.Close()
+	t.Setenv("LYRA_TEST_REPOSITORY_CREDENTIALS", "secret_from_env")
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, automationCreateCredentialsCmd(server.URL, "--repository-credentials-from-env=LYRA_TEST_REPOSITORY_CREDENTIALS --json"))
+	checkCredentialsRedacted(t, resulter, "secret_from_env")
+}
+
+func TestAutomationCreateCredentialsFromStdin(t *testing.T) {
+	server := automationCreateCredentialsServer(t, "secret_from_stdin")
+	defer server.Close()
+
+	// keep backup of the real stdin
+	oldStdin := os.Stdin
+	defer func() { os.Stdin = oldStdin }()
+	if _, err := pipeToStdin("secret_from_stdin\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, automationCreateCredentialsCmd(server.URL, "--repository-credentials-from-file=-"))
+	checkCredentialsRedacted(t, resulter, "secret_from_stdin")
+}
+
+func TestAutomationCreateCredentialsMissingEnv(t *testing.T) {
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, automationCreateCredentialsCmd("https://somewhere.com", "--repository-credentials-from-env=LYRA_TEST_NOT_EXISTING_VARIABLE"))
+
+	errorMsg := fmt.Sprintf(locales.ErrorMessages("repository-credentials-env-missing"), "LYRA_TEST_NOT_EXISTING_VARIABLE")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+}
+
+func TestAutomationCreateCredentialsMultipleSources(t *testing.T) {
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, automationCreateCredentialsCmd("https://somewhere.com", "--repository-credentials=secret --repository-credentials-from-env=HOME"))
+
+	errorMsg := locales.ErrorMessages("repository-credentials-multiple")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
 	"github.com/sapcc/lyra-cli/print"
+	"github.com/sapcc/lyra-cli/restclient"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -28,19 +29,29 @@ var AutomationCreateScriptCmd = &cobra.Command{
 		}
 
 		// set credentials if existing
-		if len(viper.GetString("automation-create-script-repository-credentials")) > 0 {
-			credentials := viper.GetString("automation-create-script-repository-credentials")
-			script.Automation.RepositoryCredentials = &credentials
+		credentials, err := readRepositoryCredentials(cmd, "automation-create-script")
+		if err != nil {
+			return err
 		}
+		script.Automation.RepositoryCredentials = credentials
 
 		// setup automation create script attributes
-		err := setupAutomationScriptAttr(&script)
+		err = setupAutomationScriptAttr(&script)
+		if err != nil {
+			return err
+		}
+
+		// validate before sending
+		err = script.Validate()
 		if err != nil {
 			return err
 		}
 
 		// create automation
 		response, err := automationCreateScript(&script)
+		if restclient.IsDryRun(err) {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
@@ -84,30 +95,102 @@ func initAutomationCreateScriptCmdFlags() {
 	AutomationCreateScriptCmd.Flags().String("name", "", locales.AttributeDescription("automation-name"))
 	AutomationCreateScriptCmd.Flags().String("repository", "", locales.AttributeDescription("automation-repository"))
 	AutomationCreateScriptCmd.Flags().String("repository-credentials", "", locales.AttributeDescription("automation-repository-credentials"))
+	AutomationCreateScriptCmd.Flags().String("repository-credentials-from-file", "", locales.AttributeDescription("automation-repository-credentials-from-file"))
+	AutomationCreateScriptCmd.Flags().String("repository-credentials-from-env", "", locales.AttributeDescription("automation-repository-credentials-from-env"))
+	AutomationCreateScriptCmd.Flags().Bool("repository-credentials-prompt", false, locales.AttributeDescription("automation-repository-credentials-prompt"))
 	AutomationCreateScriptCmd.Flags().String("repository-revision", "master", locales.AttributeDescription("automation-repository-revision"))
 	AutomationCreateScriptCmd.Flags().Int("timeout", 3600, locales.AttributeDescription("automation-timeout"))
 	AutomationCreateScriptCmd.Flags().String("path", "", locales.AttributeDescription("automation-path"))
 	AutomationCreateScriptCmd.Flags().StringArray("arg", nil, locales.AttributeDescription("automation-argument"))
 	AutomationCreateScriptCmd.Flags().StringArray("env", nil, locales.AttributeDescription("automation-environment"))
+	AutomationCreateScriptCmd.Flags().String("args-file", "", locales.AttributeDescription("automation-arguments-file"))
+	AutomationCreateScriptCmd.Flags().String("env-file", "", locales.AttributeDescription("automation-environment-file"))
+	AutomationCreateScriptCmd.Flags().String("env-from-json", "", locales.AttributeDescription("automation-environment-from-json"))
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-script-name", AutomationCreateScriptCmd.Flags().Lookup("name")), "BindPFlag:")
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-script-repository", AutomationCreateScriptCmd.Flags().Lookup("repository")), "BindPFlag:")
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-script-repository-credentials", AutomationCreateScriptCmd.Flags().Lookup("repository-credentials")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-script-repository-credentials-from-file", AutomationCreateScriptCmd.Flags().Lookup("repository-credentials-from-file")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-script-repository-credentials-from-env", AutomationCreateScriptCmd.Flags().Lookup("repository-credentials-from-env")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-script-repository-credentials-prompt", AutomationCreateScriptCmd.Flags().Lookup("repository-credentials-prompt")), "BindPFlag:")
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-script-repository-revision", AutomationCreateScriptCmd.Flags().Lookup("repository-revision")), "BindPFlag:")
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-script-timeout", AutomationCreateScriptCmd.Flags().Lookup("timeout")), "BindPFlag:")
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-script-path", AutomationCreateScriptCmd.Flags().Lookup("path")), "BindPFlag:")
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-script-argument", AutomationCreateScriptCmd.Flags().Lookup("arg")), "BindPFlag:")
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-script-environment", AutomationCreateScriptCmd.Flags().Lookup("env")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-script-args-file", AutomationCreateScriptCmd.Flags().Lookup("args-file")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-script-env-file", AutomationCreateScriptCmd.Flags().Lookup("env-file")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-create-script-env-from-json", AutomationCreateScriptCmd.Flags().Lookup("env-from-json")), "BindPFlag:")
 }
 
 // private
 
 func setupAutomationScriptAttr(scriptObj *Script) (err error) {
-	scriptObj.Arguments = viper.GetStringSlice("automation-create-script-argument")
+	scriptObj.Arguments, err = readScriptArguments("automation-create-script")
+	if err != nil {
+		return
+	}
 
-	scriptObj.Environment, err = helpers.StringSliceKeyValueMap(viper.GetStringSlice("automation-create-script-environment"))
+	scriptObj.Environment, err = readScriptEnvironment("automation-create-script", map[string]string{})
 	return
 }
 
+// readScriptArguments returns the arguments of the --args-file followed by the arguments given with --arg
+func readScriptArguments(prefix string) ([]string, error) {
+	arguments := []string{}
+	if file := viper.GetString(fmt.Sprint(prefix, "-args-file")); len(file) > 0 {
+		content, err := helpers.ReadFromFileWithLineBreaks(file)
+		if err != nil {
+			return nil, err
+		}
+		arguments, err = helpers.ParseArgumentList(content)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return append(arguments, viper.GetStringSlice(fmt.Sprint(prefix, "-argument"))...), nil
+}
+
+// readScriptEnvironment merges the environment variables from --env-file, --env-from-json and --env in this order
+// into the given environment, so explicit flags take precedence.
+func readScriptEnvironment(prefix string, environment map[string]string) (map[string]string, error) {
+	result := map[string]string{}
+	for k, v := range environment {
+		result[k] = v
+	}
+
+	if file := viper.GetString(fmt.Sprint(prefix, "-env-file")); len(file) > 0 {
+		content, err := helpers.ReadFromFileWithLineBreaks(file)
+		if err != nil {
+			return nil, err
+		}
+		env, err := helpers.ParseDotenv(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+		helpers.MapMerge(result, env)
+	}
+
+	if file := viper.GetString(fmt.Sprint(prefix, "-env-from-json")); len(file) > 0 {
+		content, err := helpers.ReadFromFileWithLineBreaks(file)
+		if err != nil {
+			return nil, err
+		}
+		env, err := helpers.JSONToEnvironment(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+		helpers.MapMerge(result, env)
+	}
+
+	env, err := helpers.StringSliceKeyValueMap(viper.GetStringSlice(fmt.Sprint(prefix, "-environment")))
+	if err != nil {
+		return nil, err
+	}
+	helpers.MapMerge(result, env)
+
+	return result, nil
+}
+
 func automationCreateScript(script *Script) (string, error) {
 	// add the type
 	script.AutomationType = "Script"
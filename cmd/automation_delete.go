@@ -15,33 +15,38 @@
 package cmd
 
 import (
-	"errors"
 	"net/url"
 	"path"
 
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/restclient"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var AutomationDeleteCmd = &cobra.Command{
-	Use:   "delete",
-	Short: locales.CmdShortDescription("automation-delete"),
-	PreRunE: func(cmd *cobra.Command, args []string) error {
-		// check required node id
-		if len(viper.GetString("automation-delete-id")) == 0 {
-			return errors.New(locales.ErrorMessages("automation-id-missing"))
-		}
-		return nil
-	},
+	Use:               "delete [NAME|ID]...",
+	Short:             locales.CmdShortDescription("automation-delete"),
+	ValidArgsFunction: automationNameCompletion,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		_, err := automationDelete(viper.GetString("automation-delete-id"))
+		// resolve the automation ids from the names
+		ids, err := resolveAutomationIds("automation-delete-id", "automation-delete-name", args)
 		if err != nil {
 			return err
 		}
-		// Print response to the sdterr. No response got it
-		cmd.Println("Automation with id ", viper.GetString("automation-delete-id"), " deleted.")
+
+		for _, id := range ids {
+			_, err := automationDelete(id)
+			if restclient.IsDryRun(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			// Print response to the sdterr. No response got it
+			cmd.Println("Automation with id ", id, " deleted.")
+		}
 
 		return nil
 	},
@@ -55,6 +60,8 @@ func init() {
 func initAutomationDeleteCmdFlags() {
 	AutomationDeleteCmd.Flags().StringP(FLAG_AUTOMATION_ID, "", "", locales.AttributeDescription(FLAG_AUTOMATION_ID))
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-delete-id", AutomationDeleteCmd.Flags().Lookup(FLAG_AUTOMATION_ID)), "BindPFlag:")
+	AutomationDeleteCmd.Flags().StringP(FLAG_AUTOMATION, "", "", locales.AttributeDescription(FLAG_AUTOMATION))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-delete-name", AutomationDeleteCmd.Flags().Lookup(FLAG_AUTOMATION)), "BindPFlag:")
 }
 
 func automationDelete(id string) (string, error) {
@@ -0,0 +1,431 @@
+// Copyright © 2016 Arturo Reuschenbach <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/print"
+	"github.com/sapcc/lyra-cli/restclient"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+var AutomationEditCmd = &cobra.Command{
+	Use:               "edit [NAME|ID]",
+	Args:              cobra.MaximumNArgs(1),
+	Short:             locales.CmdShortDescription("automation-edit"),
+	Long:              locales.CmdLongDescription("automation-edit"),
+	ValidArgsFunction: automationNameCompletion,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// resolve the automation id from the name if no id given
+		return resolveAutomationId("automation-edit-id", "automation-edit-name", args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// edit automation
+		response, err := automationEdit(cmd, viper.GetString("automation-edit-id"))
+		if restclient.IsDryRun(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(response) == 0 {
+			cmd.Println("Edit cancelled, no changes made.")
+			return nil
+		}
+
+		// convert data to struct
+		var dataStruct map[string]interface{}
+		err = helpers.JSONStringToStructure(response, &dataStruct)
+		if err != nil {
+			return err
+		}
+
+		// print the data out
+		printer := print.Print{Data: dataStruct}
+		var bodyPrint string
+		if viper.GetBool("json") {
+			bodyPrint, err = printer.JSON()
+			if err != nil {
+				return err
+			}
+		} else {
+			bodyPrint, err = printer.Table()
+			if err != nil {
+				return err
+			}
+		}
+
+		// print response
+		fmt.Println(bodyPrint)
+
+		return nil
+	},
+}
+
+func init() {
+	AutomationCmd.AddCommand(AutomationEditCmd)
+	initAutomationEditCmdFlags()
+}
+
+func initAutomationEditCmdFlags() {
+	AutomationEditCmd.Flags().StringP(FLAG_AUTOMATION_ID, "", "", locales.AttributeDescription("automation-id"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-edit-id", AutomationEditCmd.Flags().Lookup(FLAG_AUTOMATION_ID)), "BindPFlag:")
+	AutomationEditCmd.Flags().StringP(FLAG_AUTOMATION, "", "", locales.AttributeDescription(FLAG_AUTOMATION))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-edit-name", AutomationEditCmd.Flags().Lookup(FLAG_AUTOMATION)), "BindPFlag:")
+}
+
+const automationEditHeader = `# Please edit the automation below. Lines beginning with a '#' will be ignored,
+# and an empty file will abort the edit. If an error occurs while saving this file
+# will be reopened with the relevant failures.
+#
+`
+
+// automationEdit opens the automation in the editor and saves the changes. An empty response means nothing changed.
+func automationEdit(cmd *cobra.Command, id string) (string, error) {
+	automationService := RestClient.Services["automation"]
+	response, _, err := automationService.Get(path.Join("automations", id), url.Values{}, false)
+	if err != nil {
+		return "", err
+	}
+
+	// keep just the editable attributes
+	original, err := newEditableAutomation(response)
+	if err != nil {
+		return "", err
+	}
+	// the secrets are not shown in the editor and kept unless replaced
+	originalData, err := automationData(original)
+	if err != nil {
+		return "", err
+	}
+	originalJSON, err := helpers.StructureToJSON(originalData)
+	if err != nil {
+		return "", err
+	}
+	originalYAML, err := helpers.StructureToYAML(helpers.Redact(originalData))
+	if err != nil {
+		return "", err
+	}
+
+	// edit until the result is valid or the user gives up
+	content := originalYAML
+	header := ""
+	failed := ""
+	var errs ValidationErrors
+	for {
+		edited, err := editInEditor(fmt.Sprint(automationEditHeader, header, content))
+		if err != nil {
+			return "", err
+		}
+		edited = stripEditHeader(edited)
+
+		// empty file aborts the edit
+		if isEmptyYAML(edited) {
+			return "", nil
+		}
+		// same content failed already
+		if len(errs) > 0 && canonicalYAML(edited) == failed {
+			return "", fmt.Errorf("edit cancelled, the automation is still invalid: %s", strings.Join(editErrorMessages(errs), "; "))
+		}
+
+		var automation interface{}
+		automation, errs = decodeEditedAutomation(edited, original)
+		if len(errs) > 0 {
+			failed = canonicalYAML(edited)
+			content, header = annotateEditErrors(edited, errs)
+			continue
+		}
+
+		// compare to the original
+		data, err := automationData(automation)
+		if err != nil {
+			return "", err
+		}
+		body, err := helpers.StructureToJSON(helpers.Unredact(data, originalData))
+		if err != nil {
+			return "", err
+		}
+		if originalJSON == body {
+			return "", nil
+		}
+
+		// send data back
+		newResp, _, err := automationService.Put(path.Join("automations", id), url.Values{}, body)
+		if err != nil {
+			return "", err
+		}
+		return newResp, nil
+	}
+}
+
+// automationData returns the chef or script automation as generic structure using the JSON field names
+func automationData(automation interface{}) (interface{}, error) {
+	jsonString, err := helpers.StructureToJSON(automation)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	err = helpers.JSONStringToStructure(jsonString, &data)
+	return data, err
+}
+
+// newEditableAutomation maps the response to the chef or script automation
+func newEditableAutomation(response string) (interface{}, error) {
+	automation := struct {
+		AutomationType string `json:"type"`
+	}{}
+	err := helpers.JSONStringToStructure(response, &automation)
+	if err != nil {
+		return nil, err
+	}
+
+	switch automation.AutomationType {
+	case "Chef":
+		chefObj := Chef{}
+		err = chefObj.Unmarshal(response)
+		return &chefObj, err
+	case "Script":
+		scriptObj := Script{}
+		err = scriptObj.Unmarshal(response)
+		return &scriptObj, err
+	}
+
+	return nil, fmt.Errorf("unknown automation type %q", automation.AutomationType)
+}
+
+// decodeEditedAutomation decodes the YAML and validates the result
+func decodeEditedAutomation(edited string, original interface{}) (interface{}, ValidationErrors) {
+	automation, err := decodeAutomation(edited)
+	if err != nil {
+		return nil, ValidationErrors{{Message: err.Error()}}
+	}
+
+	errs := ValidationErrors{}
+	if automationKey(automation) != automationKey(original) {
+		errs.add("", "id and type can not be changed")
+	}
+	// problems already stored don't block the edit
+	if err = newValidationErrors(validateAutomation(original), validateAutomation(automation)); err != nil {
+		validationErrors, ok := err.(ValidationErrors)
+		if !ok {
+			errs.add("", err.Error())
+			return nil, errs
+		}
+		errs = append(errs, validationErrors...)
+	}
+
+	return automation, errs
+}
+
+// automationKey returns the id and type of a chef or script automation
+func automationKey(automation interface{}) string {
+	switch automation := automation.(type) {
+	case *Chef:
+		return fmt.Sprint(automation.Id, automation.AutomationType)
+	case *Script:
+		return fmt.Sprint(automation.Id, automation.AutomationType)
+	}
+	return ""
+}
+
+// editErrorPrefix starts the error comments added to the edited file
+const editErrorPrefix = "# Error: "
+
+// editErrorMessages returns the errors as field and message
+func editErrorMessages(errs ValidationErrors) []string {
+	messages := []string{}
+	for _, e := range errs {
+		if len(e.Field) == 0 {
+			messages = append(messages, e.Message)
+			continue
+		}
+		messages = append(messages, fmt.Sprintf("%s: %s", e.Field, e.Message))
+	}
+	return messages
+}
+
+// editErrorsComment returns the errors as comment block for the header
+func editErrorsComment(errs ValidationErrors) string {
+	var buffer bytes.Buffer
+	for _, message := range editErrorMessages(errs) {
+		buffer.WriteString(fmt.Sprint(editErrorPrefix, message, "\n"))
+	}
+	if buffer.Len() > 0 {
+		buffer.WriteString("#\n")
+	}
+	return buffer.String()
+}
+
+// annotateEditErrors adds every error as comment to the line of its field and returns the errors without a field
+// in the content as header comment. Error comments of the previous attempt are removed.
+func annotateEditErrors(content string, errs ValidationErrors) (string, string) {
+	doc := yaml.Node{}
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil || len(doc.Content) == 0 {
+		return content, editErrorsComment(errs)
+	}
+	clearEditErrors(&doc)
+
+	unplaced := ValidationErrors{}
+	for _, e := range errs {
+		node := findYAMLField(doc.Content[0], e.Field)
+		if node == nil {
+			unplaced = append(unplaced, e)
+			continue
+		}
+		if len(node.LineComment) == 0 {
+			node.LineComment = editErrorPrefix + e.Message
+		} else {
+			node.LineComment = fmt.Sprint(node.LineComment, "; ", e.Message)
+		}
+	}
+
+	annotated, err := yaml.Marshal(&doc)
+	if err != nil {
+		return content, editErrorsComment(errs)
+	}
+	return string(annotated), editErrorsComment(unplaced)
+}
+
+// clearEditErrors removes the error comments from the node and its children
+func clearEditErrors(node *yaml.Node) {
+	if strings.HasPrefix(node.LineComment, editErrorPrefix) {
+		node.LineComment = ""
+	}
+	for _, child := range node.Content {
+		clearEditErrors(child)
+	}
+}
+
+// findYAMLField returns the node of a validation error field like name, run_list[1] or environment.NAME. Scalar
+// values are returned, otherwise the key.
+func findYAMLField(node *yaml.Node, field string) *yaml.Node {
+	if len(field) == 0 {
+		return nil
+	}
+	var target *yaml.Node
+	for _, part := range strings.Split(field, ".") {
+		index := -1
+		if i := strings.Index(part, "["); i > 0 && strings.HasSuffix(part, "]") {
+			n, err := strconv.Atoi(part[i+1 : len(part)-1])
+			if err != nil {
+				return nil
+			}
+			part, index = part[:i], n
+		}
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var key, value *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == part {
+				key, value = node.Content[i], node.Content[i+1]
+				break
+			}
+		}
+		if key == nil {
+			return nil
+		}
+		target, node = key, value
+		if value.Kind == yaml.ScalarNode {
+			target = value
+		}
+		if index >= 0 {
+			if value.Kind != yaml.SequenceNode || index >= len(value.Content) {
+				return nil
+			}
+			target, node = value.Content[index], value.Content[index]
+		}
+	}
+	return target
+}
+
+// stripEditHeader removes the header and the error comments added at the beginning of the edited file. Other
+// comments are kept for the YAML parser to ignore them.
+func stripEditHeader(content string) string {
+	headerLines := map[string]bool{}
+	for _, line := range strings.Split(automationEditHeader, "\n") {
+		headerLines[line] = true
+	}
+	lines := strings.Split(content, "\n")
+	i := 0
+	for i < len(lines) && (headerLines[lines[i]] || strings.HasPrefix(lines[i], editErrorPrefix)) {
+		i++
+	}
+	return strings.Join(lines[i:], "\n")
+}
+
+// isEmptyYAML returns true when the content has no data, Ex: only comments
+func isEmptyYAML(content string) bool {
+	var structure interface{}
+	return helpers.YAMLStringToStructure(content, &structure) == nil && structure == nil
+}
+
+// canonicalYAML returns the data of the content as JSON to compare it ignoring comments and formatting. Invalid
+// YAML is returned as is.
+func canonicalYAML(content string) string {
+	var structure interface{}
+	if err := helpers.YAMLStringToStructure(content, &structure); err != nil {
+		return content
+	}
+	canonical, err := helpers.StructureToJSON(structure)
+	if err != nil {
+		return content
+	}
+	return canonical
+}
+
+// editInEditor writes the content to a temporary file, opens it with $EDITOR and returns the saved content
+func editInEditor(content string) (string, error) {
+	file, err := os.CreateTemp("", "lyra-edit-*.yaml")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = file.WriteString(content); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err = file.Close(); err != nil {
+		return "", err
+	}
+
+	// the editor may contain arguments. Ex: "code --wait"
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	editorCmd := exec.Command(editor[0], append(editor[1:], file.Name())...) // #nosec G204
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err = editorCmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed: %s", strings.Join(editor, " "), err)
+	}
+
+	return helpers.ReadFromFile(file.Name())
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sapcc/lyra-cli/locales"
+)
+
+func automationEditServer(t *testing.T, puts *int, want string) *httptest.Server {
+	responseBody := `{"id":34,"type":"Chef","name":"chef_test","project_id":"p-9597d2775","repository":"https://github.com/userId0123456789/automation-test.git","repository_revision":"master","timeout":3600,"run_list":["recipe[nginx]"],"chef_attributes":{"test":"test"},"created_at":"2016-05-19T12:48:51.629Z"}`
+	return automationEditResponseServer(t, puts, responseBody, want)
+}
+
+func automationEditResponseServer(t *testing.T, puts *int, responseBody, want string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "PUT" {
+			*puts++
+			data, _ := io.ReadAll(r.Body)
+			defer r.Body.Close()
+
+			eq, err := JsonDiff(string(data), want)
+			if err != nil {
+				t.Error(err.Error())
+				return
+			}
+			if !eq {
+				t.Errorf("Request body doesn't match. \n \n %s", StringDiff(string(data), want))
+			}
+			fmt.Fprintln(w, string(data))
+			return
+		}
+		fmt.Fprintln(w, responseBody)
+	}))
+}
+
+func TestAutomationEditCmdMissingId(t *testing.T) {
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation edit --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s", "https://somewhere.com", "https://somewhere.com", "token123"))
+
+	errorMsg := locales.ErrorMessages("automation-id-missing")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		diffString := StringDiff(resulter.ErrorOutput, errorMsg)
+		t.Errorf("Command error doesn't match. \n \n %s", diffString)
+	}
+}
+
+func TestAutomationEditCmdSuccess(t *testing.T) {
+	puts := 0
+	server := automationEditServer(t, &puts, `{"id":34,"type":"Chef","name":"chef_new","repository":"https://github.com/userId0123456789/automation-test.git","repository_revision":"master","timeout":3600,"run_list":["recipe[nginx]"],"chef_attributes":{"test":"test"}}`)
+	defer server.Close()
+	t.Setenv("EDITOR", "sed -i s/chef_test/chef_new/")
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation edit --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=34", server.URL, server.URL, "token123"))
+	if resulter.Error != nil {
+		t.Error(fmt.Sprint(`Command expected to not get an error: `, resulter.Error))
+	}
+	if puts != 1 {
+		t.Errorf("Command expected to update the automation once. Got %d", puts)
+	}
+	if !strings.Contains(resulter.Output, "chef_new") {
+		t.Errorf("Command response body doesn't match. \n \n %s", StringDiff(resulter.Output, "chef_new"))
+	}
+}
+
+func TestAutomationEditCmdKeepsCredentials(t *testing.T) {
+	puts := 0
+	responseBody := `{"id":34,"type":"Chef","name":"chef_test","repository":"https://github.com/userId0123456789/automation-test.git","repository_revision":"master","repository_credentials":"secret","timeout":3600,"run_list":["recipe[nginx]"]}`
+	server := automationEditResponseServer(t, &puts, responseBody, `{"id":34,"type":"Chef","name":"chef_new","repository":"https://github.com/userId0123456789/automation-test.git","repository_revision":"master","repository_credentials":"secret","timeout":3600,"run_list":["recipe[nginx]"]}`)
+	defer server.Close()
+	// the credentials would be changed if the editor got them
+	t.Setenv("EDITOR", "sed -i -e s/chef_test/chef_new/ -e s/secret/leaked/")
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation edit --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=34", server.URL, server.URL, "token123"))
+	if resulter.Error != nil {
+		t.Error(fmt.Sprint(`Command expected to not get an error: `, resulter.Error))
+	}
+	if puts != 1 {
+		t.Errorf("Command expected to update the automation once. Got %d", puts)
+	}
+	if strings.Contains(resulter.Output, "secret") {
+		t.Errorf("Command expected to redact the credentials. Got %s", resulter.Output)
+	}
+}
+
+func TestAutomationEditCmdNoChanges(t *testing.T) {
+	puts := 0
+	server := automationEditServer(t, &puts, `{}`)
+	defer server.Close()
+	t.Setenv("EDITOR", "true")
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation edit --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=34", server.URL, server.URL, "token123"))
+	if resulter.Error != nil {
+		t.Error(fmt.Sprint(`Command expected to not get an error: `, resulter.Error))
+	}
+	if puts != 0 {
+		t.Errorf("Command expected to not update the automation. Got %d", puts)
+	}
+	if !strings.Contains(resulter.ErrorOutput, "no changes made") {
+		t.Errorf("Command output doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, "no changes made"))
+	}
+}
+
+func TestAutomationEditCmdInvalid(t *testing.T) {
+	puts := 0
+	server := automationEditServer(t, &puts, `{}`)
+	defer server.Close()
+	t.Setenv("EDITOR", "sed -i s/^timeout:.*/timeout:/")
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation edit --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=34", server.URL, server.URL, "token123"))
+	if resulter.Error == nil {
+		t.Error(`Command expected to get an error`)
+	}
+	if puts != 0 {
+		t.Errorf("Command expected to not update the automation. Got %d", puts)
+	}
+	if !strings.Contains(resulter.ErrorOutput, "timeout: is required") {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, "timeout: is required"))
+	}
+}
+
+func TestAutomationEditCmdUnknownAttribute(t *testing.T) {
+	puts := 0
+	server := automationEditServer(t, &puts, `{}`)
+	defer server.Close()
+	t.Setenv("EDITOR", "sed -i s/^name:/nmae:/")
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation edit --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=34", server.URL, server.URL, "token123"))
+	if resulter.Error == nil {
+		t.Error(`Command expected to get an error`)
+	}
+	if !strings.Contains(resulter.ErrorOutput, "nmae") {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, "nmae"))
+	}
+}
+
+// editorScript returns an editor running the shell script. $1 is the edited file and $run the number of the call.
+func editorScript(t *testing.T, script string) string {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "editor.sh")
+	counter := filepath.Join(dir, "runs")
+	content := fmt.Sprintf("echo x >> %s\nrun=$(wc -l < %s | tr -d ' ')\n%s\n", counter, counter, script)
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return "sh " + file
+}
+
+func TestAutomationEditCmdKeepsBlockScalars(t *testing.T) {
+	puts := 0
+	server := automationEditServer(t, &puts, `{"id":34,"type":"Chef","name":"chef_test","repository":"https://github.com/userId0123456789/automation-test.git","repository_revision":"master","timeout":3600,"run_list":["recipe[nginx]"],"chef_attributes":{"script":"#!/bin/bash\n# install\necho hi\n"}}`)
+	defer server.Close()
+	t.Setenv("EDITOR", editorScript(t, `cat > "$1" <<'EOF'
+# a comment of the user
+id: 34
+type: Chef
+name: chef_test
+repository: https://github.com/userId0123456789/automation-test.git
+repository_revision: master
+timeout: 3600
+run_list:
+    - recipe[nginx]
+chef_attributes:
+    script: |
+        #!/bin/bash
+        # install
+        echo hi
+EOF`))
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation edit --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=34", server.URL, server.URL, "token123"))
+	if resulter.Error != nil {
+		t.Error(fmt.Sprint(`Command expected to not get an error: `, resulter.Error))
+	}
+	if puts != 1 {
+		t.Errorf("Command expected to update the automation once. Got %d", puts)
+	}
+}
+
+func TestAutomationEditCmdInlineErrors(t *testing.T) {
+	puts := 0
+	server := automationEditServer(t, &puts, `{"id":34,"type":"Chef","name":"chef_test","repository":"https://github.com/userId0123456789/automation-test.git","repository_revision":"master","timeout":60,"run_list":["recipe[nginx]"],"chef_attributes":{"test":"test"}}`)
+	defer server.Close()
+	reopened := filepath.Join(t.TempDir(), "reopened.yaml")
+	t.Setenv("EDITOR", editorScript(t, fmt.Sprintf(`if [ "$run" = 1 ]; then
+  sed -i -e 's/^timeout:.*/timeout: 100000/' -e 's/recipe\[nginx\]/nginx/' -e '/^repository_revision:/d' "$1"
+else
+  cp "$1" %s
+  sed -i -e 's/^timeout:.*/timeout: 60/' -e 's/- nginx.*/- recipe[nginx]/' -e 's/^name:/repository_revision: master\nname:/' "$1"
+fi`, reopened)))
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation edit --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=34", server.URL, server.URL, "token123"))
+	if resulter.Error != nil {
+		t.Fatal(fmt.Sprint(`Command expected to not get an error: `, resulter.Error))
+	}
+	if puts != 1 {
+		t.Errorf("Command expected to update the automation once. Got %d", puts)
+	}
+
+	content, err := os.ReadFile(reopened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Error: repository_revision: is required\n#\n",
+		"timeout: 100000 # Error: should be between 1 and 86400 seconds\n",
+		"- nginx # Error: \"nginx\" should look like recipe[cookbook::recipe] or role[name]\n",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected %q in the reopened file, got\n%s", want, content)
+		}
+	}
+}
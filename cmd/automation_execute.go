@@ -15,18 +15,23 @@
 package cmd
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"net/http"
 	"net/url"
+	"os"
+	"strings"
 	"time"
 
 	auth "github.com/sapcc/go-openstack-auth"
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
 	"github.com/sapcc/lyra-cli/print"
+	"github.com/sapcc/lyra-cli/restclient"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -38,43 +43,58 @@ var (
 
 // updateCmd represents the update command
 var AutomationExecuteCmd = &cobra.Command{
-	Use:   "execute",
-	Short: locales.CmdShortDescription("automation-execute"),
+	Use:               "execute [NAME|ID]",
+	Args:              cobra.MaximumNArgs(1),
+	Short:             locales.CmdShortDescription("automation-execute"),
+	ValidArgsFunction: automationNameCompletion,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		// DO NOT REMOVE. SHOULD OVERRIDE THE ROOT PersistentPreRunE
 		return nil
 	},
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		// setup automation run attributes
-		return setupAutomationRun()
+		return setupAutomationRun(args)
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var response string
-		if viper.GetBool("watch") {
-			// keep the auth options for reauthentication
-			ExecuteAuthOps = auth.AuthOptions{
-				IdentityEndpoint:            viper.GetString(ENV_VAR_AUTH_URL),
-				Username:                    viper.GetString(ENV_VAR_USERNAME),
-				UserId:                      viper.GetString(ENV_VAR_USER_ID),
-				Password:                    viper.GetString(ENV_VAR_PASSWORD),
-				ProjectName:                 viper.GetString(ENV_VAR_PROJECT_NAME),
-				ProjectId:                   viper.GetString(ENV_VAR_PROJECT_ID),
-				UserDomainName:              viper.GetString(ENV_VAR_USER_DOMAIN_NAME),
-				UserDomainId:                viper.GetString(ENV_VAR_USER_DOMAIN_ID),
-				ProjectDomainName:           viper.GetString(ENV_VAR_PROJECT_DOMAIN_NAME),
-				ProjectDomainId:             viper.GetString(ENV_VAR_PROJECT_DOMAIN_ID),
-				ApplicationCredentialID:     viper.GetString(ENV_VAR_APPLICATION_CREDENTIAL_ID),
-				ApplicationCredentialName:   viper.GetString(ENV_VAR_APPLICATION_CREDENTIAL_NAME),
-				ApplicationCredentialSecret: viper.GetString(ENV_VAR_APPLICATION_CREDENTIAL_SECRET),
+		if viper.GetInt("automation-execute-batch-size") > 0 {
+			// batches are always watched
+			err := setupExecuteReauthentication(cmd)
+			if err != nil {
+				return err
 			}
-			ExecuteAuthV3 = auth.AuthenticationV3(ExecuteAuthOps)
-			// force reauthenticate with password and keep values
-			err := setupRestClient(cmd, &ExecuteAuthV3, true)
+			err = resolveAutomationId(FLAG_AUTOMATION_ID, "automation-execute-name", args)
 			if err != nil {
 				return err
 			}
 
+			// check the nodes matching the selector
+			confirmed, err := confirmAutomationRun(cmd)
+			if err != nil || !confirmed {
+				return err
+			}
+
+			return automationRunBatches(cmd)
+		} else if viper.GetBool("watch") {
+			err := setupExecuteReauthentication(cmd)
+			if err != nil {
+				return err
+			}
+			err = resolveAutomationId(FLAG_AUTOMATION_ID, "automation-execute-name", args)
+			if err != nil {
+				return err
+			}
+
+			// check the nodes matching the selector
+			confirmed, err := confirmAutomationRun(cmd)
+			if err != nil || !confirmed {
+				return err
+			}
+
 			response, err = automationRunWait(cmd)
+			if restclient.IsDryRun(err) {
+				return nil
+			}
 			if err != nil {
 				return err
 			}
@@ -85,9 +105,22 @@ var AutomationExecuteCmd = &cobra.Command{
 			if err != nil {
 				return err
 			}
+			err = resolveAutomationId(FLAG_AUTOMATION_ID, "automation-execute-name", args)
+			if err != nil {
+				return err
+			}
+
+			// check the nodes matching the selector
+			confirmed, err := confirmAutomationRun(cmd)
+			if err != nil || !confirmed {
+				return err
+			}
 
 			//run automation
 			response, err = automationRun()
+			if restclient.IsDryRun(err) {
+				return nil
+			}
 			if err != nil {
 				return err
 			}
@@ -132,27 +165,142 @@ func initAutomationExecuteCmdFlags() {
 	//flags
 	AutomationExecuteCmd.Flags().StringP(FLAG_AUTOMATION_ID, "", "", locales.AttributeDescription("automation-id"))
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag(FLAG_AUTOMATION_ID, AutomationExecuteCmd.Flags().Lookup(FLAG_AUTOMATION_ID)), "BindPFlag:")
+	AutomationExecuteCmd.Flags().StringP(FLAG_AUTOMATION, "", "", locales.AttributeDescription(FLAG_AUTOMATION))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-execute-name", AutomationExecuteCmd.Flags().Lookup(FLAG_AUTOMATION)), "BindPFlag:")
 	AutomationExecuteCmd.Flags().StringP(FLAG_SELECTOR, "", "", locales.AttributeDescription("selector"))
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag(FLAG_SELECTOR, AutomationExecuteCmd.Flags().Lookup(FLAG_SELECTOR)), "BindPFlag:")
 	AutomationExecuteCmd.Flags().BoolP("watch", "", false, locales.AttributeDescription("watch"))
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("watch", AutomationExecuteCmd.Flags().Lookup("watch")), "BindPFlag:")
+	AutomationExecuteCmd.Flags().BoolP("preview", "", false, locales.AttributeDescription("automation-execute-preview"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-execute-preview", AutomationExecuteCmd.Flags().Lookup("preview")), "BindPFlag:")
+	AutomationExecuteCmd.Flags().IntP("confirm-threshold", "", 0, locales.AttributeDescription("automation-execute-confirm-threshold"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-execute-confirm-threshold", AutomationExecuteCmd.Flags().Lookup("confirm-threshold")), "BindPFlag:")
+	AutomationExecuteCmd.Flags().BoolP("yes", "y", false, locales.AttributeDescription("yes"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-execute-yes", AutomationExecuteCmd.Flags().Lookup("yes")), "BindPFlag:")
+	AutomationExecuteCmd.Flags().IntP("batch-size", "", 0, locales.AttributeDescription("automation-execute-batch-size"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-execute-batch-size", AutomationExecuteCmd.Flags().Lookup("batch-size")), "BindPFlag:")
+	AutomationExecuteCmd.Flags().IntP("canary", "", 0, locales.AttributeDescription("automation-execute-canary"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-execute-canary", AutomationExecuteCmd.Flags().Lookup("canary")), "BindPFlag:")
+	AutomationExecuteCmd.Flags().IntP("max-failures", "", 0, locales.AttributeDescription("automation-execute-max-failures"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-execute-max-failures", AutomationExecuteCmd.Flags().Lookup("max-failures")), "BindPFlag:")
+	AutomationExecuteCmd.Flags().DurationP("pause", "", 0, locales.AttributeDescription("automation-execute-pause"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-execute-pause", AutomationExecuteCmd.Flags().Lookup("pause")), "BindPFlag:")
+}
+
+// setupExecuteReauthentication keeps the auth options to be able to reauthenticate while watching long running
+// automations and forces a new authentication.
+func setupExecuteReauthentication(cmd *cobra.Command) error {
+	// keep the auth options for reauthentication
+	ExecuteAuthOps = auth.AuthOptions{
+		IdentityEndpoint:            viper.GetString(ENV_VAR_AUTH_URL),
+		Username:                    viper.GetString(ENV_VAR_USERNAME),
+		UserId:                      viper.GetString(ENV_VAR_USER_ID),
+		Password:                    viper.GetString(ENV_VAR_PASSWORD),
+		ProjectName:                 viper.GetString(ENV_VAR_PROJECT_NAME),
+		ProjectId:                   viper.GetString(ENV_VAR_PROJECT_ID),
+		UserDomainName:              viper.GetString(ENV_VAR_USER_DOMAIN_NAME),
+		UserDomainId:                viper.GetString(ENV_VAR_USER_DOMAIN_ID),
+		ProjectDomainName:           viper.GetString(ENV_VAR_PROJECT_DOMAIN_NAME),
+		ProjectDomainId:             viper.GetString(ENV_VAR_PROJECT_DOMAIN_ID),
+		ApplicationCredentialID:     viper.GetString(ENV_VAR_APPLICATION_CREDENTIAL_ID),
+		ApplicationCredentialName:   viper.GetString(ENV_VAR_APPLICATION_CREDENTIAL_NAME),
+		ApplicationCredentialSecret: viper.GetString(ENV_VAR_APPLICATION_CREDENTIAL_SECRET),
+	}
+	ExecuteAuthV3 = auth.AuthenticationV3(ExecuteAuthOps)
+	// force reauthenticate with password and keep values
+	return setupRestClient(cmd, &ExecuteAuthV3, true)
 }
 
-func setupAutomationRun() error {
-	// check required automation id
-	if len(viper.GetString(FLAG_AUTOMATION_ID)) == 0 {
+func setupAutomationRun(args []string) error {
+	// check required automation id or name
+	if len(viper.GetString(FLAG_AUTOMATION_ID)) == 0 && len(viper.GetString("automation-execute-name")) == 0 && len(args) == 0 {
 		return errors.New(locales.ErrorMessages("automation-id-missing"))
 	}
 	// check selector
-	if len(viper.GetString(FLAG_AUTOMATION_ID)) == 0 {
+	if len(viper.GetString(FLAG_SELECTOR)) == 0 {
 		return errors.New(locales.ErrorMessages("automation-selector-missing"))
 	}
+	if err := checkSelector(viper.GetString(FLAG_SELECTOR)); err != nil {
+		return err
+	}
+	// check batch options
+	if viper.GetInt("automation-execute-batch-size") < 0 || viper.GetInt("automation-execute-canary") < 0 || viper.GetInt("automation-execute-max-failures") < 0 {
+		return errors.New(locales.ErrorMessages("automation-batch-invalid"))
+	}
+	if viper.GetInt("automation-execute-batch-size") == 0 && (viper.GetInt("automation-execute-canary") > 0 || viper.GetInt("automation-execute-max-failures") > 0 || viper.GetDuration("automation-execute-pause") > 0) {
+		return errors.New(locales.ErrorMessages("automation-batch-size-missing"))
+	}
 
 	return nil
 }
 
+// confirmAutomationRun shows the nodes matching the selector when the preview is requested or the amount of nodes
+// exceeds the confirm threshold and asks for confirmation unless --yes is given.
+func confirmAutomationRun(cmd *cobra.Command) (bool, error) {
+	preview := viper.GetBool("automation-execute-preview")
+	threshold := viper.GetInt("automation-execute-confirm-threshold")
+	if !preview && threshold <= 0 {
+		return true, nil
+	}
+
+	selector := viper.GetString(FLAG_SELECTOR)
+	nodes, err := nodeListBySelector(selector)
+	if err != nil {
+		return false, err
+	}
+	if len(nodes) == 0 {
+		return false, fmt.Errorf(locales.ErrorMessages("selector-no-nodes"), selector)
+	}
+	if !preview && len(nodes) <= threshold {
+		return true, nil
+	}
+
+	// show the matching nodes
+	printer := print.Print{Data: nodes}
+	tablePrint, err := printer.TableList([]string{"agent_id", "display_name", "organization", "project", "updated_at"})
+	if err != nil {
+		return false, err
+	}
+	// the preview goes to the stderr to keep the standard output for the run
+	cmd.Println(tablePrint)
+	cmd.Printf("Selector %s matches %d nodes.\n", selector, len(nodes))
+
+	if viper.GetBool("automation-execute-yes") {
+		return true, nil
+	}
+	confirmed, err := confirm(cmd, fmt.Sprintf("Execute automation %s on %d nodes?", viper.GetString(FLAG_AUTOMATION_ID), len(nodes)))
+	if err != nil {
+		return false, err
+	}
+	if !confirmed {
+		cmd.Println("Automation execution cancelled.")
+	}
+	return confirmed, nil
+}
+
+// confirm asks the user for a yes or no answer on the standard input. Without an answer an error is returned
+// so non interactive usage has to skip the confirmation with --yes.
+func confirm(cmd *cobra.Command, question string) (bool, error) {
+	cmd.Printf("%s [y/N]: ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && (err != io.EOF || len(answer) == 0) {
+		cmd.Println()
+		return false, errors.New(locales.ErrorMessages("confirmation-missing"))
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
 func automationRun() (string, error) {
-	run := Run{AutomationId: viper.GetString(FLAG_AUTOMATION_ID), Selector: viper.GetString(FLAG_SELECTOR)}
+	return createAutomationRun(viper.GetString(FLAG_AUTOMATION_ID), viper.GetString(FLAG_SELECTOR))
+}
+
+func createAutomationRun(automationId, selector string) (string, error) {
+	run := Run{AutomationId: automationId, Selector: selector}
 	// convert to Json
 	body, err := json.Marshal(run)
 	if err != nil {
@@ -168,6 +316,12 @@ func automationRun() (string, error) {
 	return response, nil
 }
 
+// ErrRunTimeout is returned when a run does not finish in the given time
+var ErrRunTimeout = errors.New("timeout waiting for the automation run")
+
+// ErrRunFailed is returned when a run finishes in the failed state
+var ErrRunFailed = errors.New(locales.ErrorMessages("automation-run-failed"))
+
 type AutomationRun struct {
 	Id    string   `json:"id"`
 	State string   `json:"state"`
@@ -176,22 +330,43 @@ type AutomationRun struct {
 
 func automationRunWait(cmd *cobra.Command) (string, error) {
 	//run automation
+	runData, err := automationRunRetry(viper.GetString(FLAG_AUTOMATION_ID), viper.GetString(FLAG_SELECTOR))
+	if err != nil {
+		return "", err
+	}
+
+	response, _, err := waitAutomationRun(cmd, runData, 0)
+	return response, err
+}
+
+// automationRunRetry creates an automation run retrying on errors
+func automationRunRetry(automationId, selector string) (string, error) {
 	var runData string
 	var err error
 	err = retry(5, 30*time.Second, func() error {
-		runData, err = automationRun()
+		runData, err = createAutomationRun(automationId, selector)
+		if restclient.IsDryRun(err) {
+			// nothing was sent, no reason to retry
+			return stop{err}
+		}
 		return err
 	})
 	// retry error
 	if err != nil {
 		return "", err
 	}
+	return runData, nil
+}
 
+// waitAutomationRun follows the run until it is completed or failed and returns the last state of the run and
+// the amount of failed jobs. A failed run returns ErrRunFailed. A timeout greater than zero stops waiting with an
+// ErrRunTimeout. The run itself is not stopped.
+func waitAutomationRun(cmd *cobra.Command, runData string, timeout time.Duration) (string, int, error) {
 	// convert data to struct
 	automationRun := AutomationRun{}
-	err = helpers.JSONStringToStructure(runData, &automationRun)
+	err := helpers.JSONStringToStructure(runData, &automationRun)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
 	cmd.Printf("Automation run is created with id %s\n", automationRun.Id)
@@ -203,11 +378,21 @@ func automationRunWait(cmd *cobra.Command) (string, error) {
 
 	runningJobs := []string{}
 	jobsState := map[string]string{}
+	started := time.Now()
 	for ; true; <-tickChan.C {
+		// stop waiting
+		if timeout > 0 && time.Since(started) > timeout {
+			runStr, err := runShow(automationRun.Id)
+			if err != nil {
+				return "", 0, err
+			}
+			return runStr, jobsFailed(jobsState), ErrRunTimeout
+		}
+
 		// check if the token still valid
 		isExpired, err := tokenExpired()
 		if err != nil {
-			return "", err
+			return "", 0, err
 		}
 		if isExpired {
 			cmd.Println("WARNING: token expired.")
@@ -217,7 +402,7 @@ func automationRunWait(cmd *cobra.Command) (string, error) {
 			})
 			// retry error
 			if err != nil {
-				return "", err
+				return "", 0, err
 			}
 		}
 
@@ -229,7 +414,7 @@ func automationRunWait(cmd *cobra.Command) (string, error) {
 		})
 		// error from retry
 		if err != nil {
-			return "", err
+			return "", 0, err
 		}
 
 		switch automationRun.State {
@@ -257,7 +442,7 @@ func automationRunWait(cmd *cobra.Command) (string, error) {
 				})
 				// error from retry
 				if err != nil {
-					return "", err
+					return "", 0, err
 				}
 
 				if stateStr != jobsState[v] {
@@ -282,19 +467,20 @@ func automationRunWait(cmd *cobra.Command) (string, error) {
 				// get the last state of the run
 				runStr, err := runShow(automationRun.Id)
 				if err != nil {
-					return "", err
+					return "", 0, err
 				}
 				// force return error
-				return runStr, errors.New(locales.ErrorMessages("automation-run-failed"))
+				return runStr, jobsFailed(jobsState), ErrRunFailed
 			case RunCompleted:
 				cmd.Printf("Automation run %s %s. %d jobs succeeded\n", automationRun.Id, automationRun.State, len(automationRun.Jobs))
 				// return the last state of the run
-				return runShow(automationRun.Id)
+				runStr, err := runShow(automationRun.Id)
+				return runStr, 0, err
 			}
 			cmd.Printf("Automation run state %s\n", automationRun.State)
 		}
 	}
-	return "", nil
+	return "", 0, nil
 }
 
 func jobsFailed(jobsState map[string]string) int {
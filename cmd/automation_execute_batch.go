@@ -0,0 +1,204 @@
+// Copyright © 2016 Arturo Reuschenbach <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/print"
+	"github.com/sapcc/lyra-cli/restclient"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// BatchResult describes the automation run of one batch of nodes
+type BatchResult struct {
+	Batch      int      `json:"batch"`
+	Canary     bool     `json:"canary"`
+	RunId      string   `json:"run_id"`
+	State      string   `json:"state"`
+	Nodes      []string `json:"nodes"`
+	FailedJobs int      `json:"failed_jobs"`
+}
+
+// BatchSummary lists the executed batches and the nodes not touched
+type BatchSummary struct {
+	Batches        []BatchResult `json:"batches"`
+	UntouchedNodes []string      `json:"untouched_nodes"`
+	Halted         bool          `json:"halted"`
+}
+
+// automationRunBatches resolves the selector to nodes and runs the automation batch by batch. A canary batch
+// halts the rollout on any failure, the following batches when the failed jobs exceed the max failures.
+func automationRunBatches(cmd *cobra.Command) error {
+	nodes, err := nodeListBySelector(viper.GetString(FLAG_SELECTOR))
+	if err != nil {
+		return err
+	}
+	ids := nodeIds(nodes)
+	if len(ids) == 0 {
+		return fmt.Errorf(locales.ErrorMessages("selector-no-nodes"), viper.GetString(FLAG_SELECTOR))
+	}
+
+	batches := splitBatches(ids, viper.GetInt("automation-execute-canary"), viper.GetInt("automation-execute-batch-size"))
+	canary := viper.GetInt("automation-execute-canary") > 0
+	maxFailures := viper.GetInt("automation-execute-max-failures")
+	pause := viper.GetDuration("automation-execute-pause")
+
+	summary := BatchSummary{Batches: []BatchResult{}, UntouchedNodes: []string{}}
+	failures := 0
+	for i, batch := range batches {
+		result := BatchResult{Batch: i + 1, Canary: canary && i == 0, Nodes: batch}
+		cmd.Printf("Batch %d of %d on %d nodes\n", result.Batch, len(batches), len(batch))
+
+		runData, err := automationRunRetry(viper.GetString(FLAG_AUTOMATION_ID), batchSelector(batch))
+		if restclient.IsDryRun(err) {
+			continue
+		}
+		if err != nil {
+			summary.UntouchedNodes = untouchedNodes(batches[i:])
+			summary.Halted = true
+			printBatchSummary(cmd, summary)
+			return err
+		}
+
+		var runStr string
+		runStr, result.FailedJobs, err = waitAutomationRun(cmd, runData, 0)
+		runState := AutomationRun{}
+		if jsonErr := helpers.JSONStringToStructure(runStr, &runState); jsonErr == nil {
+			result.RunId = runState.Id
+			result.State = runState.State
+		}
+		if err != nil && !errors.Is(err, ErrRunFailed) {
+			summary.Batches = append(summary.Batches, result)
+			summary.UntouchedNodes = untouchedNodes(batches[i+1:])
+			summary.Halted = true
+			printBatchSummary(cmd, summary)
+			return err
+		}
+		// a failed run without failed jobs counts the whole batch as failed
+		if err != nil && result.FailedJobs == 0 {
+			result.FailedJobs = len(batch)
+		}
+		summary.Batches = append(summary.Batches, result)
+		failures += result.FailedJobs
+
+		// check failure thresholds
+		if (result.Canary && result.FailedJobs > 0) || failures > maxFailures {
+			summary.UntouchedNodes = untouchedNodes(batches[i+1:])
+			summary.Halted = true
+			printBatchSummary(cmd, summary)
+			return fmt.Errorf(locales.ErrorMessages("automation-batch-halted"), result.Batch, failures)
+		}
+
+		if pause > 0 && i < len(batches)-1 {
+			cmd.Printf("Pausing %s before the next batch\n", pause)
+			time.Sleep(pause)
+		}
+	}
+
+	return printBatchSummary(cmd, summary)
+}
+
+func nodeIds(nodes []interface{}) []string {
+	ids := []string{}
+	for _, node := range nodes {
+		nodeMap, ok := node.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if id, ok := nodeMap["agent_id"].(string); ok && len(id) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// splitBatches puts the first canary nodes in an own batch and splits the rest in batches of the given size
+func splitBatches(ids []string, canary, size int) [][]string {
+	batches := [][]string{}
+	if canary > 0 {
+		if canary > len(ids) {
+			canary = len(ids)
+		}
+		batches = append(batches, ids[:canary])
+		ids = ids[canary:]
+	}
+	for len(ids) > 0 {
+		end := size
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batches = append(batches, ids[:end])
+		ids = ids[end:]
+	}
+	return batches
+}
+
+func batchSelector(ids []string) string {
+	quoted := []string{}
+	for _, id := range ids {
+		quoted = append(quoted, fmt.Sprintf("'%s'", id))
+	}
+	return fmt.Sprintf("@identity IN (%s)", strings.Join(quoted, ", "))
+}
+
+func untouchedNodes(batches [][]string) []string {
+	nodes := []string{}
+	for _, batch := range batches {
+		nodes = append(nodes, batch...)
+	}
+	return nodes
+}
+
+func printBatchSummary(cmd *cobra.Command, summary BatchSummary) error {
+	if viper.GetBool("json") {
+		printer := print.Print{Data: summary}
+		bodyPrint, err := printer.JSON()
+		if err != nil {
+			return err
+		}
+		fmt.Println(bodyPrint)
+		return nil
+	}
+
+	rows := []interface{}{}
+	for _, result := range summary.Batches {
+		rows = append(rows, map[string]interface{}{
+			"batch":       result.Batch,
+			"canary":      result.Canary,
+			"run_id":      result.RunId,
+			"state":       result.State,
+			"nodes":       len(result.Nodes),
+			"failed_jobs": result.FailedJobs,
+		})
+	}
+	printer := print.Print{Data: rows}
+	tablePrint, err := printer.TableList([]string{"batch", "canary", "run_id", "state", "nodes", "failed_jobs"})
+	if err != nil {
+		return err
+	}
+	fmt.Println(tablePrint)
+
+	if summary.Halted {
+		cmd.Printf("Rollout halted. %d nodes not touched: %s\n", len(summary.UntouchedNodes), strings.Join(summary.UntouchedNodes, ", "))
+	}
+	return nil
+}
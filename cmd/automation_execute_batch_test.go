@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	auth "github.com/sapcc/go-openstack-auth"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/selector"
+)
+
+type automationBatchServer struct {
+	*httptest.Server
+	mutex     sync.Mutex
+	selectors []string
+}
+
+// newAutomationBatchServer fails the runs with the given numbers starting with 1
+func newAutomationBatchServer(t *testing.T, failedRuns ...int) *automationBatchServer {
+	server := &automationBatchServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/agents", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `[{"agent_id":"n1"},{"agent_id":"n2"},{"agent_id":"n3"},{"agent_id":"n4"},{"agent_id":"n5"}]`)
+	})
+	mux.HandleFunc("/api/v1/runs", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		run := Run{}
+		if err := json.Unmarshal(data, &run); err != nil {
+			t.Error(err.Error())
+		}
+		server.mutex.Lock()
+		server.selectors = append(server.selectors, run.Selector)
+		id := len(server.selectors)
+		server.mutex.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"%d","state":"preparing","jobs":null}`, id)
+	})
+	mux.HandleFunc("/api/v1/runs/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/api/v1/runs/")
+		state := RunCompleted
+		for _, failed := range failedRuns {
+			if id == fmt.Sprint(failed) {
+				state = RunFailed
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"%s","state":"%s","jobs":["job%s"]}`, id, state, id)
+	})
+	server.Server = httptest.NewServer(mux)
+	return server
+}
+
+func automationExecuteBatch(server *automationBatchServer, flags string) resulter {
+	// mock interface for authenticationt test to return mocked endopoints and tokens and test method can use user authentication params to run
+	auth.AuthenticationV3 = newMockAuthenticationV3(server.Server)
+
+	ResetFlags()
+	return FullCmdTester(RootCmd, fmt.Sprintf("lyra automation execute --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --auth-url=%s --user-id=%s --project-id=%s --password=%s --automation-id=74 --selector=pool=green %s", server.URL, server.URL, "token123", "some_test_url", "miau", "bup", "123456789", flags))
+}
+
+func TestAutomationExecuteBatches(t *testing.T) {
+	server := newAutomationBatchServer(t)
+	defer server.Close()
+
+	resulter := automationExecuteBatch(server, "--batch-size=2 --canary=1")
+	if resulter.Error != nil {
+		t.Errorf(`Command expected to not get an error: %s`, resulter.Error)
+	}
+	want := []string{"@identity IN ('n1')", "@identity IN ('n2', 'n3')", "@identity IN ('n4', 'n5')"}
+	if !reflect.DeepEqual(server.selectors, want) {
+		t.Errorf("Expected selectors %#v, Got %#v", want, server.selectors)
+	}
+	if !strings.Contains(resulter.Output, "FAILED JOBS") {
+		t.Errorf("Command summary doesn't match. \n \n %s", StringDiff(resulter.Output, "FAILED JOBS"))
+	}
+}
+
+func TestAutomationExecuteBatchesCanaryFailed(t *testing.T) {
+	server := newAutomationBatchServer(t, 1)
+	defer server.Close()
+
+	resulter := automationExecuteBatch(server, "--batch-size=2 --canary=1 --max-failures=10")
+	errorMsg := fmt.Sprintf(locales.ErrorMessages("automation-batch-halted"), 1, 1)
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+	if !strings.Contains(resulter.ErrorOutput, "4 nodes not touched: n2, n3, n4, n5") {
+		t.Errorf("Command summary doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, "4 nodes not touched: n2, n3, n4, n5"))
+	}
+	if len(server.selectors) != 1 {
+		t.Errorf("Expected 1 run to be created, got %d", len(server.selectors))
+	}
+}
+
+func TestAutomationExecuteBatchesMaxFailures(t *testing.T) {
+	server := newAutomationBatchServer(t, 2)
+	defer server.Close()
+
+	resulter := automationExecuteBatch(server, "--batch-size=2 --max-failures=1 --json")
+	errorMsg := fmt.Sprintf(locales.ErrorMessages("automation-batch-halted"), 2, 2)
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+	summary := BatchSummary{}
+	if err := json.Unmarshal([]byte(resulter.Output), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Batches) != 2 || !summary.Halted || !reflect.DeepEqual(summary.UntouchedNodes, []string{"n5"}) {
+		t.Errorf("Command summary doesn't match. \n \n %#v", summary)
+	}
+}
+
+func TestAutomationExecuteCanaryWithoutBatchSize(t *testing.T) {
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, "lyra automation execute --automation-id=74 --selector=pool=green --canary=1")
+	errorMsg := locales.ErrorMessages("automation-batch-size-missing")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+}
+
+func TestSplitBatches(t *testing.T) {
+	ids := []string{"n1", "n2", "n3", "n4", "n5"}
+	want := [][]string{{"n1", "n2"}, {"n3", "n4", "n5"}}
+	if result := splitBatches(ids, 2, 3); !reflect.DeepEqual(result, want) {
+		t.Errorf("Expected %#v, Got %#v", want, result)
+	}
+	want = [][]string{{"n1", "n2"}, {"n3", "n4"}, {"n5"}}
+	if result := splitBatches(ids, 0, 2); !reflect.DeepEqual(result, want) {
+		t.Errorf("Expected %#v, Got %#v", want, result)
+	}
+}
+
+func TestBatchSelectorValid(t *testing.T) {
+	expression := batchSelector([]string{"886ea868-3f5e-4c7a-9c8b-2a3b4c5d6e7f", "n2"})
+	if err := selector.Validate(expression); err != nil {
+		t.Fatalf("Expected %q to be valid, got %s", expression, err)
+	}
+	node, _ := selector.Parse(expression)
+	if !selector.Evaluate(node, selector.Target{Facts: map[string]interface{}{"identity": "n2"}}) {
+		t.Errorf("Expected %q to match the node n2", expression)
+	}
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sapcc/lyra-cli/locales"
+)
+
+type automationExecutePreviewServer struct {
+	*httptest.Server
+	runs int
+}
+
+func newAutomationExecutePreviewServer(t *testing.T, agents string) *automationExecutePreviewServer {
+	server := &automationExecutePreviewServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/agents", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") != "pool=green" {
+			t.Errorf("Expected selector pool=green, got %q", r.URL.Query().Get("q"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, agents)
+	})
+	mux.HandleFunc("/api/v1/runs", func(w http.ResponseWriter, r *http.Request) {
+		server.runs++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"id":"109829","automation_id":"74","selector":"pool=green","state":"preparing"}`)
+	})
+	server.Server = httptest.NewServer(mux)
+	return server
+}
+
+func automationExecutePreview(t *testing.T, server *automationExecutePreviewServer, stdin, flags string) resulter {
+	// keep backup of the real stdin
+	oldStdin := os.Stdin
+	defer func() { os.Stdin = oldStdin }()
+	if _, err := pipeToStdin(stdin); err != nil {
+		t.Fatal(err)
+	}
+
+	ResetFlags()
+	return FullCmdTester(RootCmd, fmt.Sprintf("lyra automation execute --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=74 --selector=pool=green %s", server.URL, server.URL, "token123", flags))
+}
+
+const automationExecutePreviewAgents = `[{"agent_id":"node1","display_name":"web1"},{"agent_id":"node2","display_name":"web2"},{"agent_id":"node3","display_name":"web3"}]`
+
+func TestAutomationExecutePreviewWithYes(t *testing.T) {
+	server := newAutomationExecutePreviewServer(t, automationExecutePreviewAgents)
+	defer server.Close()
+
+	resulter := automationExecutePreview(t, server, "", "--preview --yes")
+	if resulter.Error != nil {
+		t.Errorf(`Command expected to not get an error: %s`, resulter.Error)
+	}
+	for _, want := range []string{"node1", "web2", "node3"} {
+		if !strings.Contains(resulter.ErrorOutput, want) {
+			t.Errorf("Command preview doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, want))
+		}
+	}
+	if !strings.Contains(resulter.ErrorOutput, "Selector pool=green matches 3 nodes.") {
+		t.Errorf("Command preview doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, "Selector pool=green matches 3 nodes."))
+	}
+	if server.runs != 1 {
+		t.Errorf("Expected 1 run to be created, got %d", server.runs)
+	}
+}
+
+func TestAutomationExecutePreviewJSON(t *testing.T) {
+	server := newAutomationExecutePreviewServer(t, automationExecutePreviewAgents)
+	defer server.Close()
+
+	resulter := automationExecutePreview(t, server, "", "--preview --yes --json")
+	if resulter.Error != nil {
+		t.Fatalf(`Command expected to not get an error: %s`, resulter.Error)
+	}
+	// only the run is printed to the standard output
+	run := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(resulter.Output), &run); err != nil {
+		t.Errorf("Expected JSON output, got %s", resulter.Output)
+	}
+}
+
+func TestAutomationExecutePreviewConfirmed(t *testing.T) {
+	server := newAutomationExecutePreviewServer(t, automationExecutePreviewAgents)
+	defer server.Close()
+
+	resulter := automationExecutePreview(t, server, "y\n", "--preview")
+	if resulter.Error != nil {
+		t.Errorf(`Command expected to not get an error: %s`, resulter.Error)
+	}
+	if server.runs != 1 {
+		t.Errorf("Expected 1 run to be created, got %d", server.runs)
+	}
+}
+
+func TestAutomationExecutePreviewDeclined(t *testing.T) {
+	server := newAutomationExecutePreviewServer(t, automationExecutePreviewAgents)
+	defer server.Close()
+
+	resulter := automationExecutePreview(t, server, "n\n", "--preview")
+	if resulter.Error != nil {
+		t.Errorf(`Command expected to not get an error: %s`, resulter.Error)
+	}
+	if !strings.Contains(resulter.ErrorOutput, "Automation execution cancelled.") {
+		t.Errorf("Command output doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, "Automation execution cancelled."))
+	}
+	if server.runs != 0 {
+		t.Errorf("Expected no run to be created, got %d", server.runs)
+	}
+}
+
+func TestAutomationExecuteBelowConfirmThreshold(t *testing.T) {
+	server := newAutomationExecutePreviewServer(t, automationExecutePreviewAgents)
+	defer server.Close()
+
+	resulter := automationExecutePreview(t, server, "", "--confirm-threshold=3")
+	if resulter.Error != nil {
+		t.Errorf(`Command expected to not get an error: %s`, resulter.Error)
+	}
+	if server.runs != 1 {
+		t.Errorf("Expected 1 run to be created, got %d", server.runs)
+	}
+}
+
+func TestAutomationExecuteAboveConfirmThresholdNonInteractive(t *testing.T) {
+	server := newAutomationExecutePreviewServer(t, automationExecutePreviewAgents)
+	defer server.Close()
+
+	resulter := automationExecutePreview(t, server, "", "--confirm-threshold=2")
+	errorMsg := locales.ErrorMessages("confirmation-missing")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+	if server.runs != 0 {
+		t.Errorf("Expected no run to be created, got %d", server.runs)
+	}
+}
+
+func TestAutomationExecutePreviewNoNodes(t *testing.T) {
+	server := newAutomationExecutePreviewServer(t, `[]`)
+	defer server.Close()
+
+	resulter := automationExecutePreview(t, server, "", "--preview --yes")
+	errorMsg := fmt.Sprintf(locales.ErrorMessages("selector-no-nodes"), "pool=green")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+	if server.runs != 0 {
+		t.Errorf("Expected no run to be created, got %d", server.runs)
+	}
+}
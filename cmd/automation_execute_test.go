@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 
 	auth "github.com/sapcc/go-openstack-auth"
+	"github.com/sapcc/lyra-cli/locales"
 )
 
 func resetAutomationLExecute() {
@@ -232,3 +234,47 @@ func TestAutomationExecuteWatchFailed(t *testing.T) {
 		t.Errorf("Command expected to get an error. \n \n %s", resulter.Error)
 	}
 }
+
+func TestAutomationExecuteByName(t *testing.T) {
+	requests := []string{}
+	testServer := newAutomationNameServer(t, &requests)
+	defer testServer.Close()
+	// mock interface for authenticationt test to return mocked endopoints and tokens and test method can use user authentication params to run
+	auth.AuthenticationV3 = newMockAuthenticationV3(testServer)
+
+	// reset stuff
+	resetAutomationLExecute()
+
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation execute chef-base --auth-url=%s --user-id=%s --project-id=%s --password=%s --selector=%s", "some_test_url", "miau", "bup", "123456789", "@identity=886ea868-ba06-42f8-9bde-eb1a848938"))
+	if resulter.Error != nil {
+		t.Errorf("Command expected to not get an error. \n \n %s", resulter.Error)
+	}
+	want := []string{"GET /api/v1/automations", "POST /api/v1/runs"}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("Expected requests %q, got %q", want, requests)
+	}
+}
+
+func TestAutomationExecuteMissingSelector(t *testing.T) {
+	requests := []string{}
+	testServer := newAutomationNameServer(t, &requests)
+	defer testServer.Close()
+	// mock interface for authenticationt test to return mocked endopoints and tokens and test method can use user authentication params to run
+	auth.AuthenticationV3 = newMockAuthenticationV3(testServer)
+
+	// reset stuff
+	resetAutomationLExecute()
+
+	// the automation id is given, only the selector is missing
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation execute --auth-url=%s --user-id=%s --project-id=%s --password=%s --automation-id=%s", "some_test_url", "miau", "bup", "123456789", "74"))
+	if resulter.Error == nil {
+		t.Error("Command expected to get an error")
+	}
+	want := locales.ErrorMessages("automation-selector-missing")
+	if !strings.Contains(resulter.ErrorOutput, want) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, want))
+	}
+	if len(requests) > 0 {
+		t.Errorf("Expected no requests, got %q", requests)
+	}
+}
@@ -15,63 +15,39 @@
 package cmd
 
 import (
-	"errors"
-	"fmt"
 	"net/url"
 	"path"
 
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
-	"github.com/sapcc/lyra-cli/print"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 // showCmd represents the show command
 var AutomationShowCmd = &cobra.Command{
-	Use:   "show",
-	Short: locales.CmdShortDescription("automation-show"),
-	PreRunE: func(cmd *cobra.Command, args []string) error {
-		// check required automation id
-		if len(viper.GetString("show-automation-id")) == 0 {
-			return errors.New("no automation id given")
-		}
-
-		return nil
-	},
+	Use:               "show [NAME|ID]...",
+	Short:             locales.CmdShortDescription("automation-show"),
+	ValidArgsFunction: automationNameCompletion,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// show automation
-		response, err := automationShow()
-		if err != nil {
-			return err
-		}
-
-		// convert data to struct
-		var dataStruct map[string]interface{}
-		err = helpers.JSONStringToStructure(response, &dataStruct)
+		// resolve the automation ids from the names
+		ids, err := resolveAutomationIds("show-automation-id", "show-automation-name", args)
 		if err != nil {
 			return err
 		}
 
-		// print the data out
-		printer := print.Print{Data: dataStruct}
-		var bodyPrint string
-		if viper.GetBool("json") {
-			bodyPrint, err = printer.JSON()
-			if err != nil {
-				return err
-			}
-		} else {
-			bodyPrint, err = printer.Table()
+		// show automations
+		responses := []string{}
+		for _, id := range ids {
+			response, err := automationShow(id)
 			if err != nil {
 				return err
 			}
+			responses = append(responses, response)
 		}
 
-		// Print response
-		fmt.Println(bodyPrint)
-
-		return nil
+		// print the data out
+		return printShowResults(responses)
 	},
 }
 
@@ -83,11 +59,13 @@ func initAutomationShowCmdFlags() {
 	AutomationCmd.AddCommand(AutomationShowCmd)
 	AutomationShowCmd.Flags().StringP("automation-id", "", "", locales.AttributeDescription("automation-id"))
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("show-automation-id", AutomationShowCmd.Flags().Lookup("automation-id")), "BindPFlag:")
+	AutomationShowCmd.Flags().StringP(FLAG_AUTOMATION, "", "", locales.AttributeDescription(FLAG_AUTOMATION))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("show-automation-name", AutomationShowCmd.Flags().Lookup(FLAG_AUTOMATION)), "BindPFlag:")
 }
 
-func automationShow() (string, error) {
+func automationShow(id string) (string, error) {
 	automationService := RestClient.Services["automation"]
-	response, _, err := automationService.Get(path.Join("automations", viper.GetString("show-automation-id")), url.Values{}, false)
+	response, _, err := automationService.Get(path.Join("automations", id), url.Values{}, false)
 	if err != nil {
 		return "", err
 	}
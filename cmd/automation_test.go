@@ -1,8 +1,14 @@
 package cmd
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/sapcc/lyra-cli/locales"
 )
 
 func resetAutomation() {
@@ -21,3 +27,68 @@ func TestAutomationShouldDoNothing(t *testing.T) {
 		t.Error(`Command response body doesn't match.'`)
 	}
 }
+
+func newAutomationNameServer(t *testing.T, requests *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, fmt.Sprint(r.Method, " ", r.URL.Path))
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/automations" {
+			fmt.Fprintln(w, `[{"id":1,"name":"chef-base"},{"id":2,"name":"deploy-app"},{"id":3,"name":"deploy-app"}]`)
+			return
+		}
+		fmt.Fprintln(w, `{"id":1,"name":"chef-base"}`)
+	}))
+}
+
+func TestAutomationResolveName(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+	}{
+		{"flag", "lyra automation show --automation chef-base", []string{"GET /api/v1/automations", "GET /api/v1/automations/1"}},
+		{"positional", "lyra automation show chef-base", []string{"GET /api/v1/automations", "GET /api/v1/automations/1"}},
+		{"numeric positional", "lyra automation show 7", []string{"GET /api/v1/automations/7"}},
+		{"id and name", "lyra automation show --automation-id 7 --automation chef-base", []string{"GET /api/v1/automations", "GET /api/v1/automations/7", "GET /api/v1/automations/1"}},
+		{"delete", "lyra automation delete --automation chef-base", []string{"GET /api/v1/automations", "DELETE /api/v1/automations/1"}},
+	}
+	for _, test := range tests {
+		requests := []string{}
+		server := newAutomationNameServer(t, &requests)
+
+		ResetFlags()
+		resulter := FullCmdTester(RootCmd, fmt.Sprintf("%s --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s", test.command, server.URL, server.URL, "token123"))
+		server.Close()
+		if resulter.Error != nil {
+			t.Errorf("%s: command expected to not get an error: %s", test.name, resulter.Error)
+		}
+		if !reflect.DeepEqual(requests, test.want) {
+			t.Errorf("%s: expected requests %q, got %q", test.name, test.want, requests)
+		}
+	}
+}
+
+func TestAutomationResolveNameErrors(t *testing.T) {
+	requests := []string{}
+	server := newAutomationNameServer(t, &requests)
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation show --automation unknown --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s", server.URL, server.URL, "token123"))
+	errorMsg := fmt.Sprintf(locales.ErrorMessages("automation-name-not-found"), "unknown")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+
+	ResetFlags()
+	resulter = FullCmdTester(RootCmd, fmt.Sprintf("lyra automation delete deploy-app --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s", server.URL, server.URL, "token123"))
+	errorMsg = fmt.Sprintf(locales.ErrorMessages("automation-name-ambiguous"), "deploy-app", "2, 3")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+	for _, request := range requests {
+		if strings.HasPrefix(request, "DELETE") {
+			t.Errorf("Expected no automation to be deleted, got %s", request)
+		}
+	}
+}
@@ -19,25 +19,26 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"strings"
 
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
 	"github.com/sapcc/lyra-cli/print"
+	"github.com/sapcc/lyra-cli/restclient"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 // updateCmd represents the update command
 var AutomationUpdateChefAttributesCmd = &cobra.Command{
-	Use:   "attributes",
-	Short: locales.CmdShortDescription("automation-update-chef-attributes"),
-	Long:  locales.CmdLongDescription("automation-update-chef-attributes"),
+	Use:               "attributes [NAME|ID]",
+	Args:              cobra.MaximumNArgs(1),
+	Short:             locales.CmdShortDescription("automation-update-chef-attributes"),
+	Long:              locales.CmdLongDescription("automation-update-chef-attributes"),
+	ValidArgsFunction: automationNameCompletion,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		// check required automation id
-		if len(viper.GetString("automation-update-chef-attributes-automation-id")) == 0 {
-			return errors.New(locales.ErrorMessages("automation-id-missing"))
-		}
-		return nil
+		// resolve the automation id from the name if no id given
+		return resolveAutomationId("automation-update-chef-attributes-automation-id", "automation-update-chef-attributes-automation-name", args)
 	},
 
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -50,10 +51,17 @@ var AutomationUpdateChefAttributesCmd = &cobra.Command{
 		}
 
 		// update automation
-		response, err := automationUpdateChefAttributes(&chef)
+		response, err := automationUpdateChefAttributes(cmd, &chef)
+		if restclient.IsDryRun(err) {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
+		// update cancelled
+		if len(response) == 0 {
+			return nil
+		}
 
 		// convert data to struct
 		var dataStruct map[string]interface{}
@@ -92,11 +100,25 @@ func init() {
 func initAutomationUpdateChefAttributesCmdFlags() {
 	AutomationUpdateChefAttributesCmd.Flags().StringP("attributes", "", "", locales.AttributeDescription("automation-attributes"))
 	AutomationUpdateChefAttributesCmd.Flags().StringP("attributes-from-file", "", "", locales.AttributeDescription("automation-attributes-from-file"))
+	AutomationUpdateChefAttributesCmd.Flags().StringP("merge", "", "", locales.AttributeDescription("automation-attributes-merge"))
+	AutomationUpdateChefAttributesCmd.Flags().StringP("patch", "", "", locales.AttributeDescription("automation-attributes-patch"))
+	AutomationUpdateChefAttributesCmd.Flags().StringArray("set", nil, locales.AttributeDescription("automation-attributes-set"))
+	AutomationUpdateChefAttributesCmd.Flags().StringArray("unset", nil, locales.AttributeDescription("automation-attributes-unset"))
+	AutomationUpdateChefAttributesCmd.Flags().BoolP("preview", "", false, locales.AttributeDescription("automation-attributes-preview"))
+	AutomationUpdateChefAttributesCmd.Flags().BoolP("yes", "y", false, locales.AttributeDescription("yes"))
 	AutomationUpdateChefAttributesCmd.Flags().StringP(FLAG_AUTOMATION_ID, "", "", locales.AttributeDescription("automation-id"))
 
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-attributes", AutomationUpdateChefAttributesCmd.Flags().Lookup("attributes")), "BindPFlag:")
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-attributes-from-file", AutomationUpdateChefAttributesCmd.Flags().Lookup("attributes-from-file")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-attributes-merge", AutomationUpdateChefAttributesCmd.Flags().Lookup("merge")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-attributes-patch", AutomationUpdateChefAttributesCmd.Flags().Lookup("patch")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-attributes-set", AutomationUpdateChefAttributesCmd.Flags().Lookup("set")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-attributes-unset", AutomationUpdateChefAttributesCmd.Flags().Lookup("unset")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-attributes-preview", AutomationUpdateChefAttributesCmd.Flags().Lookup("preview")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-attributes-yes", AutomationUpdateChefAttributesCmd.Flags().Lookup("yes")), "BindPFlag:")
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-attributes-automation-id", AutomationUpdateChefAttributesCmd.Flags().Lookup("automation-id")), "BindPFlag:")
+	AutomationUpdateChefAttributesCmd.Flags().StringP(FLAG_AUTOMATION, "", "", locales.AttributeDescription(FLAG_AUTOMATION))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-attributes-automation-name", AutomationUpdateChefAttributesCmd.Flags().Lookup(FLAG_AUTOMATION)), "BindPFlag:")
 }
 
 func setupAutomationUpdateChefAttributes(chefObj *Chef) error {
@@ -122,7 +144,67 @@ func setupAutomationUpdateChefAttributes(chefObj *Chef) error {
 	return nil
 }
 
-func automationUpdateChefAttributes(chefObj *Chef) (string, error) {
+// editAutomationChefAttributes applies the merge patch, JSON patch, set and unset options in this order
+func editAutomationChefAttributes(attributes interface{}) (interface{}, error) {
+	var err error
+
+	// merge patch
+	if len(viper.GetString("automation-update-chef-attributes-merge")) > 0 {
+		var patch interface{}
+		err = helpers.JSONStringToStructure(viper.GetString("automation-update-chef-attributes-merge"), &patch)
+		if err != nil {
+			return nil, err
+		}
+		attributes, err = helpers.MergePatch(attributes, patch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// json patch
+	if len(viper.GetString("automation-update-chef-attributes-patch")) > 0 {
+		operations := []helpers.JSONPatchOperation{}
+		err = helpers.JSONStringToStructure(viper.GetString("automation-update-chef-attributes-patch"), &operations)
+		if err != nil {
+			return nil, err
+		}
+		attributes, err = helpers.JSONPatch(attributes, operations)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// set single values
+	for _, element := range viper.GetStringSlice("automation-update-chef-attributes-set") {
+		data := strings.SplitN(element, "=", 2)
+		if len(data) != 2 {
+			return nil, fmt.Errorf("%v is not a valid path=value pair", element)
+		}
+		attributes, err = helpers.SetPath(attributes, data[0], helpers.JSONValueFromString(data[1]))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// remove single values
+	for _, key := range viper.GetStringSlice("automation-update-chef-attributes-unset") {
+		attributes, err = helpers.UnsetPath(attributes, key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return attributes, nil
+}
+
+func automationChefAttributesEdited() bool {
+	return len(viper.GetString("automation-update-chef-attributes-merge")) > 0 ||
+		len(viper.GetString("automation-update-chef-attributes-patch")) > 0 ||
+		len(viper.GetStringSlice("automation-update-chef-attributes-set")) > 0 ||
+		len(viper.GetStringSlice("automation-update-chef-attributes-unset")) > 0
+}
+
+func automationUpdateChefAttributes(cmd *cobra.Command, chefObj *Chef) (string, error) {
 	automationService := RestClient.Services["automation"]
 	response, code, err := automationService.Get(path.Join("automations", viper.GetString("automation-update-chef-attributes-automation-id")), url.Values{}, false)
 	if err != nil {
@@ -138,9 +220,51 @@ func automationUpdateChefAttributes(chefObj *Chef) (string, error) {
 	if err := oldChef.Unmarshal(response); err != nil {
 		return "", err
 	}
+	storedErrs := oldChef.Validate()
+
+	// replace the attributes when given or when nothing should be edited
+	attributes := oldChef.Attributes
+	if len(viper.GetString("automation-update-chef-attributes")) > 0 || len(viper.GetString("automation-update-chef-attributes-from-file")) > 0 || !automationChefAttributesEdited() {
+		attributes = chefObj.Attributes
+	}
+
+	// apply the changes
+	attributes, err = editAutomationChefAttributes(attributes)
+	if err != nil {
+		return "", err
+	}
+
+	// preview the changes, without changes the stored automation is returned
+	diff := helpers.JSONDiff(oldChef.Attributes, attributes)
+	if len(diff) == 0 {
+		cmd.Println(locales.Messages("chef-attributes-unchanged"))
+		return response, nil
+	}
+	cmd.Println(locales.Messages("chef-attributes-changes"))
+	for _, line := range diff {
+		cmd.Println(line)
+	}
 
 	// change attributres
-	oldChef.Attributes = chefObj.Attributes
+	oldChef.Attributes = attributes
+
+	// validate before sending ignoring the problems already stored
+	err = newValidationErrors(storedErrs, oldChef.Validate())
+	if err != nil {
+		return "", err
+	}
+
+	// ask before sending when the preview is requested
+	if viper.GetBool("automation-update-chef-attributes-preview") && !viper.GetBool("automation-update-chef-attributes-yes") {
+		confirmed, err := confirm(cmd, locales.Messages("chef-attributes-confirm"))
+		if err != nil {
+			return "", err
+		}
+		if !confirmed {
+			cmd.Println(locales.Messages("chef-attributes-cancelled"))
+			return "", nil
+		}
+	}
 
 	body, err := oldChef.Marshal()
 	if err != nil {
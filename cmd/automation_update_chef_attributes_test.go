@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sapcc/lyra-cli/locales"
+)
+
+func automationUpdateChefAttributesServer(t *testing.T, want string) *httptest.Server {
+	responseBody := `{"id":34,"type":"Chef","name":"test","repository":"https://github.com/userId0123456789/automation-test.git","repository_revision":"master","timeout":3600,"run_list":["recipe[nginx]"],"chef_attributes":{"nginx":{"port":80,"ssl":true},"name":"web"}}`
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "PUT" {
+			data, _ := io.ReadAll(r.Body)
+			defer r.Body.Close()
+
+			sent := Chef{}
+			if err := sent.Unmarshal(string(data)); err != nil {
+				t.Error(err.Error())
+				return
+			}
+			attributes, _ := sent.Marshal()
+			eq, err := JsonDiff(fmt.Sprintf(`{"id":34,"type":"Chef","name":"test","repository":"https://github.com/userId0123456789/automation-test.git","repository_revision":"master","timeout":3600,"run_list":["recipe[nginx]"],"chef_attributes":%s}`, want), attributes)
+			if err != nil {
+				t.Error(err.Error())
+				return
+			}
+			if !eq {
+				t.Errorf("Request body doesn't match. \n \n %s", StringDiff(attributes, want))
+			}
+			fmt.Fprintln(w, string(data))
+			return
+		}
+		fmt.Fprintln(w, responseBody)
+	}))
+}
+
+func TestAutomationUpdateChefAttributesMissingId(t *testing.T) {
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation update chef attributes --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --merge=%s", "https://somewhere.com", "https://somewhere.com", "token123", `{"test":"test"}`))
+
+	errorMsg := locales.ErrorMessages("automation-id-missing")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		diffString := StringDiff(resulter.ErrorOutput, errorMsg)
+		t.Errorf("Command error doesn't match. \n \n %s", diffString)
+	}
+}
+
+func TestAutomationUpdateChefAttributesMerge(t *testing.T) {
+	server := automationUpdateChefAttributesServer(t, `{"nginx":{"port":8080,"ssl":true},"name":"web","new":"value"}`)
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation update chef attributes --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=34 --merge=%s", server.URL, server.URL, "token123", `{"nginx":{"port":8080},"new":"value"}`))
+	if resulter.Error != nil {
+		t.Error(fmt.Sprint(`Command expected to not get an error: `, resulter.Error))
+	}
+	for _, line := range []string{"~ nginx.port: 80 => 8080", "+ new: \"value\""} {
+		if !strings.Contains(resulter.ErrorOutput, line) {
+			t.Errorf("Command preview doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, line))
+		}
+	}
+}
+
+func TestAutomationUpdateChefAttributesPatch(t *testing.T) {
+	server := automationUpdateChefAttributesServer(t, `{"nginx":{"port":80},"name":"db"}`)
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation update chef attributes --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=34 --patch=%s", server.URL, server.URL, "token123", `[{"op":"remove","path":"/nginx/ssl"},{"op":"replace","path":"/name","value":"db"}]`))
+	if resulter.Error != nil {
+		t.Error(fmt.Sprint(`Command expected to not get an error: `, resulter.Error))
+	}
+	if !strings.Contains(resulter.ErrorOutput, "- nginx.ssl: true") {
+		t.Errorf("Command preview doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, "- nginx.ssl: true"))
+	}
+}
+
+func TestAutomationUpdateChefAttributesSetAndUnset(t *testing.T) {
+	server := automationUpdateChefAttributesServer(t, `{"nginx":{"port":443,"worker":{"count":4}}}`)
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation update chef attributes --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=34 --set=nginx.port=443 --set=nginx.worker.count=4 --unset=nginx.ssl --unset=name", server.URL, server.URL, "token123"))
+	if resulter.Error != nil {
+		t.Error(fmt.Sprint(`Command expected to not get an error: `, resulter.Error))
+	}
+}
+
+func TestAutomationUpdateChefAttributesUnsetMissingPath(t *testing.T) {
+	server := automationUpdateChefAttributesServer(t, `{}`)
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation update chef attributes --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=34 --unset=nginx.missing", server.URL, server.URL, "token123"))
+	if resulter.Error == nil {
+		t.Error(`Command expected to get an error`)
+	}
+}
+
+func TestAutomationUpdateChefAttributesConfirm(t *testing.T) {
+	responseBody := `{"id":34,"type":"Chef","name":"test","repository":"https://github.com/userId0123456789/automation-test.git","repository_revision":"master","timeout":3600,"run_list":["recipe[nginx]"],"chef_attributes":{"nginx":{"port":80}}}`
+	puts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "PUT" {
+			puts++
+		}
+		fmt.Fprintln(w, responseBody)
+	}))
+	defer server.Close()
+	command := fmt.Sprintf("lyra automation update chef attributes --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=34", server.URL, server.URL, "token123")
+
+	// nothing is sent without changes
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, command+" --set=nginx.port=80")
+	if resulter.Error != nil {
+		t.Error(fmt.Sprint(`Command expected to not get an error: `, resulter.Error))
+	}
+	if !strings.Contains(resulter.ErrorOutput, locales.Messages("chef-attributes-unchanged")) {
+		t.Errorf("Command preview doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, locales.Messages("chef-attributes-unchanged")))
+	}
+
+	// the preview is cancelled
+	ResetFlags()
+	if _, err := pipeToStdin("n\n"); err != nil {
+		t.Fatal(err)
+	}
+	resulter = FullCmdTester(RootCmd, command+" --set=nginx.port=8080 --preview")
+	if resulter.Error != nil {
+		t.Error(fmt.Sprint(`Command expected to not get an error: `, resulter.Error))
+	}
+	if !strings.Contains(resulter.ErrorOutput, locales.Messages("chef-attributes-cancelled")) {
+		t.Errorf("Command output doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, locales.Messages("chef-attributes-cancelled")))
+	}
+	if len(resulter.Output) > 0 {
+		t.Errorf("Expected nothing printed, got %q", resulter.Output)
+	}
+
+	// the preview is confirmed with --yes
+	ResetFlags()
+	resulter = FullCmdTester(RootCmd, command+" --set=nginx.port=8080 --preview --yes")
+	if resulter.Error != nil {
+		t.Error(fmt.Sprint(`Command expected to not get an error: `, resulter.Error))
+	}
+
+	if puts != 1 {
+		t.Errorf("Expected one update to be sent, got %d", puts)
+	}
+}
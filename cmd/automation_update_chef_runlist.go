@@ -5,34 +5,42 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"regexp"
+	"strings"
 
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
 	"github.com/sapcc/lyra-cli/print"
+	"github.com/sapcc/lyra-cli/restclient"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var AutomationUpdateChefRunlistCmd = &cobra.Command{
-	Use:   "runlist",
-	Short: locales.CmdShortDescription("automation-update-chef-runlist"),
-	Long:  locales.CmdLongDescription("automation-update-chef-runlist"),
+	Use:               "runlist [NAME|ID]",
+	Args:              cobra.MaximumNArgs(1),
+	Short:             locales.CmdShortDescription("automation-update-chef-runlist"),
+	Long:              locales.CmdLongDescription("automation-update-chef-runlist"),
+	ValidArgsFunction: automationNameCompletion,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		// check required automation id
-		if len(viper.GetString("automation-update-chef-runlist-automation-id")) == 0 {
-			return errors.New(locales.ErrorMessages("automation-id-missing"))
-		}
-		return nil
+		// resolve the automation id from the name if no id given
+		return resolveAutomationId("automation-update-chef-runlist-automation-id", "automation-update-chef-runlist-automation-name", args)
 	},
 
 	RunE: func(cmd *cobra.Command, args []string) error {
 		chef = Chef{}
 
 		// set chef runlist
-		chef.Runlist = helpers.StringToArray(viper.GetString("automation-update-chef-runlist"))
+		chef.Runlist = parseRunlist(viper.GetString("automation-update-chef-runlist"))
+		if err := checkRunlist(chef.Runlist); err != nil {
+			return err
+		}
 
 		// update automation
 		response, err := automationUpdateChefRunlist(&chef)
+		if restclient.IsDryRun(err) {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
@@ -73,9 +81,123 @@ func init() {
 
 func initAutomationUpdateChefRunlistCmdFlags() {
 	AutomationUpdateChefRunlistCmd.Flags().StringP("runlist", "", "", locales.AttributeDescription("automation-runlist"))
+	AutomationUpdateChefRunlistCmd.Flags().StringArray("add", nil, locales.AttributeDescription("automation-runlist-add"))
+	AutomationUpdateChefRunlistCmd.Flags().StringArray("remove", nil, locales.AttributeDescription("automation-runlist-remove"))
+	AutomationUpdateChefRunlistCmd.Flags().StringArray("move", nil, locales.AttributeDescription("automation-runlist-move"))
+	AutomationUpdateChefRunlistCmd.Flags().StringP("insert-before", "", "", locales.AttributeDescription("automation-runlist-insert-before"))
+	AutomationUpdateChefRunlistCmd.Flags().StringP("insert-after", "", "", locales.AttributeDescription("automation-runlist-insert-after"))
 	AutomationUpdateChefRunlistCmd.Flags().StringP(FLAG_AUTOMATION_ID, "", "", locales.AttributeDescription("automation-id"))
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-runlist", AutomationUpdateChefRunlistCmd.Flags().Lookup("runlist")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-runlist-add", AutomationUpdateChefRunlistCmd.Flags().Lookup("add")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-runlist-remove", AutomationUpdateChefRunlistCmd.Flags().Lookup("remove")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-runlist-move", AutomationUpdateChefRunlistCmd.Flags().Lookup("move")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-runlist-insert-before", AutomationUpdateChefRunlistCmd.Flags().Lookup("insert-before")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-runlist-insert-after", AutomationUpdateChefRunlistCmd.Flags().Lookup("insert-after")), "BindPFlag:")
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-runlist-automation-id", AutomationUpdateChefRunlistCmd.Flags().Lookup("automation-id")), "BindPFlag:")
+	AutomationUpdateChefRunlistCmd.Flags().StringP(FLAG_AUTOMATION, "", "", locales.AttributeDescription(FLAG_AUTOMATION))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-chef-runlist-automation-name", AutomationUpdateChefRunlistCmd.Flags().Lookup(FLAG_AUTOMATION)), "BindPFlag:")
+}
+
+var runlistEntryRegex = regexp.MustCompile(`^(recipe|role)\[[^\[\],\s]+\]$`)
+
+// parseRunlist splits the comma separated runlist. Spaces around the entries and empty entries are removed.
+func parseRunlist(data string) []string {
+	runlist := []string{}
+	for _, entry := range helpers.StringToArray(data) {
+		if entry = strings.TrimSpace(entry); len(entry) > 0 {
+			runlist = append(runlist, entry)
+		}
+	}
+	return runlist
+}
+
+// checkRunlist checks that all entries follow the recipe[...] or role[...] syntax
+func checkRunlist(runlist []string) error {
+	invalid := []string{}
+	for _, entry := range runlist {
+		if !runlistEntryRegex.MatchString(entry) {
+			invalid = append(invalid, entry)
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid runlist entries %q. Entries should look like recipe[cookbook::recipe] or role[name]", invalid)
+	}
+	return nil
+}
+
+func automationChefRunlistEdited() bool {
+	return len(viper.GetStringSlice("automation-update-chef-runlist-add")) > 0 ||
+		len(viper.GetStringSlice("automation-update-chef-runlist-remove")) > 0 ||
+		len(viper.GetStringSlice("automation-update-chef-runlist-move")) > 0
+}
+
+// editAutomationChefRunlist removes, moves and adds entries in this order. Moved and added entries are
+// inserted before or after the given entry or appended to the end.
+func editAutomationChefRunlist(runlist []string) ([]string, error) {
+	add := trimRunlistEntries(viper.GetStringSlice("automation-update-chef-runlist-add"))
+	remove := trimRunlistEntries(viper.GetStringSlice("automation-update-chef-runlist-remove"))
+	move := trimRunlistEntries(viper.GetStringSlice("automation-update-chef-runlist-move"))
+	before := strings.TrimSpace(viper.GetString("automation-update-chef-runlist-insert-before"))
+	after := strings.TrimSpace(viper.GetString("automation-update-chef-runlist-insert-after"))
+
+	if len(before) > 0 && len(after) > 0 {
+		return nil, errors.New("insert-before and insert-after can not be used together")
+	}
+	if err := checkRunlist(add); err != nil {
+		return nil, err
+	}
+
+	result := append([]string{}, runlist...)
+
+	// remove and moved entries
+	for _, entry := range append(append([]string{}, remove...), move...) {
+		i := runlistIndex(result, entry)
+		if i < 0 {
+			return nil, fmt.Errorf("entry %s not found in runlist", entry)
+		}
+		result = append(result[:i], result[i+1:]...)
+	}
+
+	// check new entries
+	for _, entry := range add {
+		if runlistIndex(result, entry) >= 0 {
+			return nil, fmt.Errorf("entry %s already in runlist", entry)
+		}
+	}
+
+	// find the position to insert
+	position := len(result)
+	if len(before) > 0 || len(after) > 0 {
+		anchor := before + after
+		position = runlistIndex(result, anchor)
+		if position < 0 {
+			return nil, fmt.Errorf("entry %s not found in runlist", anchor)
+		}
+		if len(after) > 0 {
+			position++
+		}
+	}
+
+	// insert moved and added entries
+	entries := append(append([]string{}, move...), add...)
+	return append(result[:position], append(entries, result[position:]...)...), nil
+}
+
+func trimRunlistEntries(entries []string) []string {
+	result := []string{}
+	for _, entry := range entries {
+		result = append(result, strings.TrimSpace(entry))
+	}
+	return result
+}
+
+func runlistIndex(runlist []string, entry string) int {
+	for i, v := range runlist {
+		if v == entry {
+			return i
+		}
+	}
+	return -1
 }
 
 func automationUpdateChefRunlist(chefObj *Chef) (string, error) {
@@ -95,9 +217,25 @@ func automationUpdateChefRunlist(chefObj *Chef) (string, error) {
 	if err := oldChef.Unmarshal(response); err != nil {
 		return "", err
 	}
+	storedErrs := oldChef.Validate()
+
+	// replace the runlist when given or when nothing should be edited
+	runlist := oldChef.Runlist
+	if len(chefObj.Runlist) > 0 || !automationChefRunlistEdited() {
+		runlist = chefObj.Runlist
+	}
 
 	// change runlist
-	oldChef.Runlist = chefObj.Runlist
+	oldChef.Runlist, err = editAutomationChefRunlist(runlist)
+	if err != nil {
+		return "", err
+	}
+
+	// validate before sending ignoring the problems already stored
+	err = newValidationErrors(storedErrs, oldChef.Validate())
+	if err != nil {
+		return "", err
+	}
 
 	// convert to Json
 	body, err := oldChef.Marshal()
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func automationUpdateChefRunlistServer(t *testing.T, want []string) *httptest.Server {
+	responseBody := `{"id":34,"type":"Chef","name":"test","repository":"https://github.com/userId0123456789/automation-test.git","repository_revision":"master","timeout":3600,"run_list":["recipe[base]","recipe[nginx::default]","role[staging]"]}`
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "PUT" {
+			data, _ := io.ReadAll(r.Body)
+			defer r.Body.Close()
+
+			sent := Chef{}
+			if err := sent.Unmarshal(string(data)); err != nil {
+				t.Error(err.Error())
+				return
+			}
+			if !reflect.DeepEqual(sent.Runlist, want) {
+				t.Errorf("Expected runlist %#v, Got %#v", want, sent.Runlist)
+			}
+			fmt.Fprintln(w, string(data))
+			return
+		}
+		fmt.Fprintln(w, responseBody)
+	}))
+}
+
+func TestAutomationUpdateChefRunlistEdit(t *testing.T) {
+	table := []struct {
+		args    string
+		runlist []string
+	}{
+		{"--runlist=recipe[test],role[test]", []string{"recipe[test]", "role[test]"}},
+		{`--runlist "recipe[test], role[test]"`, []string{"recipe[test]", "role[test]"}},
+		{"--add=recipe[nginx::ssl]", []string{"recipe[base]", "recipe[nginx::default]", "role[staging]", "recipe[nginx::ssl]"}},
+		{"--add=recipe[nginx::ssl] --add=recipe[a] --insert-after=recipe[nginx::default]", []string{"recipe[base]", "recipe[nginx::default]", "recipe[nginx::ssl]", "recipe[a]", "role[staging]"}},
+		{"--add=role[web] --insert-before=recipe[base]", []string{"role[web]", "recipe[base]", "recipe[nginx::default]", "role[staging]"}},
+		{"--remove=role[staging] --remove=recipe[base]", []string{"recipe[nginx::default]"}},
+		{"--move=role[staging] --insert-before=recipe[nginx::default]", []string{"recipe[base]", "role[staging]", "recipe[nginx::default]"}},
+		{"--move=recipe[base]", []string{"recipe[nginx::default]", "role[staging]", "recipe[base]"}},
+	}
+
+	for _, testCase := range table {
+		server := automationUpdateChefRunlistServer(t, testCase.runlist)
+
+		ResetFlags()
+		resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation update chef runlist --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=34 %s", server.URL, server.URL, "token123", testCase.args))
+		if resulter.Error != nil {
+			t.Error(fmt.Sprintf(`Command with %q expected to not get an error: %s`, testCase.args, resulter.Error))
+		}
+		server.Close()
+	}
+}
+
+func TestAutomationUpdateChefRunlistErrors(t *testing.T) {
+	table := []struct {
+		args  string
+		error string
+	}{
+		{"--runlist=recipe[test],nginx", "invalid runlist entries"},
+		{"--add=nginx", "invalid runlist entries"},
+		{"--add=recipe[base]", "already in runlist"},
+		{"--remove=recipe[missing]", "not found in runlist"},
+		{"--add=recipe[a] --insert-after=recipe[missing]", "not found in runlist"},
+		{"--add=recipe[a] --insert-after=recipe[base] --insert-before=role[staging]", "can not be used together"},
+	}
+
+	for _, testCase := range table {
+		server := automationUpdateChefRunlistServer(t, []string{})
+
+		ResetFlags()
+		resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation update chef runlist --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=34 %s", server.URL, server.URL, "token123", testCase.args))
+		if resulter.Error == nil {
+			t.Errorf(`Command with %q expected to get an error`, testCase.args)
+		}
+		if !strings.Contains(resulter.ErrorOutput, testCase.error) {
+			t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, testCase.error))
+		}
+		server.Close()
+	}
+}
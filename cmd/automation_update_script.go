@@ -0,0 +1,166 @@
+// Copyright © 2016 Arturo Reuschenbach <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/print"
+	"github.com/sapcc/lyra-cli/restclient"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// updateCmd represents the update command
+var AutomationUpdateScriptCmd = &cobra.Command{
+	Use:               "script [NAME|ID]",
+	Args:              cobra.MaximumNArgs(1),
+	Short:             locales.CmdShortDescription("automation-update-script"),
+	Long:              locales.CmdLongDescription("automation-update-script"),
+	ValidArgsFunction: automationNameCompletion,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// resolve the automation id from the name if no id given
+		return resolveAutomationId("automation-update-script-automation-id", "automation-update-script-automation-name", args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// update automation
+		response, err := automationUpdateScript()
+		if restclient.IsDryRun(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// convert data to struct
+		var dataStruct map[string]interface{}
+		err = helpers.JSONStringToStructure(response, &dataStruct)
+		if err != nil {
+			return err
+		}
+
+		// print the data out
+		printer := print.Print{Data: dataStruct}
+		var bodyPrint string
+		if viper.GetBool("json") {
+			bodyPrint, err = printer.JSON()
+			if err != nil {
+				return err
+			}
+		} else {
+			bodyPrint, err = printer.Table()
+			if err != nil {
+				return err
+			}
+		}
+
+		// print response
+		fmt.Println(bodyPrint)
+
+		return nil
+	},
+}
+
+func init() {
+	AutomationUpdateCmd.AddCommand(AutomationUpdateScriptCmd)
+	initAutomationUpdateScriptCmdFlags()
+}
+
+func initAutomationUpdateScriptCmdFlags() {
+	AutomationUpdateScriptCmd.Flags().StringP(FLAG_AUTOMATION_ID, "", "", locales.AttributeDescription("automation-id"))
+	AutomationUpdateScriptCmd.Flags().String("path", "", locales.AttributeDescription("automation-path"))
+	AutomationUpdateScriptCmd.Flags().StringArray("arg", nil, locales.AttributeDescription("automation-argument"))
+	AutomationUpdateScriptCmd.Flags().StringArray("env", nil, locales.AttributeDescription("automation-environment"))
+	AutomationUpdateScriptCmd.Flags().String("args-file", "", locales.AttributeDescription("automation-arguments-file"))
+	AutomationUpdateScriptCmd.Flags().String("env-file", "", locales.AttributeDescription("automation-environment-file"))
+	AutomationUpdateScriptCmd.Flags().String("env-from-json", "", locales.AttributeDescription("automation-environment-from-json"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-script-automation-id", AutomationUpdateScriptCmd.Flags().Lookup(FLAG_AUTOMATION_ID)), "BindPFlag:")
+	AutomationUpdateScriptCmd.Flags().StringP(FLAG_AUTOMATION, "", "", locales.AttributeDescription(FLAG_AUTOMATION))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-script-automation-name", AutomationUpdateScriptCmd.Flags().Lookup(FLAG_AUTOMATION)), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-script-path", AutomationUpdateScriptCmd.Flags().Lookup("path")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-script-argument", AutomationUpdateScriptCmd.Flags().Lookup("arg")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-script-environment", AutomationUpdateScriptCmd.Flags().Lookup("env")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-script-args-file", AutomationUpdateScriptCmd.Flags().Lookup("args-file")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-script-env-file", AutomationUpdateScriptCmd.Flags().Lookup("env-file")), "BindPFlag:")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-update-script-env-from-json", AutomationUpdateScriptCmd.Flags().Lookup("env-from-json")), "BindPFlag:")
+}
+
+func automationUpdateScript() (string, error) {
+	automationService := RestClient.Services["automation"]
+	id := viper.GetString("automation-update-script-automation-id")
+
+	response, code, err := automationService.Get(path.Join("automations", id), url.Values{}, false)
+	if err != nil {
+		return "", err
+	}
+
+	if int(code) >= 400 {
+		return "", errors.New(response)
+	}
+
+	// get the existing data
+	oldScript := Script{}
+	if err := oldScript.Unmarshal(response); err != nil {
+		return "", err
+	}
+	if oldScript.AutomationType != "Script" {
+		return "", fmt.Errorf("automation %s is not a script automation", id)
+	}
+	storedErrs := oldScript.Validate()
+
+	// change path
+	if len(viper.GetString("automation-update-script-path")) > 0 {
+		oldScript.Path = viper.GetString("automation-update-script-path")
+	}
+
+	// replace the arguments when given
+	if len(viper.GetStringSlice("automation-update-script-argument")) > 0 || len(viper.GetString("automation-update-script-args-file")) > 0 {
+		oldScript.Arguments, err = readScriptArguments("automation-update-script")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// merge the environment into the existing one
+	oldScript.Environment, err = readScriptEnvironment("automation-update-script", oldScript.Environment)
+	if err != nil {
+		return "", err
+	}
+
+	// validate before sending ignoring the problems already stored
+	err = newValidationErrors(storedErrs, oldScript.Validate())
+	if err != nil {
+		return "", err
+	}
+
+	// convert to Json
+	body, err := oldScript.Marshal()
+	if err != nil {
+		return "", err
+	}
+
+	// send data back
+	newResp, _, err := automationService.Put(path.Join("automations", id), url.Values{}, string(body))
+	if err != nil {
+		return "", err
+	}
+
+	return newResp, nil
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sapcc/lyra-cli/locales"
+)
+
+func automationScriptServer(t *testing.T, method string, want Script) *httptest.Server {
+	responseBody := `{"id":45,"type":"Script","name":"test","repository":"https://github.com/userId0123456789/automation-test.git","repository_revision":"master","timeout":3600,"path":"script.sh","arguments":["--old"],"environment":{"KEEP":"old","NAME":"old"}}`
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == method {
+			data, _ := io.ReadAll(r.Body)
+			defer r.Body.Close()
+
+			sent := Script{}
+			if err := sent.Unmarshal(string(data)); err != nil {
+				t.Error(err.Error())
+				return
+			}
+			if !reflect.DeepEqual(sent.Arguments, want.Arguments) {
+				t.Errorf("Arguments doesn't match. Expected %#v, Got %#v", want.Arguments, sent.Arguments)
+			}
+			if !reflect.DeepEqual(sent.Environment, want.Environment) {
+				t.Errorf("Environment doesn't match. Expected %#v, Got %#v", want.Environment, sent.Environment)
+			}
+			fmt.Fprintln(w, string(data))
+			return
+		}
+		fmt.Fprintln(w, responseBody)
+	}))
+}
+
+func writeTestFile(t *testing.T, name, content string) string {
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestAutomationCreateScriptEnvironmentAndArgumentsFromFiles(t *testing.T) {
+	server := automationScriptServer(t, "POST", Script{
+		Arguments:   []string{"--verbose", "--name=some value"},
+		Environment: map[string]string{"DB_HOST": "db", "DB_PORT": "5432", "LOG_LEVEL": "debug", "DEBUG": "true"},
+	})
+	defer server.Close()
+
+	envFile := writeTestFile(t, ".env", "# settings\nDB_HOST=localhost\nDB_PORT=\"5432\"\nLOG_LEVEL=info\n")
+	jsonFile := writeTestFile(t, "env.json", `{"LOG_LEVEL":"warn","DEBUG":true}`)
+	argsFile := writeTestFile(t, "args", "--verbose\n--name=some value\n")
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation create script --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --name=test --repository=http://some_repository --path=script.sh --env-file=%s --env-from-json=%s --args-file=%s --env=DB_HOST=db --env=LOG_LEVEL=debug", server.URL, server.URL, "token123", envFile, jsonFile, argsFile))
+	if resulter.Error != nil {
+		t.Errorf(`Command expected to not get an error: %s`, resulter.Error)
+	}
+}
+
+func TestAutomationCreateScriptArgumentsFileAndFlag(t *testing.T) {
+	server := automationScriptServer(t, "POST", Script{
+		Arguments:   []string{"--from-file", "--explicit"},
+		Environment: map[string]string{},
+	})
+	defer server.Close()
+
+	argsFile := writeTestFile(t, "args.json", `["--from-file"]`)
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation create script --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --name=test --repository=http://some_repository --path=script.sh --args-file=%s --arg=--explicit", server.URL, server.URL, "token123", argsFile))
+	if resulter.Error != nil {
+		t.Errorf(`Command expected to not get an error: %s`, resulter.Error)
+	}
+}
+
+func TestAutomationCreateScriptInvalidEnvFile(t *testing.T) {
+	envFile := writeTestFile(t, ".env", "NAME=\"not closed\n")
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation create script --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --name=test --repository=http://some_repository --path=script.sh --env-file=%s", "https://somewhere.com", "https://somewhere.com", "token123", envFile))
+	if !strings.Contains(resulter.ErrorOutput, "missing closing quote") {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, "missing closing quote"))
+	}
+}
+
+func TestAutomationUpdateScriptMissingId(t *testing.T) {
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation update script --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --env=NAME=value", "https://somewhere.com", "https://somewhere.com", "token123"))
+
+	errorMsg := locales.ErrorMessages("automation-id-missing")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+}
+
+func TestAutomationUpdateScriptMergesEnvironment(t *testing.T) {
+	server := automationScriptServer(t, "PUT", Script{
+		Arguments:   []string{"--old"},
+		Environment: map[string]string{"KEEP": "old", "NAME": "flag", "NEW": "file"},
+	})
+	defer server.Close()
+
+	envFile := writeTestFile(t, ".env", "NAME=file\nNEW=file\n")
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation update script --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=45 --env-file=%s --env=NAME=flag", server.URL, server.URL, "token123", envFile))
+	if resulter.Error != nil {
+		t.Errorf(`Command expected to not get an error: %s`, resulter.Error)
+	}
+}
+
+func TestAutomationUpdateScriptReplacesArguments(t *testing.T) {
+	server := automationScriptServer(t, "PUT", Script{
+		Arguments:   []string{"--new", "value"},
+		Environment: map[string]string{"KEEP": "old", "NAME": "old"},
+	})
+	defer server.Close()
+
+	argsFile := writeTestFile(t, "args", "--new\nvalue\n")
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation update script --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=45 --args-file=%s", server.URL, server.URL, "token123", argsFile))
+	if resulter.Error != nil {
+		t.Errorf(`Command expected to not get an error: %s`, resulter.Error)
+	}
+}
+
+func TestAutomationUpdateScriptIgnoresStoredProblems(t *testing.T) {
+	puts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "PUT" {
+			puts++
+			data, _ := io.ReadAll(r.Body)
+			fmt.Fprintln(w, string(data))
+			return
+		}
+		// the name has spaces and the timeout is out of range
+		fmt.Fprintln(w, `{"id":45,"type":"Script","name":"Deploy app","repository":"https://github.com/userId0123456789/automation-test.git","repository_revision":"master","timeout":100000,"path":"script.sh","environment":{"old-name":"x"}}`)
+	}))
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation update script --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=45 --env=NAME=value", server.URL, server.URL, "token123"))
+	if resulter.Error != nil {
+		t.Errorf(`Command expected to not get an error: %s`, resulter.Error)
+	}
+
+	// new problems are still reported
+	ResetFlags()
+	resulter = FullCmdTester(RootCmd, fmt.Sprintf("lyra automation update script --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=45 --env=1NAME=value", server.URL, server.URL, "token123"))
+	if resulter.Error == nil || !strings.Contains(resulter.Error.Error(), "environment.1NAME:") || strings.Contains(resulter.Error.Error(), "timeout:") || strings.Contains(resulter.Error.Error(), "old-name") {
+		t.Errorf("Expected only the new environment problem, got %v", resulter.Error)
+	}
+	if puts != 1 {
+		t.Errorf("Expected one update, got %d", puts)
+	}
+}
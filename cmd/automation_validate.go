@@ -0,0 +1,284 @@
+// Copyright © 2016 Arturo Reuschenbach <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	AutomationTimeoutMin = 1
+	AutomationTimeoutMax = 86400
+)
+
+var AutomationValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: locales.CmdShortDescription("automation-validate"),
+	Long:  locales.CmdLongDescription("automation-validate"),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// DO NOT REMOVE. SHOULD OVERRIDE THE ROOT PersistentPreRunE
+		return nil
+	},
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// check required manifest
+		if len(viper.GetString("automation-validate-file")) == 0 {
+			return errors.New(locales.ErrorMessages("manifest-missing"))
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manifest, err := helpers.ReadFromFileWithLineBreaks(viper.GetString("automation-validate-file"))
+		if err != nil {
+			return err
+		}
+
+		automation, err := decodeAutomation(manifest)
+		if err != nil {
+			return err
+		}
+
+		err = validateAutomation(automation)
+		if err != nil {
+			return err
+		}
+
+		// print response to the stderr
+		cmd.Println("Automation manifest is valid.")
+
+		return nil
+	},
+}
+
+func init() {
+	AutomationCmd.AddCommand(AutomationValidateCmd)
+	initAutomationValidateCmdFlags()
+}
+
+func initAutomationValidateCmdFlags() {
+	AutomationValidateCmd.Flags().StringP("file", "f", "", locales.AttributeDescription("automation-manifest"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("automation-validate-file", AutomationValidateCmd.Flags().Lookup("file")), "BindPFlag:")
+}
+
+// ValidationError describes an invalid attribute of an automation
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+// ValidationErrors collects all problems found in an automation
+type ValidationErrors []ValidationError
+
+func (v ValidationErrors) Error() string {
+	return v.describe(locales.ErrorMessages("automation-invalid"))
+}
+
+// describe lists the problems below the header
+func (v ValidationErrors) describe(header string) string {
+	lines := []string{header}
+	for _, e := range v {
+		lines = append(lines, fmt.Sprintf("  %s: %s", e.Field, e.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+var listIndexRegex = regexp.MustCompile(`\[\d+\]`)
+
+// key identifies the problem independent of the position of list entries. The messages name the invalid value,
+// so moved entries keep their key.
+func (e ValidationError) key() string {
+	return fmt.Sprintf("%s: %s", listIndexRegex.ReplaceAllString(e.Field, "[]"), e.Message)
+}
+
+func (v *ValidationErrors) add(field, message string) {
+	*v = append(*v, ValidationError{Field: field, Message: message})
+}
+
+var gitScpRegex = regexp.MustCompile(`^[\w.-]+@[\w.-]+:[\w./~-]+$`)
+
+// Validate checks the chef automation and returns all problems found
+func (c *Chef) Validate() error {
+	errs := c.Automation.validate()
+
+	if len(c.Runlist) == 0 {
+		errs.add("run_list", "is required")
+	}
+	for i, entry := range c.Runlist {
+		if !runlistEntryRegex.MatchString(entry) {
+			errs.add(fmt.Sprintf("run_list[%d]", i), fmt.Sprintf("%q should look like recipe[cookbook::recipe] or role[name]", entry))
+		}
+	}
+	if c.Attributes != nil {
+		if _, ok := c.Attributes.(map[string]interface{}); !ok {
+			errs.add("chef_attributes", "should be a JSON object")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Validate checks the script automation and returns all problems found
+func (s *Script) Validate() error {
+	errs := s.Automation.validate()
+
+	if len(s.Path) == 0 {
+		errs.add("path", "is required")
+	}
+
+	names := []string{}
+	for name := range s.Environment {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !helpers.EnvNameRegex.MatchString(name) {
+			errs.add(fmt.Sprintf("environment.%s", name), "should contain only letters, digits and '_' and not start with a digit")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (a *Automation) validate() ValidationErrors {
+	errs := ValidationErrors{}
+
+	if len(a.Name) == 0 {
+		errs.add("name", "is required")
+	}
+	if len(a.Repository) == 0 {
+		errs.add("repository", "is required")
+	} else if !validGitURL(a.Repository) {
+		errs.add("repository", fmt.Sprintf("%q is not a valid git URL", a.Repository))
+	}
+	if len(a.RepositoryRevision) == 0 {
+		errs.add("repository_revision", "is required")
+	}
+	if a.Timeout == 0 {
+		errs.add("timeout", "is required")
+	} else if a.Timeout < AutomationTimeoutMin || a.Timeout > AutomationTimeoutMax {
+		errs.add("timeout", fmt.Sprintf("should be between %d and %d seconds", AutomationTimeoutMin, AutomationTimeoutMax))
+	}
+
+	return errs
+}
+
+// newValidationErrors returns the problems of the updated automation the stored one didn't have already. Updates
+// are not blocked by problems of attributes they don't change, also when list entries were moved.
+func newValidationErrors(stored, updated error) error {
+	updatedErrs, ok := updated.(ValidationErrors)
+	if !ok {
+		return updated
+	}
+	known := map[string]int{}
+	if storedErrs, ok := stored.(ValidationErrors); ok {
+		for _, e := range storedErrs {
+			known[e.key()]++
+		}
+	}
+	errs := ValidationErrors{}
+	for _, e := range updatedErrs {
+		if known[e.key()] > 0 {
+			known[e.key()]--
+			continue
+		}
+		errs = append(errs, e)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validGitURL accepts http(s), ssh and git URLs and the scp like syntax user@host:path
+func validGitURL(repository string) bool {
+	if gitScpRegex.MatchString(repository) {
+		return true
+	}
+	u, err := url.Parse(repository)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "http", "https", "ssh", "git", "git+ssh":
+		return len(u.Host) > 0
+	}
+	return false
+}
+
+// validateAutomation validates a chef or script automation
+func validateAutomation(automation interface{}) error {
+	switch automation := automation.(type) {
+	case *Chef:
+		return automation.Validate()
+	case *Script:
+		return automation.Validate()
+	}
+	return fmt.Errorf("unknown automation %T", automation)
+}
+
+// decodeAutomation decodes a JSON or YAML definition into a chef or script automation depending on the type.
+// Unknown attributes and wrong types are not allowed.
+func decodeAutomation(data string) (interface{}, error) {
+	var structure interface{}
+	if err := helpers.YAMLStringToStructure(data, &structure); err != nil {
+		return nil, err
+	}
+	jsonString, err := helpers.StructureToJSON(structure)
+	if err != nil {
+		return nil, err
+	}
+
+	automationType := struct {
+		AutomationType string `json:"type"`
+	}{}
+	if err = helpers.JSONStringToStructure(jsonString, &automationType); err != nil {
+		return nil, err
+	}
+
+	var automation interface{}
+	switch automationType.AutomationType {
+	case "Chef":
+		automation = &Chef{}
+	case "Script":
+		automation = &Script{}
+	default:
+		return nil, fmt.Errorf("type: %q is not a valid automation type. Valid: Chef,Script", automationType.AutomationType)
+	}
+
+	decoder := json.NewDecoder(bytes.NewBufferString(jsonString))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(automation); err != nil {
+		return nil, err
+	}
+
+	return automation, nil
+}
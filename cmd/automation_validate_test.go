@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sapcc/lyra-cli/locales"
+)
+
+func writeAutomationManifest(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "automation.yaml")
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestAutomationValidateCmdMissingFile(t *testing.T) {
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, "lyra automation validate")
+
+	errorMsg := locales.ErrorMessages("manifest-missing")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		diffString := StringDiff(resulter.ErrorOutput, errorMsg)
+		t.Errorf("Command error doesn't match. \n \n %s", diffString)
+	}
+}
+
+func TestAutomationValidateCmdValid(t *testing.T) {
+	manifests := []string{
+		`type: Chef
+name: nginx
+repository: https://github.com/userId0123456789/automation-test.git
+repository_revision: master
+timeout: 3600
+run_list:
+  - recipe[nginx::default]
+  - role[web]
+chef_attributes:
+  nginx:
+    port: 80
+`,
+		`{"type":"Script","name":"install","repository":"git@github.com:userId0123456789/automation-test.git","repository_revision":"master","timeout":60,"path":"install.sh","environment":{"HTTP_PROXY":"proxy"}}`,
+	}
+
+	for _, manifest := range manifests {
+		ResetFlags()
+		resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation validate -f %s", writeAutomationManifest(t, manifest)))
+		if resulter.Error != nil {
+			t.Errorf(`Command expected to not get an error: %s`, resulter.Error)
+		}
+		if !strings.Contains(resulter.ErrorOutput, "is valid") {
+			t.Errorf("Command output doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, "is valid"))
+		}
+	}
+}
+
+func TestAutomationValidateCmdReportsAllErrors(t *testing.T) {
+	manifest := `type: Chef
+name: my nginx
+repository: not a url
+repository_revision: master
+timeout: 100000
+run_list:
+  - recipe[nginx]
+  - nginx
+`
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation validate -f %s", writeAutomationManifest(t, manifest)))
+	if resulter.Error == nil {
+		t.Error(`Command expected to get an error`)
+	}
+	for _, want := range []string{"repository: \"not a url\" is not a valid git URL", "timeout: should be between 1 and 86400 seconds", "run_list[1]: \"nginx\" should look like"} {
+		if !strings.Contains(resulter.ErrorOutput, want) {
+			t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, want))
+		}
+	}
+	// names may contain white spaces
+	if strings.Contains(resulter.ErrorOutput, "name:") {
+		t.Errorf("Command expected to accept the name, got %s", resulter.ErrorOutput)
+	}
+}
+
+func TestAutomationValidateCmdScriptErrors(t *testing.T) {
+	manifest := `{"type":"Script","environment":{"1ABC":"x","OK":"y"}}`
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation validate -f %s", writeAutomationManifest(t, manifest)))
+	if resulter.Error == nil {
+		t.Error(`Command expected to get an error`)
+	}
+	for _, want := range []string{"name: is required", "repository: is required", "repository_revision: is required", "timeout: is required", "path: is required", "environment.1ABC:"} {
+		if !strings.Contains(resulter.ErrorOutput, want) {
+			t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, want))
+		}
+	}
+	if strings.Contains(resulter.ErrorOutput, "environment.OK") {
+		t.Error("Command expected to accept valid environment names")
+	}
+}
+
+func TestAutomationValidateCmdUnknownAttributes(t *testing.T) {
+	table := []struct {
+		manifest string
+		error    string
+	}{
+		{`{"type":"Chef","runlist":["recipe[nginx]"]}`, `unknown field "runlist"`},
+		{`{"type":"Ansible"}`, `is not a valid automation type`},
+		{`{"type":"Chef","timeout":"long"}`, `timeout`},
+	}
+
+	for _, testCase := range table {
+		ResetFlags()
+		resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation validate -f %s", writeAutomationManifest(t, testCase.manifest)))
+		if resulter.Error == nil {
+			t.Error(`Command expected to get an error`)
+		}
+		if !strings.Contains(resulter.ErrorOutput, testCase.error) {
+			t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, testCase.error))
+		}
+	}
+}
+
+func TestAutomationCreateChefCmdValidatesBeforeSending(t *testing.T) {
+	server := TestServer(200, `{}`, map[string]string{})
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation create chef --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --name=%s --repository=%s --runlist=%s --timeout=0", server.URL, server.URL, "token123", "chef_test", "http://some_repository", "nginx"))
+	if resulter.Error == nil {
+		t.Error(`Command expected to get an error`)
+	}
+	for _, want := range []string{locales.ErrorMessages("automation-invalid"), "timeout: is required", "run_list[0]"} {
+		if !strings.Contains(resulter.ErrorOutput, want) {
+			t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, want))
+		}
+	}
+}
+
+func TestAutomationValidateCmdStdin(t *testing.T) {
+	// keep backup of the real stdin
+	oldStdin := os.Stdin
+	defer func() { os.Stdin = oldStdin }()
+	if _, err := pipeToStdin("type: Script\nname: install\nrepository: https://github.com/userId0123456789/automation-test.git\nrepository_revision: master\ntimeout: 60\npath: install.sh\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, "lyra automation validate -f -")
+	if resulter.Error != nil {
+		t.Errorf(`Command expected to not get an error: %s`, resulter.Error)
+	}
+}
+
+func TestNewValidationErrors(t *testing.T) {
+	stored := &Chef{Automation: Automation{Name: "nginx", Repository: "https://github.com/userId0123456789/automation-test.git", RepositoryRevision: "master", Timeout: 3600}, Runlist: []string{"nginx", "recipe[base]"}}
+	storedErrs := stored.Validate()
+
+	// the stored invalid entry moved to another position
+	updated := *stored
+	updated.Runlist = []string{"recipe[web]", "recipe[base]", "nginx"}
+	if err := newValidationErrors(storedErrs, updated.Validate()); err != nil {
+		t.Errorf("Expected the moved entry to be a stored problem, got %s", err)
+	}
+
+	// a new invalid entry is reported
+	updated.Runlist = []string{"web", "recipe[base]", "nginx"}
+	err := newValidationErrors(storedErrs, updated.Validate())
+	errs, ok := err.(ValidationErrors)
+	if !ok || len(errs) != 1 || errs[0].Field != "run_list[0]" {
+		t.Errorf("Expected the new entry to be reported, got %v", err)
+	}
+}
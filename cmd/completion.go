@@ -0,0 +1,256 @@
+// Copyright © 2016 Arturo Reuschenbach <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// completionCacheTTL is the time completions from the API are reused
+const completionCacheTTL = time.Minute
+
+var installFormats = []string{"linux", "windows", "cloud-config", "json"}
+
+var CompletionCmd = &cobra.Command{
+	Use:                   "completion bash|zsh|fish|powershell",
+	Short:                 locales.CmdShortDescription("completion"),
+	Long:                  locales.CmdLongDescription("completion"),
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// DO NOT REMOVE. SHOULD OVERRIDE THE ROOT PersistentPreRunE
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "bash":
+			return RootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			return RootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			return RootCmd.GenFishCompletion(os.Stdout, true)
+		}
+		return RootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+	},
+}
+
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+var (
+	automationIdCompletion   = apiCompletion("automation-ids", automationIdCompletions)
+	automationNameCompletion = apiCompletion("automation-names", automationNameCompletions)
+	nodeIdCompletion         = apiCompletion("node-ids", nodeIdCompletions)
+	runIdCompletion          = apiCompletion("run-ids", runIdCompletions)
+	jobIdCompletion          = apiCompletion("job-ids", jobIdCompletions)
+)
+
+// flagCompletions maps the flag names to the completion of their values
+var flagCompletions = map[string]completionFunc{
+	FLAG_AUTOMATION_ID:      automationIdCompletion,
+	FLAG_AUTOMATION:         automationNameCompletion,
+	FLAG_ARC_NODE_ID:        nodeIdCompletion,
+	FLAG_RUN_ID:             runIdCompletion,
+	FLAG_JOB_ID:             jobIdCompletion,
+	FLAG_ARC_INSTALL_FORMAT: installFormatCompletion,
+}
+
+func init() {
+	RootCmd.AddCommand(CompletionCmd)
+	cobra.OnInitialize(registerFlagCompletions)
+}
+
+// registerFlagCompletions registers the flag value completions on all commands. Flags already registered are skipped.
+func registerFlagCompletions() {
+	var register func(cmd *cobra.Command)
+	register = func(cmd *cobra.Command) {
+		for name, completion := range flagCompletions {
+			if cmd.Flags().Lookup(name) != nil {
+				// the only possible error is an already registered flag
+				_ = cmd.RegisterFlagCompletionFunc(name, completion)
+			}
+		}
+		for _, child := range cmd.Commands() {
+			register(child)
+		}
+	}
+	register(RootCmd)
+}
+
+func installFormatCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return filterCompletions(installFormats, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// apiCompletion returns a completion listing the values from the API. The values are cached for a short time
+// to keep the completion fast.
+func apiCompletion(resource string, list func() ([]string, error)) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		completions := []string{}
+		cacheName := completionCacheName(resource)
+		if !helpers.ReadCache(cacheName, completionCacheTTL, &completions) {
+			err := setupCompletionClient(cmd)
+			if err == nil {
+				completions, err = list()
+			}
+			if err != nil {
+				cobra.CompDebugln(err.Error(), true)
+				return nil, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveError
+			}
+			if err = helpers.WriteCache(cacheName, completions); err != nil {
+				cobra.CompDebugln(err.Error(), true)
+			}
+		}
+		return filterCompletions(completions, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// setupCompletionClient sets up the rest client without prompting for a password or secret
+func setupCompletionClient(cmd *cobra.Command) error {
+	hasToken := len(viper.GetString(ENV_VAR_TOKEN_NAME)) > 0 && len(viper.GetString(ENV_VAR_AUTOMATION_ENDPOINT_NAME)) > 0 && len(viper.GetString(ENV_VAR_ARC_ENDPOINT_NAME)) > 0
+	hasSecret := len(viper.GetString(ENV_VAR_PASSWORD)) > 0 || len(viper.GetString(ENV_VAR_APPLICATION_CREDENTIAL_SECRET)) > 0
+	if !hasToken && !hasSecret {
+		return errors.New(locales.ErrorMessages("completion-authentication-missing"))
+	}
+	return setupRestClient(cmd, nil, false)
+}
+
+// completionCacheName returns the cache file name of the completion values of the resource
+func completionCacheName(resource string) string {
+	return apiCacheName("completion", resource)
+}
+
+// apiCacheName returns the cache file name of the resource for the current endpoints and credentials.
+// Only a hash of the values is used so no secrets are written to disk.
+func apiCacheName(kind, resource string) string {
+	hash := sha256.New()
+	for _, key := range []string{ENV_VAR_AUTOMATION_ENDPOINT_NAME, ENV_VAR_ARC_ENDPOINT_NAME, ENV_VAR_TOKEN_NAME, ENV_VAR_AUTH_URL, ENV_VAR_REGION, ENV_VAR_PROJECT_ID, ENV_VAR_PROJECT_NAME, ENV_VAR_PROJECT_DOMAIN_ID, ENV_VAR_PROJECT_DOMAIN_NAME, ENV_VAR_USER_ID, ENV_VAR_USERNAME, ENV_VAR_APPLICATION_CREDENTIAL_ID, ENV_VAR_APPLICATION_CREDENTIAL_NAME} {
+		fmt.Fprintln(hash, viper.GetString(key))
+	}
+	return fmt.Sprintf("%s-%s-%x.json", kind, resource, hash.Sum(nil)[:8])
+}
+
+// filterCompletions returns the completions starting with toComplete which are not given as argument yet
+func filterCompletions(completions []string, args []string, toComplete string) []string {
+	given := map[string]bool{}
+	for _, arg := range args {
+		given[arg] = true
+	}
+	result := []string{}
+	for _, completion := range completions {
+		value := strings.SplitN(completion, "\t", 2)[0]
+		if strings.HasPrefix(value, toComplete) && !given[value] {
+			result = append(result, completion)
+		}
+	}
+	return result
+}
+
+// completionEntries returns the values of the entries with their description separated by a tab
+func completionEntries(entries []interface{}, valueKey string, descriptionKeys ...string) []string {
+	completions := []string{}
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		entryMap, ok := entry.(map[string]interface{})
+		if !ok || entryMap[valueKey] == nil {
+			continue
+		}
+		value := fmt.Sprint(entryMap[valueKey])
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+
+		descriptions := []string{}
+		for _, key := range descriptionKeys {
+			if description, ok := entryMap[key]; ok && description != nil && fmt.Sprint(description) != "" {
+				descriptions = append(descriptions, fmt.Sprint(description))
+			}
+		}
+		if len(descriptions) > 0 {
+			value = fmt.Sprint(value, "\t", strings.Join(descriptions, " "))
+		}
+		completions = append(completions, value)
+	}
+	return completions
+}
+
+// recentEntries returns the first page of the list to avoid paging through the whole history
+func recentEntries(service, pathAction string) ([]interface{}, error) {
+	endpoint := RestClient.Services[service]
+	response, _, err := endpoint.Get(pathAction, url.Values{"page": []string{"1"}, "per_page": []string{"50"}}, false)
+	if err != nil {
+		return nil, err
+	}
+	entries := []interface{}{}
+	err = helpers.JSONStringToStructure(response, &entries)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func automationIdCompletions() ([]string, error) {
+	automationService := RestClient.Services["automation"]
+	automations, _, err := automationService.GetList("automations", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	return completionEntries(automations, "id", "name"), nil
+}
+
+func automationNameCompletions() ([]string, error) {
+	automationService := RestClient.Services["automation"]
+	automations, _, err := automationService.GetList("automations", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	return completionEntries(automations, "name", "type"), nil
+}
+
+func nodeIdCompletions() ([]string, error) {
+	arcService := RestClient.Services["arc"]
+	nodes, _, err := arcService.GetList("agents", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	return completionEntries(nodes, "agent_id", "display_name"), nil
+}
+
+func runIdCompletions() ([]string, error) {
+	runs, err := recentEntries("automation", "runs")
+	if err != nil {
+		return nil, err
+	}
+	return completionEntries(runs, "id", "automation_name", "state"), nil
+}
+
+func jobIdCompletions() ([]string, error) {
+	jobs, err := recentEntries("arc", "jobs")
+	if err != nil {
+		return nil, err
+	}
+	return completionEntries(jobs, "request_id", "agent", "status"), nil
+}
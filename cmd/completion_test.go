@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompletionCmd(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		ResetFlags()
+		resulter := FullCmdTester(RootCmd, fmt.Sprint("lyra completion ", shell))
+		if resulter.Error != nil {
+			t.Errorf("%s: command expected to not get an error: %s", shell, resulter.Error)
+		}
+		if !strings.Contains(resulter.Output, "__complete") {
+			t.Errorf("%s: expected a dynamic completion script, got %q", shell, resulter.Output)
+		}
+	}
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, "lyra completion tcsh")
+	if resulter.Error == nil {
+		t.Error("Command expected to get an error for an unsupported shell")
+	}
+}
+
+func TestCompletionFlagValues(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/automations":
+			fmt.Fprintln(w, `[{"id":1,"name":"chef-base","type":"Chef"},{"id":12,"name":"deploy-app","type":"Script"},{"id":2,"name":"smoke-test","type":"Script"}]`)
+		case "/api/v1/agents":
+			fmt.Fprintln(w, `[{"agent_id":"node1","display_name":"web"},{"agent_id":"node2","display_name":"db"}]`)
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	flags := fmt.Sprintf("--lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s", server.URL, server.URL, "token123")
+
+	tests := []struct {
+		command string
+		want    []string
+		notWant []string
+	}{
+		{"lyra automation show --automation-id=1", []string{"1\tchef-base", "12\tdeploy-app"}, []string{"2\tsmoke-test"}},
+		{"lyra automation show smoke", []string{"smoke-test\tScript"}, []string{"chef-base"}},
+		{"lyra node show node1 node", []string{"node2\tdb"}, []string{"node1"}},
+		{"lyra node install --install-format=", []string{"linux", "windows", "cloud-config", "json"}, nil},
+	}
+	for _, test := range tests {
+		ResetFlags()
+		resulter := FullCmdTester(RootCmd, fmt.Sprintf("__complete %s %s", flags, test.command))
+		if resulter.Error != nil {
+			t.Errorf("%s: command expected to not get an error: %s", test.command, resulter.Error)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(resulter.ErrorOutput, want) {
+				t.Errorf("%s: expected completion %q, got %q", test.command, want, resulter.ErrorOutput)
+			}
+		}
+		for _, notWant := range test.notWant {
+			if strings.Contains(resulter.ErrorOutput, notWant) {
+				t.Errorf("%s: expected no completion %q, got %q", test.command, notWant, resulter.ErrorOutput)
+			}
+		}
+	}
+
+	// the second completion of the automation ids is served from the cache
+	before := requests
+	ResetFlags()
+	FullCmdTester(RootCmd, fmt.Sprintf("__complete %s lyra automation delete --automation-id=", flags))
+	if requests != before {
+		t.Errorf("Expected the automation ids to be cached, got %d new requests", requests-before)
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// dryRunServer answers GET requests and fails the test on any request changing data
+func dryRunServer(t *testing.T, responseBody string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("Request %s %s should not be sent on dry run", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, responseBody)
+	}))
+}
+
+func checkDryRunOutput(t *testing.T, resulter resulter, want []string) {
+	if resulter.Error != nil {
+		t.Errorf(`Command expected to not get an error: %s`, resulter.Error)
+	}
+	for _, line := range want {
+		if !strings.Contains(resulter.Output, line) {
+			t.Errorf("Dry run output doesn't match. \n \n %s", StringDiff(resulter.Output, line))
+		}
+	}
+	if strings.Contains(resulter.Output, "token123") {
+		t.Errorf("Dry run output should not contain the token. \n \n %s", resulter.Output)
+	}
+}
+
+func TestDryRunAutomationCreate(t *testing.T) {
+	server := dryRunServer(t, "{}")
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation create script --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --name=test --repository=http://some_repository --path=script.sh --repository-credentials=secret --dry-run", server.URL, server.URL, "token123"))
+	checkDryRunOutput(t, resulter, []string{
+		fmt.Sprintf("POST %s/api/v1/automations\n", server.URL),
+		"Content-Type: application/json\n",
+		"X-Auth-Token: [REDACTED]\n",
+		`"name": "test"`,
+		`"repository_credentials": "[REDACTED]"`,
+	})
+}
+
+func TestDryRunAutomationUpdate(t *testing.T) {
+	server := dryRunServer(t, `{"id":45,"type":"Script","name":"test","repository":"https://github.com/userId0123456789/automation-test.git","repository_revision":"master","timeout":3600,"path":"script.sh"}`)
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation update script --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=45 --env=NAME=value --dry-run", server.URL, server.URL, "token123"))
+	checkDryRunOutput(t, resulter, []string{
+		fmt.Sprintf("PUT %s/api/v1/automations/45\n", server.URL),
+		`"NAME": "value"`,
+	})
+}
+
+func TestDryRunNodeTagDelete(t *testing.T) {
+	server := dryRunServer(t, "{}")
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra node tag delete --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --node-id=node123 pool name --dry-run", server.URL, server.URL, "token123"))
+	checkDryRunOutput(t, resulter, []string{
+		fmt.Sprintf("DELETE %s/api/v1/agents/node123/tags/pool\n", server.URL),
+		fmt.Sprintf("DELETE %s/api/v1/agents/node123/tags/name\n", server.URL),
+	})
+	if strings.Contains(resulter.ErrorOutput, "deleted") {
+		t.Errorf("Dry run should not report deleted tags. \n \n %s", resulter.ErrorOutput)
+	}
+}
+
+func TestDryRunNodeDelete(t *testing.T) {
+	server := dryRunServer(t, "{}")
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra node delete --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --node-id=node123 --dry-run", server.URL, server.URL, "token123"))
+	checkDryRunOutput(t, resulter, []string{fmt.Sprintf("DELETE %s/api/v1/agents/node123\n", server.URL)})
+}
+
+func TestDryRunAutomationExecute(t *testing.T) {
+	server := dryRunServer(t, "{}")
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra automation execute --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --automation-id=45 --selector=@identity='node123' --dry-run", server.URL, server.URL, "token123"))
+	checkDryRunOutput(t, resulter, []string{
+		fmt.Sprintf("POST %s/api/v1/runs\n", server.URL),
+		`"automation_id": "45"`,
+	})
+}
@@ -52,11 +52,14 @@ var (
 	FLAG_APPLICATION_CREDENTIAL_SECRET    = "application-credential-secret"
 
 	FLAG_AUTOMATION_ID      = "automation-id"
+	FLAG_AUTOMATION         = "automation"
 	FLAG_RUN_ID             = "run-id"
 	FLAG_JOB_ID             = "job-id"
 	FLAG_SELECTOR           = "selector"
 	FLAG_DEBUG              = "debug"
+	FLAG_DRY_RUN            = "dry-run"
 	FLAG_ARC_NODE_ID        = "node-id"
+	FLAG_ARC_NODE           = "node"
 	FLAG_ARC_INSTALL_FORMAT = "install-format"
 
 	TOKEN_EXPIRES_AT = "token_expires_at"
@@ -0,0 +1,104 @@
+// Copyright © 2016 Arturo Reuschenbach <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/print"
+	"github.com/spf13/viper"
+)
+
+// commandIds returns the id given with the flag followed by the ids given as positional arguments without duplicates
+func commandIds(flagKey string, args []string) []string {
+	return uniqueIds(append([]string{viper.GetString(flagKey)}, args...))
+}
+
+// uniqueIds removes empty and duplicated ids keeping the order
+func uniqueIds(ids []string) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if len(id) == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
+
+// printShowResults prints the responses of a show command. A single response is printed as before, several responses
+// are printed as JSON list or as one table per response.
+func printShowResults(responses []string) error {
+	dataStructs := []interface{}{}
+	for _, response := range responses {
+		var dataStruct map[string]interface{}
+		err := helpers.JSONStringToStructure(response, &dataStruct)
+		if err != nil {
+			return err
+		}
+		dataStructs = append(dataStructs, dataStruct)
+	}
+
+	if viper.GetBool("json") {
+		var data interface{} = dataStructs
+		if len(dataStructs) == 1 {
+			data = dataStructs[0]
+		}
+		printer := print.Print{Data: data}
+		bodyPrint, err := printer.JSON()
+		if err != nil {
+			return err
+		}
+		fmt.Println(bodyPrint)
+		return nil
+	}
+
+	tables := []string{}
+	for _, dataStruct := range dataStructs {
+		printer := print.Print{Data: dataStruct}
+		bodyPrint, err := printer.Table()
+		if err != nil {
+			return err
+		}
+		tables = append(tables, bodyPrint)
+	}
+	fmt.Println(strings.Join(tables, "\n"))
+	return nil
+}
+
+// forEachConcurrently calls fn for every index from 0 to count-1 running at most concurrency calls at the same time
+func forEachConcurrently(count, concurrency int, fn func(i int)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
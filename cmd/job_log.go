@@ -27,24 +27,33 @@ import (
 )
 
 var JobLogCmd = &cobra.Command{
-	Use:   "log",
-	Short: locales.CmdShortDescription("job-log"),
+	Use:               "log [JOB_ID]...",
+	Short:             locales.CmdShortDescription("job-log"),
+	ValidArgsFunction: jobIdCompletion,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		// check required job id
-		if len(viper.GetString("log-job-id")) == 0 {
+		if len(commandIds("log-job-id", args)) == 0 {
 			return errors.New(locales.ErrorMessages("job-id-missing"))
 		}
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// list automation
-		response, err := jobLog(viper.GetString("log-job-id"))
-		if err != nil {
-			return err
-		}
+		ids := commandIds("log-job-id", args)
+		for i, id := range ids {
+			response, err := jobLog(id)
+			if err != nil {
+				return err
+			}
 
-		// print response
-		fmt.Println(response)
+			// print response, several logs get a header with the job id
+			if len(ids) > 1 {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Printf("==> job %s <==\n", id)
+			}
+			fmt.Println(response)
+		}
 
 		return nil
 	},
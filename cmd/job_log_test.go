@@ -64,3 +64,20 @@ func TestJobLogCmdResult(t *testing.T) {
 		t.Errorf("Command response body doesn't match. \n \n %s", diffString)
 	}
 }
+
+func TestJobLogCmdPositionalIds(t *testing.T) {
+	// set test server
+	server := TestServer(200, `This is a job log`, map[string]string{})
+	defer server.Close()
+
+	// reset stuff
+	resetJobLog()
+	// run commando
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra job log job1 job2 --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s", "http://somewhere.com", server.URL, "token123"))
+	for _, want := range []string{"==> job job1 <==\nThis is a job log", "==> job job2 <==\nThis is a job log"} {
+		if !strings.Contains(resulter.Output, want) {
+			diffString := StringDiff(resulter.Output, want)
+			t.Errorf("Command response body doesn't match. \n \n %s", diffString)
+		}
+	}
+}
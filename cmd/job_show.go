@@ -16,61 +16,40 @@ package cmd
 
 import (
 	"errors"
-	"fmt"
 	"net/url"
 	"path"
 
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
-	"github.com/sapcc/lyra-cli/print"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var JobShowCmd = &cobra.Command{
-	Use:   "show",
-	Short: locales.CmdShortDescription("job-show"),
+	Use:               "show [JOB_ID]...",
+	Short:             locales.CmdShortDescription("job-show"),
+	ValidArgsFunction: jobIdCompletion,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		// check required job id
-		if len(viper.GetString("show-job-id")) == 0 {
+		if len(commandIds("show-job-id", args)) == 0 {
 			return errors.New(locales.ErrorMessages("job-id-missing"))
 		}
 
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// list automation
-		response, err := jobShow(viper.GetString("show-job-id"))
-		if err != nil {
-			return err
-		}
-
-		// convert data to struct
-		var dataStruct map[string]interface{}
-		err = helpers.JSONStringToStructure(response, &dataStruct)
-		if err != nil {
-			return err
-		}
-
-		// print the data out
-		printer := print.Print{Data: dataStruct}
-		var bodyPrint string
-		if viper.GetBool("json") {
-			bodyPrint, err = printer.JSON()
-			if err != nil {
-				return err
-			}
-		} else {
-			bodyPrint, err = printer.Table()
+		// show jobs
+		responses := []string{}
+		for _, id := range commandIds("show-job-id", args) {
+			response, err := jobShow(id)
 			if err != nil {
 				return err
 			}
+			responses = append(responses, response)
 		}
 
-		// print response
-		fmt.Println(bodyPrint)
-
-		return nil
+		// print the data out
+		return printShowResults(responses)
 	},
 }
 
@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -120,3 +125,31 @@ func TestJobShowCmdResultJSON(t *testing.T) {
 		t.Error("Json response body and print out Json do not match.")
 	}
 }
+
+func TestJobShowCmdPositionalIds(t *testing.T) {
+	paths := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"request_id":"%s"}`, path.Base(r.URL.Path))
+	}))
+	defer server.Close()
+
+	// reset stuff
+	resetJobShow()
+	// run commando with a duplicated id
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra job show job1 job2 --job-id=job1 --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --json", "http://somewhere.com", server.URL, "token123"))
+	if resulter.Error != nil {
+		t.Errorf("Command expected to not get an error: %s", resulter.Error)
+	}
+	if want := []string{"/api/v1/jobs/job1", "/api/v1/jobs/job2"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("Expected requests %q, got %q", want, paths)
+	}
+	jobs := []map[string]interface{}{}
+	if err := json.Unmarshal([]byte(resulter.Output), &jobs); err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0]["request_id"] != "job1" || jobs[1]["request_id"] != "job2" {
+		t.Errorf("Expected a JSON list with both jobs, got %s", resulter.Output)
+	}
+}
@@ -15,8 +15,17 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
 	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/print"
+	"github.com/sapcc/lyra-cli/restclient"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var NodeCmd = &cobra.Command{
@@ -27,3 +36,132 @@ var NodeCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(NodeCmd)
 }
+
+// findNodeId returns the id of the node with the given id, display name or hostname fact
+func findNodeId(name string) (string, error) {
+	arcService := RestClient.Services["arc"]
+	nodes, _, err := arcService.GetList("agents", url.Values{"facts": []string{"hostname"}})
+	if err != nil {
+		return "", err
+	}
+
+	matches := map[string]bool{}
+	for _, node := range nodes {
+		nodeMap, ok := node.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id := fmt.Sprint(nodeMap["agent_id"])
+		facts, _ := nodeMap["facts"].(map[string]interface{})
+		if id == name || nodeMap["display_name"] == name || (facts != nil && facts["hostname"] == name) {
+			matches[id] = true
+		}
+	}
+
+	ids := []string{}
+	for id := range matches {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf(locales.ErrorMessages("node-name-not-found"), name)
+	case 1:
+		return ids[0], nil
+	}
+	return "", fmt.Errorf(locales.ErrorMessages("node-name-ambiguous"), name, strings.Join(ids, ", "))
+}
+
+// resolveNodeId sets the node id under idKey. When no id is given the node is looked up by the name given under nameKey.
+func resolveNodeId(idKey, nameKey string) error {
+	if len(viper.GetString(idKey)) > 0 {
+		return nil
+	}
+	name := viper.GetString(nameKey)
+	if len(name) == 0 {
+		return errors.New(locales.ErrorMessages("node-id-missing"))
+	}
+
+	id, err := findNodeId(name)
+	if err != nil {
+		return err
+	}
+	viper.Set(idKey, id)
+	return nil
+}
+
+// resolveNodeIds returns the ids of the nodes given by id, by name or as positional arguments
+func resolveNodeIds(idKey, nameKey string, args []string) ([]string, error) {
+	ids := commandIds(idKey, args)
+	if len(viper.GetString(nameKey)) > 0 {
+		id, err := findNodeId(viper.GetString(nameKey))
+		if err != nil {
+			return nil, err
+		}
+		ids = uniqueIds(append(ids, id))
+	}
+	if len(ids) == 0 {
+		return nil, errors.New(locales.ErrorMessages("node-id-missing"))
+	}
+	return ids, nil
+}
+
+const (
+	NodeResultSuccess = "ok"
+	NodeResultFailed  = "failed"
+	NodeResultDryRun  = "dry-run"
+
+	NodeResultRolledBack     = "rolled-back"
+	NodeResultRollbackFailed = "rollback-failed"
+)
+
+// NodeResult describes the outcome of an operation on a single node
+type NodeResult struct {
+	NodeId  string `json:"node_id"`
+	Result  string `json:"result"`
+	Changes string `json:"changes,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// newNodeResult returns the result of the operation on the node depending on the error
+func newNodeResult(nodeId string, err error) NodeResult {
+	switch {
+	case restclient.IsDryRun(err):
+		return NodeResult{NodeId: nodeId, Result: NodeResultDryRun}
+	case err != nil:
+		return NodeResult{NodeId: nodeId, Result: NodeResultFailed, Error: err.Error()}
+	}
+	return NodeResult{NodeId: nodeId, Result: NodeResultSuccess}
+}
+
+// printNodeResults prints the results as JSON or table and returns the amount of failed nodes. The changes column
+// is only shown when any node reports changes.
+func printNodeResults(results []NodeResult) (int, error) {
+	failed := 0
+	rows := []interface{}{}
+	columns := []string{"node_id", "result", "error"}
+	for _, result := range results {
+		if result.Result == NodeResultFailed {
+			failed++
+		}
+		if len(result.Changes) > 0 {
+			columns = []string{"node_id", "result", "changes", "error"}
+		}
+		rows = append(rows, map[string]interface{}{"node_id": result.NodeId, "result": result.Result, "changes": result.Changes, "error": result.Error})
+	}
+
+	var bodyPrint string
+	var err error
+	if viper.GetBool("json") {
+		printer := print.Print{Data: results}
+		bodyPrint, err = printer.JSON()
+	} else {
+		printer := print.Print{Data: rows}
+		bodyPrint, err = printer.TableList(columns)
+	}
+	if err != nil {
+		return failed, err
+	}
+	fmt.Println(bodyPrint)
+	return failed, nil
+}
@@ -16,35 +16,51 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"net/url"
 	"path"
+	"time"
 
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/print"
+	"github.com/sapcc/lyra-cli/restclient"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var NodeDeleteCmd = &cobra.Command{
-	Use:   "delete",
-	Short: locales.CmdShortDescription("arc-node-delete"),
-	Long:  locales.CmdLongDescription("arc-node-delete"),
-	PreRunE: func(cmd *cobra.Command, args []string) error {
-		// check required node id
-		if len(viper.GetString("arc-delete-node-id")) == 0 {
-			return errors.New(locales.ErrorMessages("node-id-missing"))
-		}
-		return nil
-	},
+	Use:               "delete [NODE_ID]...",
+	Short:             locales.CmdShortDescription("arc-node-delete"),
+	Long:              locales.CmdLongDescription("arc-node-delete"),
+	ValidArgsFunction: nodeIdCompletion,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// list automation
-		_, err := nodeDelete(viper.GetString("arc-delete-node-id"))
+		// delete all nodes matching the selector or being stale
+		if len(viper.GetString("arc-delete-selector")) > 0 || len(viper.GetString("arc-delete-stale-since")) > 0 {
+			if len(args) > 0 || len(viper.GetString("arc-delete-node-id")) > 0 || len(viper.GetString("arc-delete-node-name")) > 0 {
+				return errors.New(locales.ErrorMessages("node-delete-selector-ids"))
+			}
+			return nodeDeleteBulk(cmd)
+		}
+
+		// resolve the node ids from the name
+		ids, err := resolveNodeIds("arc-delete-node-id", "arc-delete-node-name", args)
 		if err != nil {
 			return err
 		}
 
-		// Print response to std error. No response got
-		cmd.Println("Node with id ", viper.GetString("arc-delete-node-id"), " deleted.")
+		for _, id := range ids {
+			_, err := nodeDelete(id)
+			if restclient.IsDryRun(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			// Print response to std error. No response got
+			cmd.Println("Node with id ", id, " deleted.")
+		}
 
 		return nil
 	},
@@ -58,6 +74,93 @@ func init() {
 func initNodeDeleteCmdFlags() {
 	NodeDeleteCmd.Flags().StringP(FLAG_ARC_NODE_ID, "", "", locales.AttributeDescription("arc-node-id"))
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-delete-node-id", NodeDeleteCmd.Flags().Lookup(FLAG_ARC_NODE_ID)), "BindPFlag:")
+	NodeDeleteCmd.Flags().StringP(FLAG_ARC_NODE, "", "", locales.AttributeDescription(FLAG_ARC_NODE))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-delete-node-name", NodeDeleteCmd.Flags().Lookup(FLAG_ARC_NODE)), "BindPFlag:")
+	NodeDeleteCmd.Flags().StringP(FLAG_SELECTOR, "", "", locales.AttributeDescription("node-delete-selector"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-delete-selector", NodeDeleteCmd.Flags().Lookup(FLAG_SELECTOR)), "BindPFlag:")
+	NodeDeleteCmd.Flags().StringP("stale-since", "", "", locales.AttributeDescription("node-delete-stale-since"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-delete-stale-since", NodeDeleteCmd.Flags().Lookup("stale-since")), "BindPFlag:")
+	NodeDeleteCmd.Flags().IntP("concurrency", "", 5, locales.AttributeDescription("concurrency"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-delete-concurrency", NodeDeleteCmd.Flags().Lookup("concurrency")), "BindPFlag:")
+	NodeDeleteCmd.Flags().BoolP("yes", "y", false, locales.AttributeDescription("yes"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-delete-yes", NodeDeleteCmd.Flags().Lookup("yes")), "BindPFlag:")
+}
+
+// nodeDeleteBulk deletes the nodes matching the selector and the stale since option after confirmation
+func nodeDeleteBulk(cmd *cobra.Command) error {
+	var staleSince time.Duration
+	var err error
+	if len(viper.GetString("arc-delete-stale-since")) > 0 {
+		staleSince, err = helpers.ParseDuration(viper.GetString("arc-delete-stale-since"))
+		if err != nil {
+			return err
+		}
+	}
+
+	nodes, err := nodeListBySelector(viper.GetString("arc-delete-selector"))
+	if err != nil {
+		return err
+	}
+	if staleSince > 0 {
+		nodes = staleNodes(nodes, time.Now().Add(-staleSince))
+	}
+	if len(nodes) == 0 {
+		cmd.Println("No nodes match.")
+		return nil
+	}
+
+	// show the matching nodes
+	printer := print.Print{Data: nodes}
+	tablePrint, err := printer.TableList([]string{"agent_id", "display_name", "organization", "project", "updated_at"})
+	if err != nil {
+		return err
+	}
+	// the preview goes to the stderr to keep the standard output for the results
+	cmd.Println(tablePrint)
+	cmd.Printf("%d nodes match.\n", len(nodes))
+
+	if !viper.GetBool("arc-delete-yes") && !viper.GetBool(FLAG_DRY_RUN) {
+		confirmed, err := confirm(cmd, fmt.Sprintf("Delete %d nodes?", len(nodes)))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			cmd.Println("Node deletion cancelled.")
+			return nil
+		}
+	}
+
+	ids := nodeIds(nodes)
+	results := make([]NodeResult, len(ids))
+	forEachConcurrently(len(ids), viper.GetInt("arc-delete-concurrency"), func(i int) {
+		_, err := nodeDelete(ids[i])
+		results[i] = newNodeResult(ids[i], err)
+	})
+
+	failed, err := printNodeResults(results)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf(locales.ErrorMessages("node-delete-failed"), failed, len(ids))
+	}
+	return nil
+}
+
+// staleNodes returns the nodes not updated since the given time
+func staleNodes(nodes []interface{}, since time.Time) []interface{} {
+	result := []interface{}{}
+	for _, node := range nodes {
+		nodeMap, ok := node.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		updatedAt, err := time.Parse(time.RFC3339Nano, fmt.Sprint(nodeMap["updated_at"]))
+		if err == nil && updatedAt.Before(since) {
+			result = append(result, node)
+		}
+	}
+	return result
 }
 
 func nodeDelete(id string) (string, error) {
@@ -1,11 +1,17 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	auth "github.com/sapcc/go-openstack-auth"
 	"github.com/sapcc/lyra-cli/locales"
@@ -87,3 +93,145 @@ func TestNodeDeleteCmdRightParams(t *testing.T) {
 	// run commando
 	FullCmdTester(RootCmd, fmt.Sprintf("lyra node delete --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --node-id=%s", "https://somewhere.com", server.URL, "token123", "123456789"))
 }
+
+func TestNodeDeleteCmdPositionalIds(t *testing.T) {
+	deleted := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			deleted = append(deleted, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	// reset stuff
+	ResetFlags()
+	// run commando
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra node delete node1 node2 --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s", "https://somewhere.com", server.URL, "token123"))
+	if resulter.Error != nil {
+		t.Errorf("Command expected to not get an error: %s", resulter.Error)
+	}
+	want := []string{"/api/v1/agents/node1", "/api/v1/agents/node2"}
+	if len(deleted) != len(want) || deleted[0] != want[0] || deleted[1] != want[1] {
+		t.Errorf("Expected deleted nodes %q, got %q", want, deleted)
+	}
+	for _, id := range []string{"node1", "node2"} {
+		if !strings.Contains(resulter.ErrorOutput, fmt.Sprint("Node with id  ", id, "  deleted.")) {
+			t.Errorf("Expected node %s to be reported as deleted, got %s", id, resulter.ErrorOutput)
+		}
+	}
+}
+
+type nodeDeleteBulkServer struct {
+	*httptest.Server
+	mutex   sync.Mutex
+	deleted []string
+}
+
+func newNodeDeleteBulkServer(t *testing.T, failing string) *nodeDeleteBulkServer {
+	server := &nodeDeleteBulkServer{}
+	recent := time.Now().Add(-time.Hour).Format(time.RFC3339Nano)
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "GET" && r.URL.Path == "/api/v1/agents" {
+			if r.URL.Query().Get("q") != "@os='linux'" && r.URL.Query().Get("q") != "" {
+				t.Errorf("Unexpected selector %q", r.URL.Query().Get("q"))
+			}
+			fmt.Fprintf(w, `[{"agent_id":"node1","display_name":"web1","updated_at":"2016-12-09T14:40:56.1885Z"},{"agent_id":"node2","display_name":"web2","updated_at":"%s"},{"agent_id":"node3","display_name":"web3","updated_at":"2017-02-08T14:36:01.762113Z"}]`, recent)
+			return
+		}
+		if r.Method == "DELETE" {
+			id := strings.TrimPrefix(r.URL.Path, "/api/v1/agents/")
+			if id == failing {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprintln(w, `{"error":"boom"}`)
+				return
+			}
+			server.mutex.Lock()
+			server.deleted = append(server.deleted, id)
+			server.mutex.Unlock()
+			return
+		}
+		t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+	return server
+}
+
+func nodeDeleteBulkTester(t *testing.T, server *nodeDeleteBulkServer, stdin, flags string) resulter {
+	// keep backup of the real stdin
+	oldStdin := os.Stdin
+	defer func() { os.Stdin = oldStdin }()
+	if _, err := pipeToStdin(stdin); err != nil {
+		t.Fatal(err)
+	}
+
+	ResetFlags()
+	return FullCmdTester(RootCmd, fmt.Sprintf("lyra node delete --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s %s", server.URL, server.URL, "token123", flags))
+}
+
+func TestNodeDeleteCmdSelector(t *testing.T) {
+	server := newNodeDeleteBulkServer(t, "")
+	defer server.Close()
+
+	resulter := nodeDeleteBulkTester(t, server, "", "--selector=@os='linux' --yes --concurrency=2")
+	if resulter.Error != nil {
+		t.Errorf("Command expected to not get an error: %s", resulter.Error)
+	}
+	sort.Strings(server.deleted)
+	if want := []string{"node1", "node2", "node3"}; !reflect.DeepEqual(server.deleted, want) {
+		t.Errorf("Expected deleted nodes %q, got %q", want, server.deleted)
+	}
+	if !strings.Contains(resulter.ErrorOutput, "3 nodes match.") {
+		t.Errorf("Expected the matches to be reported, got %s", resulter.ErrorOutput)
+	}
+}
+
+func TestNodeDeleteCmdStaleSince(t *testing.T) {
+	server := newNodeDeleteBulkServer(t, "node3")
+	defer server.Close()
+
+	resulter := nodeDeleteBulkTester(t, server, "", "--stale-since=30d --yes --json")
+	errorMsg := fmt.Sprintf(locales.ErrorMessages("node-delete-failed"), 1, 2)
+	if resulter.Error == nil || resulter.Error.Error() != errorMsg {
+		t.Errorf("Expected error %q, got %v", errorMsg, resulter.Error)
+	}
+	if want := []string{"node1"}; !reflect.DeepEqual(server.deleted, want) {
+		t.Errorf("Expected deleted nodes %q, got %q", want, server.deleted)
+	}
+
+	// the table of the matches goes to the stderr
+	results := []NodeResult{}
+	if err := json.Unmarshal([]byte(resulter.Output), &results); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resulter.ErrorOutput, "2 nodes match.") || !strings.Contains(resulter.ErrorOutput, "node1") {
+		t.Errorf("Expected the matches on the stderr, got %s", resulter.ErrorOutput)
+	}
+	if len(results) != 2 || results[0] != (NodeResult{NodeId: "node1", Result: NodeResultSuccess}) || results[1].Result != NodeResultFailed {
+		t.Errorf("Unexpected results %#v", results)
+	}
+}
+
+func TestNodeDeleteCmdSelectorConfirmation(t *testing.T) {
+	server := newNodeDeleteBulkServer(t, "")
+	defer server.Close()
+
+	resulter := nodeDeleteBulkTester(t, server, "n\n", "--selector=@os='linux'")
+	if resulter.Error != nil {
+		t.Errorf("Command expected to not get an error: %s", resulter.Error)
+	}
+	if len(server.deleted) != 0 {
+		t.Errorf("Expected no node to be deleted, got %q", server.deleted)
+	}
+
+	resulter = nodeDeleteBulkTester(t, server, "", "--selector=@os='linux'")
+	if !strings.Contains(resulter.ErrorOutput, locales.ErrorMessages("confirmation-missing")) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, locales.ErrorMessages("confirmation-missing")))
+	}
+
+	resulter = nodeDeleteBulkTester(t, server, "y\n", "--selector=@os='linux' --node-id=node1")
+	if !strings.Contains(resulter.ErrorOutput, locales.ErrorMessages("node-delete-selector-ids")) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, locales.ErrorMessages("node-delete-selector-ids")))
+	}
+	if len(server.deleted) != 0 {
+		t.Errorf("Expected no node to be deleted, got %q", server.deleted)
+	}
+}
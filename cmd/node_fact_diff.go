@@ -0,0 +1,193 @@
+// Copyright © 2016 Arturo Reuschenbach Puncernau <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/print"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	FactAdded   = "added"
+	FactRemoved = "removed"
+	FactChanged = "changed"
+)
+
+// FactChange describes a fact differing between two fact sets
+type FactChange struct {
+	Fact   string `json:"fact"`
+	Change string `json:"change"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+}
+
+var NodeFactDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: locales.CmdShortDescription("arc-node-fact-diff"),
+	Long:  locales.CmdLongDescription("arc-node-fact-diff"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ids := uniqueIds(viper.GetStringSlice("arc-fact-diff-node-id"))
+		for _, name := range viper.GetStringSlice("arc-fact-diff-node-name") {
+			id, err := findNodeId(name)
+			if err != nil {
+				return err
+			}
+			ids = uniqueIds(append(ids, id))
+		}
+
+		var oldLabel, newLabel string
+		var oldFacts, newFacts map[string]interface{}
+		if since := viper.GetString("arc-fact-diff-since"); len(since) > 0 {
+			// compare the snapshot with the current facts of its node or the given one
+			if len(ids) > 1 {
+				return errors.New(locales.ErrorMessages("fact-diff-nodes"))
+			}
+			snapshot, err := readFactSnapshot(since)
+			if err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				ids = []string{snapshot.NodeId}
+			}
+			oldLabel, oldFacts = snapshot.Id, snapshot.Facts
+		} else {
+			if len(ids) != 2 {
+				return errors.New(locales.ErrorMessages("fact-diff-nodes"))
+			}
+			facts, err := nodeFacts(ids[0])
+			if err != nil {
+				return err
+			}
+			oldLabel, oldFacts = ids[0], facts
+			ids = ids[1:]
+		}
+		newLabel = ids[0]
+		newFacts, err := nodeFacts(newLabel)
+		if err != nil {
+			return err
+		}
+
+		changes, err := factDiff(oldFacts, newFacts)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			cmd.Println(locales.Messages("fact-diff-unchanged"))
+			return nil
+		}
+		return printFactChanges(changes, oldLabel, newLabel)
+	},
+}
+
+func init() {
+	NodeFactCmd.AddCommand(NodeFactDiffCmd)
+	initNodeFactDiffCmdFlags()
+}
+
+func initNodeFactDiffCmdFlags() {
+	NodeFactDiffCmd.Flags().StringArray(FLAG_ARC_NODE_ID, nil, locales.AttributeDescription("fact-diff-node-id"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-fact-diff-node-id", NodeFactDiffCmd.Flags().Lookup(FLAG_ARC_NODE_ID)), "BindPFlag:")
+	NodeFactDiffCmd.Flags().StringArray(FLAG_ARC_NODE, nil, locales.AttributeDescription("fact-diff-node"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-fact-diff-node-name", NodeFactDiffCmd.Flags().Lookup(FLAG_ARC_NODE)), "BindPFlag:")
+	NodeFactDiffCmd.Flags().StringP("since", "", "", locales.AttributeDescription("fact-diff-since"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-fact-diff-since", NodeFactDiffCmd.Flags().Lookup("since")), "BindPFlag:")
+}
+
+// factDiff returns the added, removed and changed facts sorted by name. Nested facts are compared by their
+// dotted path.
+func factDiff(oldFacts, newFacts map[string]interface{}) ([]FactChange, error) {
+	oldFlat, newFlat := map[string]string{}, map[string]string{}
+	if err := flattenFacts("", oldFacts, oldFlat); err != nil {
+		return nil, err
+	}
+	if err := flattenFacts("", newFacts, newFlat); err != nil {
+		return nil, err
+	}
+
+	changes := []FactChange{}
+	for key, oldValue := range oldFlat {
+		newValue, ok := newFlat[key]
+		switch {
+		case !ok:
+			changes = append(changes, FactChange{Fact: key, Change: FactRemoved, Old: oldValue})
+		case newValue != oldValue:
+			changes = append(changes, FactChange{Fact: key, Change: FactChanged, Old: oldValue, New: newValue})
+		}
+	}
+	for key, newValue := range newFlat {
+		if _, ok := oldFlat[key]; !ok {
+			changes = append(changes, FactChange{Fact: key, Change: FactAdded, New: newValue})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Fact < changes[j].Fact })
+	return changes, nil
+}
+
+// flattenFacts adds the facts to result with nested objects joined by dots. Values other than strings are JSON.
+func flattenFacts(prefix string, facts map[string]interface{}, result map[string]string) error {
+	for key, value := range facts {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if len(value) > 0 {
+				if err := flattenFacts(prefix+key+".", value, result); err != nil {
+					return err
+				}
+				continue
+			}
+		case string:
+			result[prefix+key] = value
+			continue
+		}
+		bin, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		result[prefix+key] = string(bin)
+	}
+	return nil
+}
+
+// printFactChanges prints the changes as JSON or as table with the labels of both sides as columns
+func printFactChanges(changes []FactChange, oldLabel, newLabel string) error {
+	if viper.GetBool("json") {
+		printer := print.Print{Data: changes}
+		bodyPrint, err := printer.JSON()
+		if err != nil {
+			return err
+		}
+		fmt.Println(bodyPrint)
+		return nil
+	}
+
+	rows := []interface{}{}
+	for _, change := range changes {
+		rows = append(rows, map[string]interface{}{"fact": change.Fact, "change": change.Change, oldLabel: change.Old, newLabel: change.New})
+	}
+	printer := print.Print{Data: rows}
+	tablePrint, err := printer.TableList([]string{"fact", "change", oldLabel, newLabel})
+	if err != nil {
+		return err
+	}
+	fmt.Println(tablePrint)
+	return nil
+}
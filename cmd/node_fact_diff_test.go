@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sapcc/lyra-cli/locales"
+)
+
+func nodeFactDiffTester(t *testing.T, options string) resulter {
+	var listRequests int32
+	server := newNodeSnapshotServer(t, &listRequests)
+	defer server.Close()
+
+	ResetFlags()
+	return FullCmdTester(RootCmd, fmt.Sprintf("lyra node fact diff %s --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", options, server.URL, server.URL))
+}
+
+func TestNodeFactDiffCmdNodes(t *testing.T) {
+	resulter := nodeFactDiffTester(t, "--node-id node1 --node-id node3 --json")
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	changes := []FactChange{}
+	if err := json.Unmarshal([]byte(resulter.Output), &changes); err != nil {
+		t.Fatal(err)
+	}
+	want := []FactChange{
+		{Fact: "hostname", Change: FactRemoved, Old: "node1.example.com"},
+		{Fact: "online", Change: FactChanged, Old: "true", New: "false"},
+		{Fact: "os", Change: FactChanged, Old: "linux", New: "windows"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Expected %+v, got %+v", want, changes)
+	}
+
+	// the table is labeled with the node ids
+	resulter = nodeFactDiffTester(t, "--node-id node3 --node-id node1")
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	for _, text := range []string{"NODE3", "NODE1", "added", "node1.example.com"} {
+		if !strings.Contains(resulter.Output, text) {
+			t.Errorf("Expected %q in the table, got %s", text, resulter.Output)
+		}
+	}
+}
+
+func TestNodeFactDiffCmdNodeCount(t *testing.T) {
+	for _, options := range []string{"--node-id node1", "--node-id node1 --node-id node2 --node-id node3", "--node-id node1 --node-id node1"} {
+		resulter := nodeFactDiffTester(t, options)
+		if resulter.Error == nil || resulter.Error.Error() != locales.ErrorMessages("fact-diff-nodes") {
+			t.Errorf("Expected node count error for %q, got %v", options, resulter.Error)
+		}
+	}
+}
+
+func TestFactDiffNested(t *testing.T) {
+	oldFacts := map[string]interface{}{"network": map[string]interface{}{"eth0": map[string]interface{}{"ip": "10.0.0.1"}, "eth1": map[string]interface{}{"ip": "10.0.0.2"}}, "memory_total": float64(8080)}
+	newFacts := map[string]interface{}{"network": map[string]interface{}{"eth0": map[string]interface{}{"ip": "10.0.0.3"}}, "memory_total": float64(8080), "disks": []interface{}{"sda"}}
+	changes, err := factDiff(oldFacts, newFacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FactChange{
+		{Fact: "disks", Change: FactAdded, New: `["sda"]`},
+		{Fact: "network.eth0.ip", Change: FactChanged, Old: "10.0.0.1", New: "10.0.0.3"},
+		{Fact: "network.eth1.ip", Change: FactRemoved, Old: "10.0.0.2"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Expected %+v, got %+v", want, changes)
+	}
+}
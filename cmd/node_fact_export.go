@@ -0,0 +1,310 @@
+// Copyright © 2016 Arturo Reuschenbach Puncernau <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/print"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var inventoryFormats = []string{"ansible-ini", "ansible-yaml", "json", "csv"}
+
+// InventoryHost is a node of the exported inventory
+type InventoryHost struct {
+	NodeId string                 `json:"node_id"`
+	Host   string                 `json:"host"`
+	Groups []string               `json:"groups"`
+	Tags   map[string]string      `json:"tags"`
+	Facts  map[string]interface{} `json:"facts"`
+}
+
+// vars returns the host variables
+func (h InventoryHost) vars() map[string]interface{} {
+	vars := map[string]interface{}{"arc_node_id": h.NodeId}
+	for key, value := range h.Facts {
+		vars[key] = value
+	}
+	return vars
+}
+
+var NodeFactExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: locales.CmdShortDescription("arc-node-fact-export"),
+	Long:  locales.CmdLongDescription("arc-node-fact-export"),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		format := viper.GetString("arc-fact-export-format")
+		for _, known := range inventoryFormats {
+			if format == known {
+				return nil
+			}
+		}
+		return fmt.Errorf(locales.ErrorMessages("inventory-format-invalid"), format, strings.Join(inventoryFormats, ", "))
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		expression := viper.GetString("arc-fact-export-selector")
+		nodes, err := nodeListBySelector(expression)
+		if err != nil {
+			return err
+		}
+		if len(nodes) == 0 && len(expression) > 0 {
+			return fmt.Errorf(locales.ErrorMessages("selector-no-nodes"), expression)
+		}
+		snapshots, err := collectNodeSnapshots(nodes, viper.GetInt("arc-fact-export-concurrency"))
+		if err != nil {
+			return err
+		}
+
+		hosts := inventoryHosts(snapshots, viper.GetString("arc-fact-export-host-fact"), viper.GetStringSlice("arc-fact-export-group-by"), viper.GetStringSlice("arc-fact-export-fact"))
+		inventory, err := formatInventory(hosts, viper.GetString("arc-fact-export-format"))
+		if err != nil {
+			return err
+		}
+		fmt.Print(inventory)
+		return nil
+	},
+}
+
+func init() {
+	NodeFactCmd.AddCommand(NodeFactExportCmd)
+	initNodeFactExportCmdFlags()
+}
+
+func initNodeFactExportCmdFlags() {
+	NodeFactExportCmd.Flags().StringP(FLAG_SELECTOR, "", "", locales.AttributeDescription("node-selector"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-fact-export-selector", NodeFactExportCmd.Flags().Lookup(FLAG_SELECTOR)), "BindPFlag:")
+	NodeFactExportCmd.Flags().StringP("format", "", "ansible-ini", locales.AttributeDescription("inventory-format"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-fact-export-format", NodeFactExportCmd.Flags().Lookup("format")), "BindPFlag:")
+	NodeFactExportCmd.Flags().StringArray("group-by", nil, locales.AttributeDescription("inventory-group-by"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-fact-export-group-by", NodeFactExportCmd.Flags().Lookup("group-by")), "BindPFlag:")
+	NodeFactExportCmd.Flags().StringArray("fact", nil, locales.AttributeDescription("inventory-fact"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-fact-export-fact", NodeFactExportCmd.Flags().Lookup("fact")), "BindPFlag:")
+	NodeFactExportCmd.Flags().StringP("host-fact", "", "hostname", locales.AttributeDescription("inventory-host-fact"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-fact-export-host-fact", NodeFactExportCmd.Flags().Lookup("host-fact")), "BindPFlag:")
+	NodeFactExportCmd.Flags().IntP("concurrency", "", 5, locales.AttributeDescription("concurrency"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-fact-export-concurrency", NodeFactExportCmd.Flags().Lookup("concurrency")), "BindPFlag:")
+}
+
+var groupNameRegexp = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// hostNameRegexp matches names usable as host in all inventory formats. White space, brackets, '=', '#' and ';'
+// break the ini format.
+var hostNameRegexp = regexp.MustCompile(`^[^\s\[\]=#;]+$`)
+
+// inventoryHosts returns the hosts sorted by name. The host name is the host fact, the display name or the node id.
+// Nodes are grouped by the value of every group by tag or '@' prefixed fact. Only the given facts are kept.
+func inventoryHosts(snapshots []NodeSnapshot, hostFact string, groupBy []string, facts []string) []InventoryHost {
+	hosts := []InventoryHost{}
+	names := map[string]bool{}
+	for _, snapshot := range snapshots {
+		host := InventoryHost{NodeId: snapshot.Id(), Groups: []string{}, Tags: snapshot.Tags, Facts: snapshot.Facts}
+		if host.Tags == nil {
+			host.Tags = map[string]string{}
+		}
+		if len(facts) > 0 {
+			host.Facts = map[string]interface{}{}
+			for _, fact := range facts {
+				if value, ok := snapshot.Facts[fact]; ok {
+					host.Facts[fact] = value
+				}
+			}
+		}
+
+		// names have to be unique and valid, otherwise the node id is used
+		for _, name := range []interface{}{snapshot.Facts[hostFact], snapshot.Node["display_name"], host.NodeId} {
+			if name != nil && hostNameRegexp.MatchString(fmt.Sprint(name)) && !names[fmt.Sprint(name)] {
+				host.Host = fmt.Sprint(name)
+				break
+			}
+		}
+		if host.Host == "" {
+			host.Host = host.NodeId
+		}
+		names[host.Host] = true
+
+		for _, key := range groupBy {
+			var value interface{}
+			if strings.HasPrefix(key, "@") {
+				value = snapshot.Facts[strings.TrimPrefix(key, "@")]
+			} else if tag, ok := snapshot.Tags[key]; ok {
+				value = tag
+			}
+			if value == nil || fmt.Sprint(value) == "" {
+				continue
+			}
+			group := groupNameRegexp.ReplaceAllString(strings.TrimPrefix(key, "@")+"_"+fmt.Sprint(value), "_")
+			host.Groups = append(host.Groups, group)
+		}
+		hosts = append(hosts, host)
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Host < hosts[j].Host })
+	return hosts
+}
+
+// inventoryGroups returns the hosts of every group sorted by group name
+func inventoryGroups(hosts []InventoryHost) ([]string, map[string][]string) {
+	groups := map[string][]string{}
+	names := []string{}
+	for _, host := range hosts {
+		for _, group := range host.Groups {
+			if _, ok := groups[group]; !ok {
+				names = append(names, group)
+			}
+			groups[group] = append(groups[group], host.Host)
+		}
+	}
+	sort.Strings(names)
+	return names, groups
+}
+
+// formatInventory returns the hosts in the given format
+func formatInventory(hosts []InventoryHost, format string) (string, error) {
+	switch format {
+	case "ansible-ini":
+		return ansibleIniInventory(hosts)
+	case "ansible-yaml":
+		return ansibleYAMLInventory(hosts)
+	case "csv":
+		return csvInventory(hosts)
+	}
+	printer := print.Print{Data: hosts}
+	bodyPrint, err := printer.JSON()
+	if err != nil {
+		return "", err
+	}
+	return bodyPrint + "\n", nil
+}
+
+// ansibleIniInventory lists the hosts with their variables first followed by the groups
+func ansibleIniInventory(hosts []InventoryHost) (string, error) {
+	var b strings.Builder
+	for _, host := range hosts {
+		vars := host.vars()
+		keys := []string{}
+		for key := range vars {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b.WriteString(host.Host)
+		for _, key := range keys {
+			value, err := iniValue(vars[key])
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, " %s=%s", key, value)
+		}
+		b.WriteString("\n")
+	}
+
+	names, groups := inventoryGroups(hosts)
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n[%s]\n%s\n", name, strings.Join(groups[name], "\n"))
+	}
+	return b.String(), nil
+}
+
+// iniValue returns simple values as they are and quotes the rest as JSON
+func iniValue(value interface{}) (string, error) {
+	if text, ok := value.(string); ok && len(text) > 0 && !strings.ContainsAny(text, " \t\n'\"#=\\") {
+		return text, nil
+	}
+	bin, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := value.(string); ok {
+		return string(bin), nil
+	}
+	if bytes.ContainsAny(bin, " \t'") {
+		return "'" + strings.ReplaceAll(string(bin), "'", `\'`) + "'", nil
+	}
+	return string(bin), nil
+}
+
+// ansibleYAMLInventory returns the hosts with their variables under all and the groups as children
+func ansibleYAMLInventory(hosts []InventoryHost) (string, error) {
+	hostVars := map[string]interface{}{}
+	for _, host := range hosts {
+		hostVars[host.Host] = host.vars()
+	}
+	all := map[string]interface{}{"hosts": hostVars}
+
+	names, groups := inventoryGroups(hosts)
+	if len(names) > 0 {
+		children := map[string]interface{}{}
+		for _, name := range names {
+			members := map[string]interface{}{}
+			for _, host := range groups[name] {
+				members[host] = nil
+			}
+			children[name] = map[string]interface{}{"hosts": members}
+		}
+		all["children"] = children
+	}
+	return helpers.StructureToYAML(map[string]interface{}{"all": all})
+}
+
+// csvInventory returns one row per host with a column per fact
+func csvInventory(hosts []InventoryHost) (string, error) {
+	factNames := map[string]bool{}
+	for _, host := range hosts {
+		for key := range host.Facts {
+			factNames[key] = true
+		}
+	}
+	columns := []string{}
+	for key := range factNames {
+		columns = append(columns, key)
+	}
+	sort.Strings(columns)
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(append([]string{"node_id", "host", "groups"}, columns...)); err != nil {
+		return "", err
+	}
+	for _, host := range hosts {
+		row := []string{host.NodeId, host.Host, strings.Join(host.Groups, " ")}
+		for _, column := range columns {
+			switch value := host.Facts[column].(type) {
+			case nil:
+				row = append(row, "")
+			case string:
+				row = append(row, value)
+			default:
+				bin, err := json.Marshal(value)
+				if err != nil {
+					return "", err
+				}
+				row = append(row, string(bin))
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return "", err
+		}
+	}
+	writer.Flush()
+	return buf.String(), writer.Error()
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sapcc/lyra-cli/locales"
+)
+
+func nodeFactExportTester(t *testing.T, options string) resulter {
+	var listRequests int32
+	server := newNodeSnapshotServer(t, &listRequests)
+	defer server.Close()
+
+	ResetFlags()
+	return FullCmdTester(RootCmd, fmt.Sprintf("lyra node fact export %s --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", options, server.URL, server.URL))
+}
+
+func TestNodeFactExportCmdAnsibleIni(t *testing.T) {
+	resulter := nodeFactExportTester(t, "--group-by pool --group-by @os")
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	want := `db1 arc_node_id=node3 online=false os=windows
+node1.example.com arc_node_id=node1 hostname=node1.example.com online=true os=linux
+node2.example.com arc_node_id=node2 hostname=node2.example.com online=true os=linux
+
+[os_linux]
+node1.example.com
+node2.example.com
+
+[os_windows]
+db1
+
+[pool_blue]
+node2.example.com
+
+[pool_green]
+node1.example.com
+`
+	if resulter.Output != want {
+		t.Errorf("Command response body doesn't match. \n \n %s", StringDiff(resulter.Output, want))
+	}
+}
+
+func TestAnsibleIniInventoryInvalidHostNames(t *testing.T) {
+	snapshots := []NodeSnapshot{
+		{Node: map[string]interface{}{"agent_id": "node1", "display_name": "web server"}, Facts: map[string]interface{}{"hostname": "web[1]"}},
+		{Node: map[string]interface{}{"agent_id": "node2", "display_name": "db=primary"}, Facts: map[string]interface{}{}},
+		{Node: map[string]interface{}{"agent_id": "node3", "display_name": "cache"}, Facts: map[string]interface{}{"hostname": "cache #2"}},
+	}
+	result, err := ansibleIniInventory(inventoryHosts(snapshots, "hostname", []string{}, []string{"os"}))
+	if err != nil {
+		t.Fatalf("Expected to not get an error: %s", err)
+	}
+	want := `cache arc_node_id=node3
+node1 arc_node_id=node1
+node2 arc_node_id=node2
+`
+	if result != want {
+		t.Errorf("Inventory doesn't match. \n \n %s", StringDiff(result, want))
+	}
+}
+
+func TestNodeFactExportCmdAnsibleYAML(t *testing.T) {
+	resulter := nodeFactExportTester(t, "--selector=@os='linux' --format ansible-yaml --group-by pool --fact os")
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	want := `all:
+    children:
+        pool_blue:
+            hosts:
+                node2.example.com: null
+        pool_green:
+            hosts:
+                node1.example.com: null
+    hosts:
+        node1.example.com:
+            arc_node_id: node1
+            os: linux
+        node2.example.com:
+            arc_node_id: node2
+            os: linux
+`
+	if resulter.Output != want {
+		t.Errorf("Command response body doesn't match. \n \n %s", StringDiff(resulter.Output, want))
+	}
+}
+
+func TestNodeFactExportCmdJSONAndCSV(t *testing.T) {
+	resulter := nodeFactExportTester(t, "--format json --host-fact none --group-by pool")
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	hosts := []InventoryHost{}
+	if err := json.Unmarshal([]byte(resulter.Output), &hosts); err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, host := range hosts {
+		got = append(got, fmt.Sprint(host.NodeId, " ", host.Host, " ", host.Groups, " ", host.Tags["pool"]))
+	}
+	if want := []string{"node3 db1 [] ", "node1 web1 [pool_green] green", "node2 web2 [pool_blue] blue"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	resulter = nodeFactExportTester(t, "--format csv --fact os --fact online --group-by pool")
+	want := "node_id,host,groups,online,os\nnode3,db1,,false,windows\nnode1,node1.example.com,pool_green,true,linux\nnode2,node2.example.com,pool_blue,true,linux\n"
+	if resulter.Output != want {
+		t.Errorf("Command response body doesn't match. \n \n %s", StringDiff(resulter.Output, want))
+	}
+}
+
+func TestNodeFactExportCmdInvalidFormat(t *testing.T) {
+	resulter := nodeFactExportTester(t, "--format xml")
+	want := fmt.Sprintf(locales.ErrorMessages("inventory-format-invalid"), "xml", "ansible-ini, ansible-yaml, json, csv")
+	if resulter.Error == nil || resulter.Error.Error() != want {
+		t.Errorf("Expected error %q, got %v", want, resulter.Error)
+	}
+}
+
+func TestIniValue(t *testing.T) {
+	tests := map[interface{}]string{
+		"linux":        "linux",
+		"Ubuntu 22.04": `"Ubuntu 22.04"`,
+		"":             `""`,
+		true:           "true",
+		float64(8):     "8",
+	}
+	for value, want := range tests {
+		if got, err := iniValue(value); err != nil || got != want {
+			t.Errorf("Expected %v to be %s, got %s %v", value, want, got, err)
+		}
+	}
+	if got, _ := iniValue(map[string]interface{}{"a": "b c"}); got != `'{"a":"b c"}'` {
+		t.Errorf("Unexpected value %s", got)
+	}
+}
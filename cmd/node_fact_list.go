@@ -15,7 +15,6 @@
 package cmd
 
 import (
-	"errors"
 	"fmt"
 	"net/url"
 	"path"
@@ -31,11 +30,8 @@ var NodeFactListCmd = &cobra.Command{
 	Use:   "list",
 	Short: locales.CmdShortDescription("arc-node-fact-list"),
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		// check required node id
-		if len(viper.GetString("arc-fact-list-node-id")) == 0 {
-			return errors.New(locales.ErrorMessages("node-id-missing"))
-		}
-		return nil
+		// resolve the node id from the name if no id given
+		return resolveNodeId("arc-fact-list-node-id", "arc-fact-list-node-name")
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// list automation
@@ -81,6 +77,8 @@ func init() {
 func initNodeFactListCmdFlags() {
 	NodeFactListCmd.Flags().StringP(FLAG_ARC_NODE_ID, "", "", locales.AttributeDescription(FLAG_ARC_NODE_ID))
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-fact-list-node-id", NodeFactListCmd.Flags().Lookup(FLAG_ARC_NODE_ID)), "BindPFlag:")
+	NodeFactListCmd.Flags().StringP(FLAG_ARC_NODE, "", "", locales.AttributeDescription(FLAG_ARC_NODE))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-fact-list-node-name", NodeFactListCmd.Flags().Lookup(FLAG_ARC_NODE)), "BindPFlag:")
 }
 
 func nodeFactList(id string) (string, error) {
@@ -0,0 +1,137 @@
+// Copyright © 2016 Arturo Reuschenbach Puncernau <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/print"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// factSnapshotDir is the data sub directory of the fact snapshots
+const factSnapshotDir = "fact-snapshots"
+
+// FactSnapshot is the set of facts of a node at a given time
+type FactSnapshot struct {
+	Id        string                 `json:"id"`
+	NodeId    string                 `json:"node_id"`
+	CreatedAt time.Time              `json:"created_at"`
+	Facts     map[string]interface{} `json:"facts"`
+}
+
+var NodeFactSnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: locales.CmdShortDescription("arc-node-fact-snapshot"),
+	Long:  locales.CmdLongDescription("arc-node-fact-snapshot"),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// all snapshots are listed without node
+		if viper.GetBool("arc-fact-snapshot-list") && len(viper.GetString("arc-fact-snapshot-node-id")) == 0 && len(viper.GetString("arc-fact-snapshot-node-name")) == 0 {
+			return nil
+		}
+		// resolve the node id from the name if no id given
+		return resolveNodeId("arc-fact-snapshot-node-id", "arc-fact-snapshot-node-name")
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id := viper.GetString("arc-fact-snapshot-node-id")
+		if viper.GetBool("arc-fact-snapshot-list") {
+			return printFactSnapshots(id)
+		}
+
+		facts, err := nodeFacts(id)
+		if err != nil {
+			return err
+		}
+		createdAt := time.Now().UTC()
+		snapshot := FactSnapshot{Id: fmt.Sprintf("%s-%s", createdAt.Format("20060102T150405Z"), id), NodeId: id, CreatedAt: createdAt, Facts: facts}
+		err = helpers.WriteData(factSnapshotDir, snapshot.Id+".json", snapshot)
+		if err != nil {
+			return err
+		}
+
+		cmd.Println(fmt.Sprintf(locales.Messages("fact-snapshot-saved"), len(facts), id))
+		fmt.Println(snapshot.Id)
+		return nil
+	},
+}
+
+func init() {
+	NodeFactCmd.AddCommand(NodeFactSnapshotCmd)
+	initNodeFactSnapshotCmdFlags()
+}
+
+func initNodeFactSnapshotCmdFlags() {
+	NodeFactSnapshotCmd.Flags().StringP(FLAG_ARC_NODE_ID, "", "", locales.AttributeDescription(FLAG_ARC_NODE_ID))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-fact-snapshot-node-id", NodeFactSnapshotCmd.Flags().Lookup(FLAG_ARC_NODE_ID)), "BindPFlag:")
+	NodeFactSnapshotCmd.Flags().StringP(FLAG_ARC_NODE, "", "", locales.AttributeDescription(FLAG_ARC_NODE))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-fact-snapshot-node-name", NodeFactSnapshotCmd.Flags().Lookup(FLAG_ARC_NODE)), "BindPFlag:")
+	NodeFactSnapshotCmd.Flags().BoolP("list", "", false, locales.AttributeDescription("fact-snapshot-list"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-fact-snapshot-list", NodeFactSnapshotCmd.Flags().Lookup("list")), "BindPFlag:")
+}
+
+// readFactSnapshot returns the stored snapshot with the given id
+func readFactSnapshot(id string) (*FactSnapshot, error) {
+	if len(id) == 0 || filepath.Base(id) != id {
+		return nil, fmt.Errorf(locales.ErrorMessages("fact-snapshot-missing"), id)
+	}
+	snapshot := FactSnapshot{}
+	err := helpers.ReadData(factSnapshotDir, id+".json", &snapshot)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf(locales.ErrorMessages("fact-snapshot-missing"), id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// printFactSnapshots lists the stored snapshots of the node or of all nodes when no node id is given
+func printFactSnapshots(nodeId string) error {
+	names, err := helpers.ListData(factSnapshotDir, ".json")
+	if err != nil {
+		return err
+	}
+	rows := []interface{}{}
+	for _, name := range names {
+		snapshot, err := readFactSnapshot(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			return err
+		}
+		if len(nodeId) > 0 && snapshot.NodeId != nodeId {
+			continue
+		}
+		rows = append(rows, map[string]interface{}{"id": snapshot.Id, "node_id": snapshot.NodeId, "created_at": snapshot.CreatedAt.Format(time.RFC3339), "facts": len(snapshot.Facts)})
+	}
+
+	printer := print.Print{Data: rows}
+	var bodyPrint string
+	if viper.GetBool("json") {
+		bodyPrint, err = printer.JSON()
+	} else {
+		bodyPrint, err = printer.TableList([]string{"id", "node_id", "created_at", "facts"})
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(bodyPrint)
+	return nil
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sapcc/lyra-cli/locales"
+)
+
+func TestNodeFactSnapshotCmd(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	var listRequests int32
+	server := newNodeSnapshotServer(t, &listRequests)
+	defer server.Close()
+	endpoints := fmt.Sprintf("--lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", server.URL, server.URL)
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, "lyra node fact snapshot --node-id node1 "+endpoints)
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	id := strings.TrimSpace(resulter.Output)
+	if !strings.HasSuffix(id, "-node1") {
+		t.Fatalf("Expected the snapshot id of node1, got %q", id)
+	}
+	if !strings.Contains(resulter.ErrorOutput, "node1") {
+		t.Errorf("Expected the saved facts to be reported, got %q", resulter.ErrorOutput)
+	}
+
+	ResetFlags()
+	resulter = FullCmdTester(RootCmd, "lyra node fact snapshot --list "+endpoints)
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	if !strings.Contains(resulter.Output, id) {
+		t.Errorf("Expected the snapshot %s to be listed, got %s", id, resulter.Output)
+	}
+
+	// the snapshot matches the current facts of its node
+	ResetFlags()
+	resulter = FullCmdTester(RootCmd, fmt.Sprintf("lyra node fact diff --since %s %s", id, endpoints))
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	if !strings.Contains(resulter.ErrorOutput, locales.Messages("fact-diff-unchanged")) || len(resulter.Output) > 0 {
+		t.Errorf("Expected no differences, got %q", resulter.ErrorOutput)
+	}
+
+	ResetFlags()
+	resulter = FullCmdTester(RootCmd, fmt.Sprintf("lyra node fact diff --since %s --node-id node3 %s", id, endpoints))
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	for _, text := range []string{strings.ToUpper(id), "NODE3", "windows", "removed"} {
+		if !strings.Contains(resulter.Output, text) {
+			t.Errorf("Expected %q in the table, got %s", text, resulter.Output)
+		}
+	}
+}
+
+func TestNodeFactDiffCmdSnapshotMissing(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	for _, id := range []string{"20260101T000000Z-node1", "../node1"} {
+		resulter := nodeFactDiffTester(t, "--since "+id)
+		if resulter.Error == nil || !strings.Contains(resulter.Error.Error(), fmt.Sprintf("Fact snapshot %s not found", id)) {
+			t.Errorf("Expected missing snapshot error for %s, got %v", id, resulter.Error)
+		}
+	}
+}
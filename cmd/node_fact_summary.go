@@ -0,0 +1,159 @@
+// Copyright © 2016 Arturo Reuschenbach Puncernau <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/print"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// factMissing is shown for nodes not reporting a fact
+const factMissing = "(none)"
+
+// FactSummary counts the nodes per combination of fact values
+type FactSummary struct {
+	Facts   []string            `json:"facts"`
+	Total   int                 `json:"total"`
+	Buckets []FactSummaryBucket `json:"buckets"`
+}
+
+// FactSummaryBucket holds the nodes with the same fact values. Facts a node doesn't report are null.
+type FactSummaryBucket struct {
+	Values  map[string]interface{} `json:"values"`
+	Count   int                    `json:"count"`
+	Percent float64                `json:"percent"`
+	NodeIds []string               `json:"node_ids"`
+}
+
+var NodeFactSummaryCmd = &cobra.Command{
+	Use:   "summary",
+	Short: locales.CmdShortDescription("arc-node-fact-summary"),
+	Long:  locales.CmdLongDescription("arc-node-fact-summary"),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(viper.GetStringSlice("arc-fact-summary-fact")) == 0 {
+			return errors.New(locales.ErrorMessages("fact-summary-fact-missing"))
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		expression := viper.GetString("arc-fact-summary-selector")
+		facts := uniqueIds(viper.GetStringSlice("arc-fact-summary-fact"))
+		nodes, err := nodeListBySelector(expression, facts...)
+		if err != nil {
+			return err
+		}
+		if len(nodes) == 0 && len(expression) > 0 {
+			return fmt.Errorf(locales.ErrorMessages("selector-no-nodes"), expression)
+		}
+		return printFactSummary(cmd, nodeFactSummary(nodes, facts))
+	},
+}
+
+func init() {
+	NodeFactCmd.AddCommand(NodeFactSummaryCmd)
+	initNodeFactSummaryCmdFlags()
+}
+
+func initNodeFactSummaryCmdFlags() {
+	NodeFactSummaryCmd.Flags().StringArray("fact", nil, locales.AttributeDescription("fact-summary-fact"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-fact-summary-fact", NodeFactSummaryCmd.Flags().Lookup("fact")), "BindPFlag:")
+	NodeFactSummaryCmd.Flags().StringP(FLAG_SELECTOR, "", "", locales.AttributeDescription("node-selector"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-fact-summary-selector", NodeFactSummaryCmd.Flags().Lookup(FLAG_SELECTOR)), "BindPFlag:")
+}
+
+// nodeFactSummary groups the nodes by the values of the facts. The buckets are sorted by count descending.
+func nodeFactSummary(nodes []interface{}, facts []string) FactSummary {
+	summary := FactSummary{Facts: facts, Buckets: []FactSummaryBucket{}}
+	buckets := map[string]int{}
+	for _, node := range nodes {
+		nodeMap, ok := node.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		nodeFacts, _ := nodeMap["facts"].(map[string]interface{})
+		values := map[string]interface{}{}
+		keys := []string{}
+		for _, fact := range facts {
+			values[fact] = nodeFacts[fact]
+			keys = append(keys, fmt.Sprintf("%q", stringValue(nodeFacts[fact], factMissing)))
+		}
+		key := strings.Join(keys, ",")
+		index, ok := buckets[key]
+		if !ok {
+			index = len(summary.Buckets)
+			buckets[key] = index
+			summary.Buckets = append(summary.Buckets, FactSummaryBucket{Values: values, NodeIds: []string{}})
+		}
+		summary.Buckets[index].Count++
+		summary.Buckets[index].NodeIds = append(summary.Buckets[index].NodeIds, fmt.Sprint(nodeMap["agent_id"]))
+		summary.Total++
+	}
+
+	for i := range summary.Buckets {
+		summary.Buckets[i].Percent = float64(summary.Buckets[i].Count) * 100 / float64(summary.Total)
+		sort.Strings(summary.Buckets[i].NodeIds)
+	}
+	sort.SliceStable(summary.Buckets, func(i, j int) bool {
+		if summary.Buckets[i].Count != summary.Buckets[j].Count {
+			return summary.Buckets[i].Count > summary.Buckets[j].Count
+		}
+		for _, fact := range facts {
+			left, right := stringValue(summary.Buckets[i].Values[fact], factMissing), stringValue(summary.Buckets[j].Values[fact], factMissing)
+			if left != right {
+				return left < right
+			}
+		}
+		return false
+	})
+	return summary
+}
+
+// printFactSummary prints the summary as JSON or as table with a column per fact, the count and the percentage
+func printFactSummary(cmd *cobra.Command, summary FactSummary) error {
+	if viper.GetBool("json") {
+		printer := print.Print{Data: summary}
+		bodyPrint, err := printer.JSON()
+		if err != nil {
+			return err
+		}
+		fmt.Println(bodyPrint)
+		return nil
+	}
+
+	rows := []interface{}{}
+	for _, bucket := range summary.Buckets {
+		row := map[string]interface{}{"nodes": bucket.Count, "percent": fmt.Sprintf("%.1f%%", bucket.Percent)}
+		for _, fact := range summary.Facts {
+			row[fact] = stringValue(bucket.Values[fact], factMissing)
+		}
+		rows = append(rows, row)
+	}
+	printer := print.Print{Data: rows}
+	tablePrint, err := printer.TableList(append(append([]string{}, summary.Facts...), "nodes", "percent"))
+	if err != nil {
+		return err
+	}
+	fmt.Println(tablePrint)
+	cmd.Printf("%d nodes in %d groups.\n", summary.Total, len(summary.Buckets))
+	return nil
+}
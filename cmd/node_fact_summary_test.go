@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sapcc/lyra-cli/locales"
+)
+
+func nodeFactSummaryTester(t *testing.T, options string) resulter {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/agents" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if facts := r.URL.Query().Get("facts"); facts != "os,platform_version" {
+			t.Errorf("Unexpected facts %q requested", facts)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("q") == "@os='windows'" {
+			fmt.Fprintln(w, `[]`)
+			return
+		}
+		fmt.Fprintln(w, `[
+			{"agent_id":"node1","facts":{"os":"linux","platform_version":"22.04"}},
+			{"agent_id":"node2","facts":{"os":"windows","platform_version":"2019"}},
+			{"agent_id":"node3","facts":{"os":"linux","platform_version":"22.04"}},
+			{"agent_id":"node4","facts":{"os":"linux"}}
+		]`)
+	}))
+	defer server.Close()
+
+	ResetFlags()
+	return FullCmdTester(RootCmd, fmt.Sprintf("lyra node fact summary %s --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", options, server.URL, server.URL))
+}
+
+func TestNodeFactSummaryCmd(t *testing.T) {
+	resulter := nodeFactSummaryTester(t, "--fact os --fact platform_version")
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	want := `+---------+------------------+-------+---------+
+|   OS    | PLATFORM VERSION | NODES | PERCENT |
++---------+------------------+-------+---------+
+| linux   | 22.04            | 2     | 50.0%   |
+| linux   | (none)           | 1     | 25.0%   |
+| windows | 2019             | 1     | 25.0%   |
++---------+------------------+-------+---------+
+
+`
+	if resulter.Output != want {
+		t.Errorf("Command response body doesn't match. \n \n %s", StringDiff(resulter.Output, want))
+	}
+	if !strings.Contains(resulter.ErrorOutput, "4 nodes in 3 groups.") {
+		t.Errorf("Expected the totals, got %q", resulter.ErrorOutput)
+	}
+}
+
+func TestNodeFactSummaryCmdJSON(t *testing.T) {
+	resulter := nodeFactSummaryTester(t, "--fact os --fact platform_version --fact os --json")
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	summary := FactSummary{}
+	if err := json.Unmarshal([]byte(resulter.Output), &summary); err != nil {
+		t.Fatal(err)
+	}
+	want := FactSummary{
+		Facts: []string{"os", "platform_version"},
+		Total: 4,
+		Buckets: []FactSummaryBucket{
+			{Values: map[string]interface{}{"os": "linux", "platform_version": "22.04"}, Count: 2, Percent: 50, NodeIds: []string{"node1", "node3"}},
+			{Values: map[string]interface{}{"os": "linux", "platform_version": nil}, Count: 1, Percent: 25, NodeIds: []string{"node4"}},
+			{Values: map[string]interface{}{"os": "windows", "platform_version": "2019"}, Count: 1, Percent: 25, NodeIds: []string{"node2"}},
+		},
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("Expected %+v, got %+v", want, summary)
+	}
+}
+
+func TestNodeFactSummaryCmdErrors(t *testing.T) {
+	resulter := nodeFactSummaryTester(t, "")
+	if resulter.Error == nil || resulter.Error.Error() != locales.ErrorMessages("fact-summary-fact-missing") {
+		t.Errorf("Expected missing fact error, got %v", resulter.Error)
+	}
+
+	resulter = nodeFactSummaryTester(t, `--fact os --fact platform_version --selector=@os='windows'`)
+	if resulter.Error == nil || resulter.Error.Error() != fmt.Sprintf(locales.ErrorMessages("selector-no-nodes"), "@os='windows'") {
+		t.Errorf("Expected no nodes error, got %v", resulter.Error)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright © 2016 Arturo Reuschenbach <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/print"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	NodeOnline  = "online"
+	NodeStale   = "stale"
+	NodeOffline = "offline"
+)
+
+// NodeHealth describes the state of a node
+type NodeHealth struct {
+	NodeId       string `json:"agent_id"`
+	DisplayName  string `json:"display_name"`
+	Organization string `json:"organization"`
+	Project      string `json:"project"`
+	UpdatedAt    string `json:"updated_at"`
+	State        string `json:"state"`
+}
+
+// NodeHealthSummary counts the node states of a project
+type NodeHealthSummary struct {
+	Organization string `json:"organization"`
+	Project      string `json:"project"`
+	Online       int    `json:"online"`
+	Stale        int    `json:"stale"`
+	Offline      int    `json:"offline"`
+	Total        int    `json:"total"`
+}
+
+// NodeHealthReport is the result of the health command
+type NodeHealthReport struct {
+	Summary []NodeHealthSummary `json:"summary"`
+	Nodes   []NodeHealth        `json:"nodes"`
+}
+
+var NodeHealthCmd = &cobra.Command{
+	Use:   "health",
+	Short: locales.CmdShortDescription("arc-node-health"),
+	Long:  locales.CmdLongDescription("arc-node-health"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		staleAfter, err := helpers.ParseDuration(viper.GetString("arc-health-stale-after"))
+		if err != nil {
+			return err
+		}
+		offlineAfter, err := helpers.ParseDuration(viper.GetString("arc-health-offline-after"))
+		if err != nil {
+			return err
+		}
+		if staleAfter <= 0 || offlineAfter < staleAfter {
+			return errors.New(locales.ErrorMessages("node-health-thresholds-invalid"))
+		}
+
+		nodes, err := nodeListBySelector(viper.GetString("arc-health-selector"), "online")
+		if err != nil {
+			return err
+		}
+		report := nodeHealthReport(nodes, time.Now(), staleAfter, offlineAfter)
+
+		err = printNodeHealthReport(report)
+		if err != nil {
+			return err
+		}
+
+		// exit with an own code to be used as monitoring probe
+		offline := 0
+		for _, summary := range report.Summary {
+			offline += summary.Offline
+		}
+		maxOffline := viper.GetInt("arc-health-max-offline")
+		if maxOffline >= 0 && offline > maxOffline {
+			return &ExitError{Code: 2, Err: fmt.Errorf(locales.ErrorMessages("node-health-offline"), offline, maxOffline)}
+		}
+		return nil
+	},
+}
+
+func init() {
+	NodeCmd.AddCommand(NodeHealthCmd)
+	initNodeHealthCmdFlags()
+}
+
+func initNodeHealthCmdFlags() {
+	NodeHealthCmd.Flags().StringP(FLAG_SELECTOR, "", "", locales.AttributeDescription("node-selector"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-health-selector", NodeHealthCmd.Flags().Lookup(FLAG_SELECTOR)), "BindPFlag:")
+	NodeHealthCmd.Flags().StringP("stale-after", "", "15m", locales.AttributeDescription("node-health-stale-after"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-health-stale-after", NodeHealthCmd.Flags().Lookup("stale-after")), "BindPFlag:")
+	NodeHealthCmd.Flags().StringP("offline-after", "", "24h", locales.AttributeDescription("node-health-offline-after"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-health-offline-after", NodeHealthCmd.Flags().Lookup("offline-after")), "BindPFlag:")
+	NodeHealthCmd.Flags().IntP("max-offline", "", -1, locales.AttributeDescription("node-health-max-offline"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-health-max-offline", NodeHealthCmd.Flags().Lookup("max-offline")), "BindPFlag:")
+}
+
+// nodeState classifies a node. Nodes reporting not to be online are offline, otherwise the last update decides.
+func nodeState(nodeMap map[string]interface{}, now time.Time, staleAfter, offlineAfter time.Duration) string {
+	if facts, ok := nodeMap["facts"].(map[string]interface{}); ok {
+		if online, ok := facts["online"].(bool); ok && !online {
+			return NodeOffline
+		}
+	}
+
+	updatedAt, err := time.Parse(time.RFC3339Nano, fmt.Sprint(nodeMap["updated_at"]))
+	if err != nil {
+		return NodeOffline
+	}
+	switch age := now.Sub(updatedAt); {
+	case age > offlineAfter:
+		return NodeOffline
+	case age > staleAfter:
+		return NodeStale
+	}
+	return NodeOnline
+}
+
+// nodeHealthReport classifies the nodes and counts the states per organization and project
+func nodeHealthReport(nodes []interface{}, now time.Time, staleAfter, offlineAfter time.Duration) NodeHealthReport {
+	report := NodeHealthReport{Summary: []NodeHealthSummary{}, Nodes: []NodeHealth{}}
+	summaries := map[string]*NodeHealthSummary{}
+	for _, node := range nodes {
+		nodeMap, ok := node.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		health := NodeHealth{
+			NodeId:       fmt.Sprint(nodeMap["agent_id"]),
+			DisplayName:  stringValue(nodeMap["display_name"], ""),
+			Organization: stringValue(nodeMap["organization"], ""),
+			Project:      stringValue(nodeMap["project"], ""),
+			UpdatedAt:    stringValue(nodeMap["updated_at"], ""),
+			State:        nodeState(nodeMap, now, staleAfter, offlineAfter),
+		}
+		report.Nodes = append(report.Nodes, health)
+
+		key := health.Organization + "/" + health.Project
+		summary, ok := summaries[key]
+		if !ok {
+			summary = &NodeHealthSummary{Organization: health.Organization, Project: health.Project}
+			summaries[key] = summary
+		}
+		switch health.State {
+		case NodeOnline:
+			summary.Online++
+		case NodeStale:
+			summary.Stale++
+		case NodeOffline:
+			summary.Offline++
+		}
+		summary.Total++
+	}
+
+	for _, summary := range summaries {
+		report.Summary = append(report.Summary, *summary)
+	}
+	sort.Slice(report.Summary, func(i, j int) bool {
+		if report.Summary[i].Organization != report.Summary[j].Organization {
+			return report.Summary[i].Organization < report.Summary[j].Organization
+		}
+		return report.Summary[i].Project < report.Summary[j].Project
+	})
+	return report
+}
+
+// printNodeHealthReport prints the whole report as JSON or the not online nodes and the summary as tables
+func printNodeHealthReport(report NodeHealthReport) error {
+	if viper.GetBool("json") {
+		printer := print.Print{Data: report}
+		bodyPrint, err := printer.JSON()
+		if err != nil {
+			return err
+		}
+		fmt.Println(bodyPrint)
+		return nil
+	}
+
+	unhealthy := []interface{}{}
+	for _, node := range report.Nodes {
+		if node.State != NodeOnline {
+			unhealthy = append(unhealthy, map[string]interface{}{"agent_id": node.NodeId, "display_name": node.DisplayName, "organization": node.Organization, "project": node.Project, "updated_at": node.UpdatedAt, "state": node.State})
+		}
+	}
+	if len(unhealthy) > 0 {
+		printer := print.Print{Data: unhealthy}
+		tablePrint, err := printer.TableList([]string{"agent_id", "display_name", "organization", "project", "updated_at", "state"})
+		if err != nil {
+			return err
+		}
+		fmt.Println(tablePrint)
+	}
+
+	rows := []interface{}{}
+	for _, summary := range report.Summary {
+		rows = append(rows, map[string]interface{}{"organization": summary.Organization, "project": summary.Project, "online": summary.Online, "stale": summary.Stale, "offline": summary.Offline, "total": summary.Total})
+	}
+	printer := print.Print{Data: rows}
+	tablePrint, err := printer.TableList([]string{"organization", "project", "online", "stale", "offline", "total"})
+	if err != nil {
+		return err
+	}
+	fmt.Println(tablePrint)
+	return nil
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sapcc/lyra-cli/locales"
+)
+
+func TestNodeHealthReport(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	nodes := []interface{}{
+		map[string]interface{}{"agent_id": "node1", "organization": "o1", "project": "p1", "updated_at": "2024-05-01T11:55:00Z", "facts": map[string]interface{}{"online": true}},
+		map[string]interface{}{"agent_id": "node2", "organization": "o1", "project": "p1", "updated_at": "2024-05-01T11:00:00Z"},
+		map[string]interface{}{"agent_id": "node3", "organization": "o1", "project": "p1", "updated_at": "2024-05-01T11:59:00Z", "facts": map[string]interface{}{"online": false}},
+		map[string]interface{}{"agent_id": "node4", "organization": "o1", "project": "p2", "updated_at": "2024-04-01T11:59:00Z"},
+		map[string]interface{}{"agent_id": "node5", "organization": "o0", "project": "p9"},
+	}
+
+	report := nodeHealthReport(nodes, now, 15*time.Minute, 24*time.Hour)
+	states := []string{}
+	for _, node := range report.Nodes {
+		states = append(states, node.State)
+	}
+	if want := []string{NodeOnline, NodeStale, NodeOffline, NodeOffline, NodeOffline}; !reflect.DeepEqual(states, want) {
+		t.Errorf("Expected states %q, got %q", want, states)
+	}
+	want := []NodeHealthSummary{
+		{Organization: "o0", Project: "p9", Offline: 1, Total: 1},
+		{Organization: "o1", Project: "p1", Online: 1, Stale: 1, Offline: 1, Total: 3},
+		{Organization: "o1", Project: "p2", Offline: 1, Total: 1},
+	}
+	if !reflect.DeepEqual(report.Summary, want) {
+		t.Errorf("Expected summary %#v, got %#v", want, report.Summary)
+	}
+}
+
+func nodeHealthTester(t *testing.T, flags string) resulter {
+	recent := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339Nano)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("facts") != "online" {
+			t.Errorf("Expected the online fact to be requested, got %q", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `[{"agent_id":"node1","organization":"o1","project":"p1","updated_at":"%s","facts":{"online":true}},{"agent_id":"node2","display_name":"db","organization":"o1","project":"p1","updated_at":"2016-12-09T14:40:56.1885Z","facts":{"online":false}}]`, recent)
+	}))
+	defer server.Close()
+
+	ResetFlags()
+	return FullCmdTester(RootCmd, fmt.Sprintf("lyra node health --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s %s", server.URL, server.URL, "token123", flags))
+}
+
+func TestNodeHealthCmd(t *testing.T) {
+	resulter := nodeHealthTester(t, "--max-offline=1")
+	if resulter.Error != nil {
+		t.Errorf("Command expected to not get an error: %s", resulter.Error)
+	}
+	for _, want := range []string{"| node2    | db", "| o1           | p1      | 1      | 0     | 1       | 2     |"} {
+		if !strings.Contains(resulter.Output, want) {
+			t.Errorf("Command response body doesn't match. \n \n %s", StringDiff(resulter.Output, want))
+		}
+	}
+	if strings.Contains(resulter.Output, "| node1") {
+		t.Errorf("Expected only unhealthy nodes to be listed, got %s", resulter.Output)
+	}
+}
+
+func TestNodeHealthCmdMaxOffline(t *testing.T) {
+	resulter := nodeHealthTester(t, "--max-offline=0 --json")
+	var exitErr *ExitError
+	if !errors.As(resulter.Error, &exitErr) || exitErr.Code != 2 {
+		t.Errorf("Command expected to exit with code 2, got %#v", resulter.Error)
+	}
+	errorMsg := fmt.Sprintf(locales.ErrorMessages("node-health-offline"), 1, 0)
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+
+	report := NodeHealthReport{}
+	if err := json.Unmarshal([]byte(resulter.Output), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Nodes) != 2 || report.Summary[0].Offline != 1 {
+		t.Errorf("Unexpected report %#v", report)
+	}
+}
+
+func TestNodeHealthCmdInvalidThresholds(t *testing.T) {
+	resulter := nodeHealthTester(t, "--stale-after=2d --offline-after=1d")
+	errorMsg := locales.ErrorMessages("node-health-thresholds-invalid")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+}
@@ -15,34 +15,92 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
 
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/print"
+	"github.com/sapcc/lyra-cli/restclient"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+// InstallScript is the install script of a node and the data given to the templates. The json format is decoded
+// into Pki.
+type InstallScript struct {
+	NodeId string
+	Index  int
+	Format string
+	Script string
+	Pki    *PkiResult
+}
+
 var NodeInstallCmd = &cobra.Command{
 	Use:   "install",
 	Short: locales.CmdShortDescription("arc-node-install"),
+	Long:  locales.CmdLongDescription("arc-node-install"),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		err := checkArcInstallParams()
 		if err != nil {
 			return err
 		}
 
-		script, err := generateScript()
+		scripts, err := installScripts()
+		if err != nil {
+			return err
+		}
+
+		// check the templates before creating the scripts
+		tmpl, err := installTemplate(viper.GetString("arc-install-template"))
+		if err != nil {
+			return err
+		}
+		files, err := installOutputFiles(scripts, viper.GetString("arc-install-output-file"))
 		if err != nil {
 			return err
 		}
 
+		scripts, err = generateScripts(scripts)
+		if restclient.IsDryRun(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if viper.GetString("arc-install-format") == "json" {
+			// the script is printed when neither a template nor output files are given
+			scripts, err = decodePkiResults(scripts, tmpl == nil && len(files) == 0)
+			if err != nil {
+				return err
+			}
+		}
+
+		if tmpl != nil {
+			for i := range scripts {
+				scripts[i].Script, err = renderInstallTemplate(tmpl, scripts[i])
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(files) > 0 {
+			return writeInstallScripts(cmd, scripts, files)
+		}
+
 		// print response
-		fmt.Println(script)
+		fmt.Println(scripts[0].Script)
 
 		return nil
 	},
@@ -58,6 +116,20 @@ func initNodeInstallCmdFlags() {
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-node-id", NodeInstallCmd.Flags().Lookup(FLAG_ARC_NODE_ID)), "BindPFlag:")
 	NodeInstallCmd.Flags().StringP(FLAG_ARC_INSTALL_FORMAT, "", "json", locales.AttributeDescription(FLAG_ARC_INSTALL_FORMAT))
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-install-format", NodeInstallCmd.Flags().Lookup(FLAG_ARC_INSTALL_FORMAT)), "BindPFlag:")
+	NodeInstallCmd.Flags().StringP("output-file", "o", "", locales.AttributeDescription("install-output-file"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-install-output-file", NodeInstallCmd.Flags().Lookup("output-file")), "BindPFlag:")
+	NodeInstallCmd.Flags().IntP("count", "", 0, locales.AttributeDescription("install-count"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-install-count", NodeInstallCmd.Flags().Lookup("count")), "BindPFlag:")
+	NodeInstallCmd.Flags().StringP("node-ids-from-file", "", "", locales.AttributeDescription("node-ids-from-file"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-install-node-ids-file", NodeInstallCmd.Flags().Lookup("node-ids-from-file")), "BindPFlag:")
+	NodeInstallCmd.Flags().StringP("template", "", "", locales.AttributeDescription("install-template"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-install-template", NodeInstallCmd.Flags().Lookup("template")), "BindPFlag:")
+	NodeInstallCmd.Flags().BoolP("export", "", false, locales.AttributeDescription("install-export"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-install-export", NodeInstallCmd.Flags().Lookup("export")), "BindPFlag:")
+	NodeInstallCmd.Flags().BoolP("yaml", "", false, locales.AttributeDescription("install-yaml"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-install-yaml", NodeInstallCmd.Flags().Lookup("yaml")), "BindPFlag:")
+	NodeInstallCmd.Flags().BoolP("table", "", false, locales.AttributeDescription("install-table"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-install-table", NodeInstallCmd.Flags().Lookup("table")), "BindPFlag:")
 }
 
 func checkArcInstallParams() error {
@@ -69,12 +141,159 @@ func checkArcInstallParams() error {
 	default:
 		return fmt.Errorf("invalid %#v given. Valid: windows,linux,cloud-config,json", "arc-install-format")
 	}
+	if (viper.GetBool("arc-install-export") || viper.GetBool("arc-install-yaml") || viper.GetBool("arc-install-table")) && viper.GetString("arc-install-format") != "json" {
+		return errors.New(locales.ErrorMessages("install-json-format-only"))
+	}
+
+	// the nodes are given by one option only
+	given := 0
+	for _, key := range []string{"arc-node-id", "arc-install-node-ids-file"} {
+		if len(viper.GetString(key)) > 0 {
+			given++
+		}
+	}
+	if viper.GetInt("arc-install-count") < 0 {
+		return fmt.Errorf(locales.ErrorMessages("install-count-invalid"), viper.GetInt("arc-install-count"))
+	}
+	if viper.GetInt("arc-install-count") > 0 {
+		given++
+	}
+	if given > 1 {
+		return errors.New(locales.ErrorMessages("install-nodes-conflict"))
+	}
 
 	return nil
 }
 
-func generateScript() (string, error) {
-	requestBody, err := json.Marshal(&map[string]string{"CN": viper.GetString("arc-node-id")})
+// installScripts returns one script per node given by --count, --node-ids-from-file or --node-id. Scripts created
+// with --count have no node id.
+func installScripts() ([]InstallScript, error) {
+	ids := []string{viper.GetString("arc-node-id")}
+	if count := viper.GetInt("arc-install-count"); count > 0 {
+		ids = make([]string, count)
+	}
+	if file := viper.GetString("arc-install-node-ids-file"); len(file) > 0 {
+		var err error
+		ids, err = readNodeIdsFile(file)
+		if err != nil {
+			return nil, err
+		}
+		ids = uniqueIds(ids)
+		if len(ids) == 0 {
+			return nil, errors.New(locales.ErrorMessages("node-id-missing"))
+		}
+	}
+	if len(ids) > 1 && len(viper.GetString("arc-install-output-file")) == 0 {
+		return nil, errors.New(locales.ErrorMessages("install-output-file-missing"))
+	}
+
+	scripts := []InstallScript{}
+	for i, id := range ids {
+		scripts = append(scripts, InstallScript{NodeId: id, Index: i + 1, Format: viper.GetString("arc-install-format")})
+	}
+	return scripts, nil
+}
+
+// generateScripts gets the script of every node
+func generateScripts(scripts []InstallScript) ([]InstallScript, error) {
+	for i := range scripts {
+		script, err := generateScript(scripts[i].NodeId)
+		if err != nil {
+			return nil, err
+		}
+		scripts[i].Script = script
+	}
+	return scripts, nil
+}
+
+// installTemplateFuncs are the functions available in the install and output file templates
+var installTemplateFuncs = template.FuncMap{
+	"indent": func(spaces int, text string) string {
+		pad := strings.Repeat(" ", spaces)
+		return pad + strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", "\n"+pad)
+	},
+	"base64": func(text string) string {
+		return base64.StdEncoding.EncodeToString([]byte(text))
+	},
+	"json": func(value interface{}) (string, error) {
+		bin, err := json.Marshal(value)
+		return string(bin), err
+	},
+}
+
+// renderInstallTemplate executes the template with the script as data
+func renderInstallTemplate(tmpl *template.Template, script InstallScript) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, script); err != nil {
+		return "", fmt.Errorf(locales.ErrorMessages("install-template-invalid"), err)
+	}
+	return buf.String(), nil
+}
+
+// installTemplate returns the parsed template of the file or nil when no file is given
+func installTemplate(file string) (*template.Template, error) {
+	if len(file) == 0 {
+		return nil, nil
+	}
+	content, err := helpers.ReadFromFileWithLineBreaks(file)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New("template").Funcs(installTemplateFuncs).Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf(locales.ErrorMessages("install-template-invalid"), err)
+	}
+	return tmpl, nil
+}
+
+// installOutputFiles returns the file name of every script rendered from the output file template. The names have
+// to be unique.
+func installOutputFiles(scripts []InstallScript, outputFile string) ([]string, error) {
+	if len(outputFile) == 0 {
+		return nil, nil
+	}
+	tmpl, err := template.New("output-file").Funcs(installTemplateFuncs).Parse(outputFile)
+	if err != nil {
+		return nil, fmt.Errorf(locales.ErrorMessages("install-template-invalid"), err)
+	}
+	names := []string{}
+	seen := map[string]bool{}
+	for _, script := range scripts {
+		name, err := renderInstallTemplate(tmpl, script)
+		if err != nil {
+			return nil, err
+		}
+		if seen[name] {
+			return nil, fmt.Errorf(locales.ErrorMessages("install-output-file-duplicate"), name)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// writeInstallScripts writes every script to its file. The scripts contain a registration token and are only
+// readable by the owner. Linux scripts are made executable.
+func writeInstallScripts(cmd *cobra.Command, scripts []InstallScript, files []string) error {
+	perm := os.FileMode(0600)
+	if scripts[0].Format == "linux" {
+		perm = 0700
+	}
+	for i, script := range scripts {
+		if err := os.WriteFile(files[i], []byte(script.Script), perm); err != nil {
+			return err
+		}
+		// existing files keep their mode when written
+		if err := os.Chmod(files[i], perm); err != nil {
+			return err
+		}
+		cmd.Printf("Install script written to %s.\n", files[i])
+	}
+	return nil
+}
+
+func generateScript(nodeId string) (string, error) {
+	requestBody, err := json.Marshal(&map[string]string{"CN": nodeId})
 	if err != nil {
 		return "", errors.New("failed to marshel request body")
 	}
@@ -104,3 +323,50 @@ type PkiResult struct {
 	Token string `json:"token"`
 	Url   string `json:"url"`
 }
+
+// decodePkiResults decodes the json install format. The response is kept as it is unless the export lines, YAML or
+// a table are requested. YAML and table show all fields of the response.
+func decodePkiResults(scripts []InstallScript, printed bool) ([]InstallScript, error) {
+	for i := range scripts {
+		result := PkiResult{}
+		if err := json.Unmarshal([]byte(scripts[i].Script), &result); err != nil {
+			return nil, fmt.Errorf(locales.ErrorMessages("install-json-invalid"), err)
+		}
+		scripts[i].Pki = &result
+
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(scripts[i].Script), &fields); err != nil {
+			return nil, fmt.Errorf(locales.ErrorMessages("install-json-invalid"), err)
+		}
+		printer := print.Print{Data: fields}
+		var err error
+		switch {
+		case viper.GetBool("arc-install-export"):
+			scripts[i].Script = fmt.Sprintf("ARC_TOKEN=%s\nARC_URL=%s\n", shellQuote(result.Token), shellQuote(result.Url))
+		case viper.GetBool("arc-install-yaml"):
+			scripts[i].Script, err = printer.YAML()
+		case viper.GetBool("arc-install-table"):
+			scripts[i].Script, err = printer.Table()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// printing adds the line break
+		if printed {
+			scripts[i].Script = strings.TrimSuffix(scripts[i].Script, "\n")
+		}
+	}
+	return scripts, nil
+}
+
+var shellSafeRegexp = regexp.MustCompile(`^[a-zA-Z0-9_./:@%+=,-]*$`)
+
+// shellQuote returns the value single quoted unless it only contains characters safe in a shell
+func shellQuote(value string) string {
+	if len(value) > 0 && shellSafeRegexp.MatchString(value) {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -1,13 +1,17 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	auth "github.com/sapcc/go-openstack-auth"
+	"github.com/sapcc/lyra-cli/locales"
 )
 
 func TestNodeInstallCmdWithNoEnvEndpointAndTokenSet(t *testing.T) {
@@ -173,8 +177,232 @@ func nodeInstallServer() *httptest.Server {
 			fmt.Fprintln(w, `cloud config script`)
 		} else {
 			w.WriteHeader(200) // keep the code after setting headers. If not they will disapear...
-			fmt.Fprintln(w, `json script`)
+			fmt.Fprintln(w, `{"token":"json script","url":"https://arc.example.com/api/v1/pki/sign/abc","expires_at":"2024-05-01T12:00:00Z"}`)
 		}
 	}))
 	return server
 }
+
+// nodeInstallCNServer returns a shell script containing the requested node id
+func nodeInstallCNServer(t *testing.T, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		*requests++
+		w.Header().Set("Content-Type", "text/x-shellscript")
+		fmt.Fprintf(w, "#!/bin/sh\necho install %s\n", body["CN"])
+	}))
+}
+
+func TestNodeInstallCmdOutputFile(t *testing.T) {
+	requests := 0
+	server := nodeInstallCNServer(t, &requests)
+	defer server.Close()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "install.sh")
+	// existing files readable by others are restricted
+	if err := os.WriteFile(file, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra node install --node-id node1 --install-format linux --output-file %s --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", file, server.URL, server.URL))
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "#!/bin/sh\necho install node1\n" {
+		t.Errorf("Unexpected script %q", content)
+	}
+	if info, _ := os.Stat(file); info.Mode().Perm() != 0700 {
+		t.Errorf("Expected the linux script to be executable by the owner only, got %s", info.Mode())
+	}
+	if len(resulter.Output) > 0 {
+		t.Errorf("Expected nothing printed, got %q", resulter.Output)
+	}
+
+	ResetFlags()
+	file = filepath.Join(dir, "install.ps1")
+	resulter = FullCmdTester(RootCmd, fmt.Sprintf("lyra node install --install-format windows --output-file %s --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", file, server.URL, server.URL))
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	if info, _ := os.Stat(file); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the windows script to be readable by the owner only, got %s", info.Mode())
+	}
+}
+
+func TestNodeInstallCmdMultipleNodes(t *testing.T) {
+	requests := 0
+	server := nodeInstallCNServer(t, &requests)
+	defer server.Close()
+	dir := t.TempDir()
+	idsFile := writeTestFile(t, "nodes.txt", "node1\n# comment\nnode2\nnode1\n")
+	template := writeTestFile(t, "cloud-init.tmpl", "#cloud-config\nwrite_files:\n  - path: /tmp/arc-{{.NodeId}}.sh\n    permissions: '0755'\n    content: |\n{{indent 6 .Script}}\n")
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra node install --install-format linux --node-ids-from-file %s --template %s --output-file %s --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", idsFile, template, filepath.Join(dir, "{{.NodeId}}.yaml"), server.URL, server.URL))
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	if requests != 2 {
+		t.Errorf("Expected one request per node, got %d", requests)
+	}
+	for _, id := range []string{"node1", "node2"} {
+		content, err := os.ReadFile(filepath.Join(dir, id+".yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := fmt.Sprintf("#cloud-config\nwrite_files:\n  - path: /tmp/arc-%s.sh\n    permissions: '0755'\n    content: |\n      #!/bin/sh\n      echo install %s\n", id, id)
+		if string(content) != want {
+			t.Errorf("Command response body doesn't match. \n \n %s", StringDiff(string(content), want))
+		}
+	}
+
+	ResetFlags()
+	resulter = FullCmdTester(RootCmd, fmt.Sprintf("lyra node install --install-format linux --count 3 --output-file %s --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", filepath.Join(dir, "node-{{.Index}}.sh"), server.URL, server.URL))
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	for i := 1; i <= 3; i++ {
+		if _, err := os.Stat(filepath.Join(dir, fmt.Sprintf("node-%d.sh", i))); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestNodeInstallCmdMultipleNodesErrors(t *testing.T) {
+	requests := 0
+	server := nodeInstallCNServer(t, &requests)
+	defer server.Close()
+	dir := t.TempDir()
+
+	tests := map[string]string{
+		"--count 2":                 locales.ErrorMessages("install-output-file-missing"),
+		"--count -1":                fmt.Sprintf(locales.ErrorMessages("install-count-invalid"), -1),
+		"--count 2 --node-id node1": locales.ErrorMessages("install-nodes-conflict"),
+		"--count 2 --output-file " + dir + "/x.sh":  fmt.Sprintf(locales.ErrorMessages("install-output-file-duplicate"), dir+"/x.sh"),
+		"--output-file " + dir + "/{{.Missing}}.sh": "Invalid template: ",
+		"--template " + writeTestFile(t, "t", "{{"): "Invalid template: ",
+	}
+	for options, want := range tests {
+		ResetFlags()
+		resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra node install --install-format linux %s --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", options, server.URL, server.URL))
+		if resulter.Error == nil || !strings.HasPrefix(resulter.Error.Error(), want) {
+			t.Errorf("Expected error %q for %q, got %v", want, options, resulter.Error)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("Expected no script to be requested, got %d requests", requests)
+	}
+}
+
+func TestNodeInstallCmdJSONFormat(t *testing.T) {
+	server := nodeInstallServer()
+	defer server.Close()
+
+	// the response is printed as it is by default, all fields are kept
+	raw := `{
+  "token": "json script",
+  "url": "https://arc.example.com/api/v1/pki/sign/abc",
+  "expires_at": "2024-05-01T12:00:00Z"
+}
+
+`
+	tests := map[string]string{
+		"":       raw,
+		"--json": raw,
+		"--table": `+------------+---------------------------------------------+
+|    KEY     |                    VALUE                    |
++------------+---------------------------------------------+
+| expires_at | 2024-05-01T12:00:00Z                        |
+| token      | json script                                 |
+| url        | https://arc.example.com/api/v1/pki/sign/abc |
++------------+---------------------------------------------+
+`,
+		"--yaml": `expires_at: "2024-05-01T12:00:00Z"
+token: json script
+url: https://arc.example.com/api/v1/pki/sign/abc
+`,
+		"--export": `ARC_TOKEN='json script'
+ARC_URL=https://arc.example.com/api/v1/pki/sign/abc
+`,
+	}
+	for options, want := range tests {
+		ResetFlags()
+		resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra node install %s --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", options, server.URL, server.URL))
+		if resulter.Error != nil {
+			t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+		}
+		if resulter.Output != want {
+			t.Errorf("Command response body for %q doesn't match. \n \n %s", options, StringDiff(resulter.Output, want))
+		}
+	}
+}
+
+func TestNodeInstallCmdJSONFormatTemplate(t *testing.T) {
+	server := nodeInstallServer()
+	defer server.Close()
+	file := filepath.Join(t.TempDir(), "arc.env")
+	template := writeTestFile(t, "terraform.tmpl", `arc_token = {{json .Pki.Token}}`+"\n")
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra node install --export --output-file %s --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", file, server.URL, server.URL))
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "ARC_TOKEN='json script'\nARC_URL=https://arc.example.com/api/v1/pki/sign/abc\n" {
+		t.Errorf("Unexpected export file %q", content)
+	}
+
+	ResetFlags()
+	resulter = FullCmdTester(RootCmd, fmt.Sprintf("lyra node install --template %s --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", template, server.URL, server.URL))
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	if resulter.Output != "arc_token = \"json script\"\n\n" {
+		t.Errorf("Unexpected template output %q", resulter.Output)
+	}
+}
+
+func TestNodeInstallCmdJSONFormatErrors(t *testing.T) {
+	requests := 0
+	server := nodeInstallCNServer(t, &requests)
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra node install --install-format linux --export --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", server.URL, server.URL))
+	if resulter.Error == nil || resulter.Error.Error() != locales.ErrorMessages("install-json-format-only") {
+		t.Errorf("Expected json format error, got %v", resulter.Error)
+	}
+
+	// the server returns a shell script
+	ResetFlags()
+	resulter = FullCmdTester(RootCmd, fmt.Sprintf("lyra node install --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", server.URL, server.URL))
+	if resulter.Error == nil || !strings.HasPrefix(resulter.Error.Error(), "Invalid install response: ") {
+		t.Errorf("Expected invalid response error, got %v", resulter.Error)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"abc-123":                  "abc-123",
+		"https://arc.example.com/": "https://arc.example.com/",
+		"":                         "''",
+		"it's $HOME":               `'it'\''s $HOME'`,
+	}
+	for value, want := range tests {
+		if got := shellQuote(value); got != want {
+			t.Errorf("Expected %q to be quoted as %s, got %s", value, want, got)
+		}
+	}
+}
@@ -17,10 +17,12 @@ package cmd
 import (
 	"fmt"
 	"net/url"
+	"strings"
 
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
 	"github.com/sapcc/lyra-cli/print"
+	"github.com/sapcc/lyra-cli/selector"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -30,7 +32,13 @@ var NodeListCmd = &cobra.Command{
 	Short: locales.CmdShortDescription("arc-node-list"),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// list automation
-		response, err := nodeList()
+		var response interface{}
+		var err error
+		if viper.GetBool("node-list-local") {
+			response, err = nodeListLocal(viper.GetString("node-selector"))
+		} else {
+			response, err = nodeList()
+		}
 		if err != nil {
 			return err
 		}
@@ -64,19 +72,76 @@ func initNodeListCmdFlags() {
 	//flags
 	NodeListCmd.Flags().StringP(FLAG_SELECTOR, "", "", locales.AttributeDescription("node-selector"))
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("node-selector", NodeListCmd.Flags().Lookup(FLAG_SELECTOR)), "BindPFlag:")
+	NodeListCmd.Flags().BoolP("local", "", false, locales.AttributeDescription("node-list-local"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("node-list-local", NodeListCmd.Flags().Lookup("local")), "BindPFlag:")
+	NodeListCmd.Flags().StringP("max-age", "", "10m", locales.AttributeDescription("node-snapshot-max-age"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("node-list-max-age", NodeListCmd.Flags().Lookup("max-age")), "BindPFlag:")
 }
 
 func nodeList() (interface{}, error) {
-	// collect all automations do the pagination
+	response, err := nodeListBySelector(viper.GetString("node-selector"))
+	if err != nil {
+		return "", err
+	}
+
+	return response, nil
+}
+
+// nodeListLocal returns the nodes of the cached snapshot matching the selector
+func nodeListLocal(expression string) ([]interface{}, error) {
+	var node selector.Node
+	if expression != "" {
+		var err error
+		node, err = selector.Parse(expression)
+		if err != nil {
+			return nil, selectorError(err)
+		}
+	}
+	maxAge, err := helpers.ParseDuration(viper.GetString("node-list-max-age"))
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := nodeSnapshots(maxAge)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []interface{}{}
+	for _, snapshot := range snapshots {
+		if node == nil || selector.Evaluate(node, snapshot.Target()) {
+			nodes = append(nodes, snapshot.Node)
+		}
+	}
+	return nodes, nil
+}
+
+// nodeListBySelector returns all nodes matching the selector. An empty selector returns all nodes.
+// The given facts are included in the nodes.
+func nodeListBySelector(selector string, facts ...string) ([]interface{}, error) {
+	// collect all nodes do the pagination
 	arcService := RestClient.Services["arc"]
 	urlValues := url.Values{}
-	if viper.GetString("node-selector") != "" {
-		urlValues.Set("q", viper.GetString("node-selector"))
+	if len(facts) > 0 {
+		urlValues.Set("facts", strings.Join(facts, ","))
+	}
+	if selector != "" {
+		if err := checkSelector(selector); err != nil {
+			return nil, err
+		}
+		urlValues.Set("q", selector)
 	}
 	response, _, err := arcService.GetList("agents", urlValues)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	return response, nil
 }
+
+// stringValue returns the value as string or the given replacement for missing values
+func stringValue(value interface{}, missing string) string {
+	if value == nil {
+		return missing
+	}
+	return fmt.Sprint(value)
+}
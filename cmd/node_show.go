@@ -15,61 +15,38 @@
 package cmd
 
 import (
-	"errors"
-	"fmt"
 	"net/url"
 	"path"
 
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
-	"github.com/sapcc/lyra-cli/print"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var NodeShowCmd = &cobra.Command{
-	Use:   "show",
-	Short: locales.CmdShortDescription("arc-node-show"),
-	PreRunE: func(cmd *cobra.Command, args []string) error {
-		// check required node id
-		if len(viper.GetString("arc-show-node-id")) == 0 {
-			return errors.New(locales.ErrorMessages("node-id-missing"))
-		}
-		return nil
-	},
+	Use:               "show [NODE_ID]...",
+	Short:             locales.CmdShortDescription("arc-node-show"),
+	ValidArgsFunction: nodeIdCompletion,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// list automation
-		response, err := nodeShow(viper.GetString("arc-show-node-id"))
-		if err != nil {
-			return err
-		}
-
-		// convert data to struct
-		var dataStruct map[string]interface{}
-		err = helpers.JSONStringToStructure(response, &dataStruct)
+		// resolve the node ids from the name
+		ids, err := resolveNodeIds("arc-show-node-id", "arc-show-node-name", args)
 		if err != nil {
 			return err
 		}
 
-		// print the data out
-		printer := print.Print{Data: dataStruct}
-		var bodyPrint string
-		if viper.GetBool("json") {
-			bodyPrint, err = printer.JSON()
-			if err != nil {
-				return err
-			}
-		} else {
-			bodyPrint, err = printer.Table()
+		// show nodes
+		responses := []string{}
+		for _, id := range ids {
+			response, err := nodeShow(id)
 			if err != nil {
 				return err
 			}
+			responses = append(responses, response)
 		}
 
-		// print response
-		fmt.Println(bodyPrint)
-
-		return nil
+		// print the data out
+		return printShowResults(responses)
 	},
 }
 
@@ -81,6 +58,8 @@ func init() {
 func initNodeShowCmdFlags() {
 	NodeShowCmd.Flags().StringP(FLAG_ARC_NODE_ID, "", "", locales.AttributeDescription(FLAG_ARC_NODE_ID))
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-show-node-id", NodeShowCmd.Flags().Lookup(FLAG_ARC_NODE_ID)), "BindPFlag:")
+	NodeShowCmd.Flags().StringP(FLAG_ARC_NODE, "", "", locales.AttributeDescription(FLAG_ARC_NODE))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-show-node-name", NodeShowCmd.Flags().Lookup(FLAG_ARC_NODE)), "BindPFlag:")
 }
 
 func nodeShow(id string) (string, error) {
@@ -0,0 +1,131 @@
+// Copyright © 2016 Arturo Reuschenbach Puncernau <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/selector"
+)
+
+// nodeSnapshotConcurrency is the amount of nodes fetched at the same time when building the snapshot
+const nodeSnapshotConcurrency = 10
+
+// NodeSnapshot holds a node with its tags and facts to evaluate selectors locally
+type NodeSnapshot struct {
+	Node  map[string]interface{} `json:"node"`
+	Tags  map[string]string      `json:"tags"`
+	Facts map[string]interface{} `json:"facts"`
+}
+
+// Id returns the id of the node
+func (n NodeSnapshot) Id() string {
+	return fmt.Sprint(n.Node["agent_id"])
+}
+
+// Target returns the tags and facts of the node for the selector evaluation
+func (n NodeSnapshot) Target() selector.Target {
+	return selector.Target{Tags: n.Tags, Facts: n.Facts}
+}
+
+// nodeSnapshots returns all nodes with their tags and facts. The snapshot is cached and fetched again when it is
+// older than maxAge.
+func nodeSnapshots(maxAge time.Duration) ([]NodeSnapshot, error) {
+	cacheName := apiCacheName("nodes", "snapshot")
+	snapshots := []NodeSnapshot{}
+	if maxAge > 0 && helpers.ReadCache(cacheName, maxAge, &snapshots) {
+		return snapshots, nil
+	}
+
+	snapshots, err := fetchNodeSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	if err = helpers.WriteCache(cacheName, snapshots); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// fetchNodeSnapshots lists all nodes and gets their tags and facts
+func fetchNodeSnapshots() ([]NodeSnapshot, error) {
+	arcService := RestClient.Services["arc"]
+	nodes, _, err := arcService.GetList("agents", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	return collectNodeSnapshots(nodes, nodeSnapshotConcurrency)
+}
+
+// collectNodeSnapshots gets the tags and facts of the nodes concurrently
+func collectNodeSnapshots(nodes []interface{}, concurrency int) ([]NodeSnapshot, error) {
+	snapshots := []NodeSnapshot{}
+	for _, node := range nodes {
+		if nodeMap, ok := node.(map[string]interface{}); ok {
+			snapshots = append(snapshots, NodeSnapshot{Node: nodeMap})
+		}
+	}
+	errs := make([]error, len(snapshots))
+	forEachConcurrently(len(snapshots), concurrency, func(i int) {
+		snapshots[i].Tags, errs[i] = nodeTags(snapshots[i].Id())
+		if errs[i] != nil {
+			return
+		}
+		snapshots[i].Facts, errs[i] = nodeFacts(snapshots[i].Id())
+	})
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", snapshots[i].Id(), err)
+		}
+	}
+	return snapshots, nil
+}
+
+// nodeFacts returns the facts of the node
+func nodeFacts(id string) (map[string]interface{}, error) {
+	response, err := nodeFactList(id)
+	if err != nil {
+		return nil, err
+	}
+	facts := map[string]interface{}{}
+	err = helpers.JSONStringToStructure(response, &facts)
+	if err != nil {
+		return nil, err
+	}
+	return facts, nil
+}
+
+// findNodeSnapshot returns the snapshot of the node. A cached snapshot not containing the node is fetched again.
+func findNodeSnapshot(id string, maxAge time.Duration) (*NodeSnapshot, error) {
+	for _, age := range []time.Duration{maxAge, 0} {
+		snapshots, err := nodeSnapshots(age)
+		if err != nil {
+			return nil, err
+		}
+		for i := range snapshots {
+			if snapshots[i].Id() == id {
+				return &snapshots[i], nil
+			}
+		}
+		if age == 0 {
+			break
+		}
+	}
+	return nil, fmt.Errorf(locales.ErrorMessages("node-snapshot-missing"), id)
+}
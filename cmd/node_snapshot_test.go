@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newNodeSnapshotServer serves three nodes with their tags and facts and counts the agent list requests
+func newNodeSnapshotServer(t *testing.T, listRequests *int32) *httptest.Server {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/agents":
+			switch r.URL.Query().Get("q") {
+			case "":
+			case "@os='linux'":
+				fmt.Fprintln(w, `[{"agent_id":"node1","display_name":"web1"},{"agent_id":"node2","display_name":"web2"}]`)
+				return
+			default:
+				t.Errorf("Unexpected selector %q sent to the server", r.URL.Query().Get("q"))
+			}
+			atomic.AddInt32(listRequests, 1)
+			fmt.Fprintln(w, `[{"agent_id":"node1","display_name":"web1"},{"agent_id":"node2","display_name":"web2"},{"agent_id":"node3","display_name":"db1"}]`)
+		case "/api/v1/agents/node1/tags":
+			fmt.Fprintln(w, `{"pool":"green"}`)
+		case "/api/v1/agents/node2/tags":
+			fmt.Fprintln(w, `{"pool":"blue"}`)
+		case "/api/v1/agents/node3/tags":
+			fmt.Fprintln(w, `{}`)
+		case "/api/v1/agents/node1/facts", "/api/v1/agents/node2/facts":
+			fmt.Fprintln(w, `{"os":"linux","online":true,"hostname":"`+strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/agents/"), "/facts")+`.example.com"}`)
+		case "/api/v1/agents/node3/facts":
+			fmt.Fprintln(w, `{"os":"windows","online":false}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestNodeSnapshotsCached(t *testing.T) {
+	var listRequests int32
+	server := newNodeSnapshotServer(t, &listRequests)
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		ResetFlags()
+		resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra node list --local --selector=@online=true --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", server.URL, server.URL))
+		if resulter.Error != nil {
+			t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+		}
+	}
+	if listRequests != 1 {
+		t.Errorf("Expected the snapshot to be fetched once, got %d requests", listRequests)
+	}
+
+	// a max age of zero fetches it again
+	ResetFlags()
+	FullCmdTester(RootCmd, fmt.Sprintf("lyra node list --local --max-age=0 --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", server.URL, server.URL))
+	if listRequests != 2 {
+		t.Errorf("Expected the snapshot to be fetched again, got %d requests", listRequests)
+	}
+}
+
+func TestNodeListCmdLocal(t *testing.T) {
+	var listRequests int32
+	server := newNodeSnapshotServer(t, &listRequests)
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra node list --local --selector=@os='linux'_AND_NOT_pool=blue --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123", server.URL, server.URL))
+	if resulter.Error == nil || !strings.Contains(resulter.Error.Error(), `expected AND or OR but found "_AND_NOT_pool" at position 12`) {
+		t.Errorf("Expected invalid selector error, got %v", resulter.Error)
+	}
+
+	ResetFlags()
+	resulter = FullCmdTester(RootCmd, fmt.Sprintf(`lyra node list --local --selector "@os='linux' AND NOT pool=blue" --json --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123`, server.URL, server.URL))
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	if !strings.Contains(resulter.Output, `"agent_id": "node1"`) || strings.Contains(resulter.Output, "node2") || strings.Contains(resulter.Output, "node3") {
+		t.Errorf("Expected only node1 to match, got %s", resulter.Output)
+	}
+}
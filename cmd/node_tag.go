@@ -15,8 +15,15 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var NodeTagCmd = &cobra.Command{
@@ -27,3 +34,171 @@ var NodeTagCmd = &cobra.Command{
 func init() {
 	NodeCmd.AddCommand(NodeTagCmd)
 }
+
+// initNodeTagBulkFlags adds the flags selecting several nodes to a tag command. The viper keys start with the prefix.
+func initNodeTagBulkFlags(cmd *cobra.Command, prefix string) {
+	cmd.Flags().StringP(FLAG_SELECTOR, "", "", locales.AttributeDescription("node-tag-selector"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag(prefix+"-selector", cmd.Flags().Lookup(FLAG_SELECTOR)), "BindPFlag:")
+	cmd.Flags().StringP("node-ids-from-file", "", "", locales.AttributeDescription("node-ids-from-file"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag(prefix+"-node-ids-file", cmd.Flags().Lookup("node-ids-from-file")), "BindPFlag:")
+	cmd.Flags().IntP("concurrency", "", 5, locales.AttributeDescription("concurrency"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag(prefix+"-concurrency", cmd.Flags().Lookup("concurrency")), "BindPFlag:")
+	cmd.Flags().BoolP("atomic", "", false, locales.AttributeDescription("node-tag-atomic"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag(prefix+"-atomic", cmd.Flags().Lookup("atomic")), "BindPFlag:")
+}
+
+// isNodeTagBulk returns true when the nodes are given by selector or file
+func isNodeTagBulk(prefix string) bool {
+	return len(viper.GetString(prefix+"-selector")) > 0 || len(viper.GetString(prefix+"-node-ids-file")) > 0
+}
+
+// nodeTagBulkIds returns the ids of the nodes given by id, name, selector and file
+func nodeTagBulkIds(prefix string) ([]string, error) {
+	ids := commandIds(prefix+"-node-id", nil)
+	if len(viper.GetString(prefix+"-node-name")) > 0 {
+		id, err := findNodeId(viper.GetString(prefix + "-node-name"))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if selector := viper.GetString(prefix + "-selector"); len(selector) > 0 {
+		nodes, err := nodeListBySelector(selector)
+		if err != nil {
+			return nil, err
+		}
+		if len(nodes) == 0 {
+			return nil, fmt.Errorf(locales.ErrorMessages("selector-no-nodes"), selector)
+		}
+		ids = append(ids, nodeIds(nodes)...)
+	}
+
+	if file := viper.GetString(prefix + "-node-ids-file"); len(file) > 0 {
+		fileIds, err := readNodeIdsFile(file)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, fileIds...)
+	}
+
+	ids = uniqueIds(ids)
+	if len(ids) == 0 {
+		return nil, errors.New(locales.ErrorMessages("node-id-missing"))
+	}
+	return ids, nil
+}
+
+// tagChanges describes the changed keys grouped by their status in the given order, Ex: created: a, b; overwritten: c
+func tagChanges(statuses map[string][]string, order ...string) string {
+	groups := []string{}
+	for _, status := range order {
+		keys := statuses[status]
+		if len(keys) == 0 {
+			continue
+		}
+		sort.Strings(keys)
+		groups = append(groups, fmt.Sprintf("%s: %s", status, strings.Join(keys, ", ")))
+	}
+	return strings.Join(groups, "; ")
+}
+
+// readNodeIdsFile reads one node id per line. Empty lines and lines starting with '#' are ignored.
+func readNodeIdsFile(file string) ([]string, error) {
+	content, err := helpers.ReadFromFileWithLineBreaks(file)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ids = append(ids, line)
+	}
+	return ids, nil
+}
+
+// nodeTags returns the current tags of the node
+func nodeTags(id string) (map[string]string, error) {
+	response, err := nodeTagList(id)
+	if err != nil {
+		return nil, err
+	}
+	tags := map[string]string{}
+	err = helpers.JSONStringToStructure(response, &tags)
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// nodeTagBulk applies the change to all nodes concurrently. The current tags are read first, the change returns
+// the tag changes reported per node. In atomic mode the rollback restores the tags on all nodes when any node failed.
+func nodeTagBulk(prefix string, apply func(id string, previous map[string]string) (string, error), rollback func(id string, previous map[string]string, result NodeResult) error) error {
+	ids, err := nodeTagBulkIds(prefix)
+	if err != nil {
+		return err
+	}
+	concurrency := viper.GetInt(prefix + "-concurrency")
+	atomic := viper.GetBool(prefix+"-atomic") && !viper.GetBool(FLAG_DRY_RUN)
+
+	// keep the tags to report the changes and to be able to roll back
+	previous := make([]map[string]string, len(ids))
+	errs := make([]error, len(ids))
+	forEachConcurrently(len(ids), concurrency, func(i int) {
+		previous[i], errs[i] = nodeTags(ids[i])
+	})
+	if atomic {
+		for i, err := range errs {
+			if err != nil {
+				return fmt.Errorf("%s: %s", ids[i], err)
+			}
+		}
+	}
+
+	results := make([]NodeResult, len(ids))
+	forEachConcurrently(len(ids), concurrency, func(i int) {
+		if errs[i] != nil {
+			results[i] = newNodeResult(ids[i], errs[i])
+			return
+		}
+		changes, err := apply(ids[i], previous[i])
+		results[i] = newNodeResult(ids[i], err)
+		if results[i].Result != NodeResultFailed {
+			results[i].Changes = changes
+		}
+	})
+
+	failed := 0
+	for _, result := range results {
+		if result.Result == NodeResultFailed {
+			failed++
+		}
+	}
+	if atomic && failed > 0 {
+		forEachConcurrently(len(ids), concurrency, func(i int) {
+			err := rollback(ids[i], previous[i], results[i])
+			switch {
+			case err != nil:
+				results[i].Result = NodeResultRollbackFailed
+				results[i].Error = strings.TrimSpace(fmt.Sprint(results[i].Error, " ", err))
+			case results[i].Result == NodeResultSuccess:
+				results[i].Result = NodeResultRolledBack
+			}
+		})
+	}
+
+	_, err = printNodeResults(results)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		if atomic {
+			return fmt.Errorf(locales.ErrorMessages("node-tag-rolled-back"), failed, len(ids))
+		}
+		return fmt.Errorf(locales.ErrorMessages("node-tag-failed"), failed, len(ids))
+	}
+	return nil
+}
@@ -17,13 +17,20 @@ package cmd
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"path"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/print"
+	"github.com/sapcc/lyra-cli/restclient"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -33,29 +40,54 @@ var NodeTagAddCmd = &cobra.Command{
 	Short: locales.CmdShortDescription("arc-node-tag-add"),
 	Long:  locales.CmdLongDescription("arc-node-tag-add"),
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		// check required node id
-		if len(viper.GetString("arc-tag-add-node-id")) == 0 {
-			return errors.New(locales.ErrorMessages("node-id-missing"))
+		// several nodes are resolved when running
+		if isNodeTagBulk("arc-tag-add") {
+			return nil
 		}
-		return nil
+		// resolve the node id from the name if no id given
+		return resolveNodeId("arc-tag-add-node-id", "arc-tag-add-node-name")
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// parse arguments
-		body, err := parseArgs(args)
+		tags, err := parseTags(cmd, args)
 		if err != nil {
 			return err
 		}
+		body, err := json.Marshal(tags)
+		if err != nil {
+			return err
+		}
+
+		if isNodeTagBulk("arc-tag-add") {
+			return nodeTagAddBulk(string(body))
+		}
+
+		// keep the current tags to report the overwritten ones
+		id := viper.GetString("arc-tag-add-node-id")
+		previous := map[string]string{}
+		if len(tags) > 0 {
+			previous, err = nodeTags(id)
+			if err != nil {
+				return err
+			}
+		}
 
 		// post tags
-		_, err = nodeTagAdd(viper.GetString("arc-tag-add-node-id"), body)
+		_, err = nodeTagAdd(id, string(body))
+		if restclient.IsDryRun(err) {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
 
 		// Print response to the sdterr. No response got it
-		cmd.Println("Tags added successfully to the node with id ", viper.GetString("arc-tag-add-node-id"))
+		cmd.Println("Tags added successfully to the node with id ", id)
 
-		return nil
+		if len(tags) == 0 {
+			return nil
+		}
+		return printTagAddReport(tags, previous)
 	},
 }
 
@@ -67,24 +99,208 @@ func init() {
 func initNodeTagAddCmdFlags() {
 	NodeTagAddCmd.Flags().StringP(FLAG_ARC_NODE_ID, "", "", locales.AttributeDescription(FLAG_ARC_NODE_ID))
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-tag-add-node-id", NodeTagAddCmd.Flags().Lookup(FLAG_ARC_NODE_ID)), "BindPFlag:")
+	NodeTagAddCmd.Flags().StringP(FLAG_ARC_NODE, "", "", locales.AttributeDescription(FLAG_ARC_NODE))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-tag-add-node-name", NodeTagAddCmd.Flags().Lookup(FLAG_ARC_NODE)), "BindPFlag:")
+	NodeTagAddCmd.Flags().StringP("from-file", "", "", locales.AttributeDescription("node-tag-from-file"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-tag-add-from-file", NodeTagAddCmd.Flags().Lookup("from-file")), "BindPFlag:")
+	NodeTagAddCmd.Flags().BoolP("strict", "", true, locales.AttributeDescription("node-tag-strict"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-tag-add-strict", NodeTagAddCmd.Flags().Lookup("strict")), "BindPFlag:")
+	initNodeTagBulkFlags(NodeTagAddCmd, "arc-tag-add")
 }
 
-func parseArgs(args []string) (string, error) {
-	keyValuePairs := map[string]string{}
+// nodeTagAddBulk adds the tags to several nodes. The rollback restores overwritten tags and removes the added ones.
+func nodeTagAddBulk(body string) error {
+	tags := map[string]string{}
+	err := helpers.JSONStringToStructure(body, &tags)
+	if err != nil {
+		return err
+	}
+
+	apply := func(id string, previous map[string]string) (string, error) {
+		statuses := map[string][]string{}
+		for key, value := range tags {
+			status := tagStatus(key, value, previous)
+			statuses[status] = append(statuses[status], key)
+		}
+		_, err := nodeTagAdd(id, body)
+		return tagChanges(statuses, TagCreated, TagOverwritten, TagUnchanged), err
+	}
+	rollback := func(id string, previous map[string]string, result NodeResult) error {
+		if result.Result != NodeResultSuccess {
+			return nil
+		}
+		restore := map[string]string{}
+		for key := range tags {
+			if value, ok := previous[key]; ok {
+				restore[key] = value
+			} else if _, err := nodeTagdelete(id, key); err != nil {
+				return err
+			}
+		}
+		if len(restore) == 0 {
+			return nil
+		}
+		restoreBody, err := json.Marshal(restore)
+		if err != nil {
+			return err
+		}
+		_, err = nodeTagAdd(id, string(restoreBody))
+		return err
+	}
+	return nodeTagBulk("arc-tag-add", apply, rollback)
+}
+
+const (
+	TagCreated     = "created"
+	TagOverwritten = "overwritten"
+	TagUnchanged   = "unchanged"
+
+	tagKeyMaxLength   = 255
+	tagValueMaxLength = 255
+)
+
+// tagKeyRegexp matches the tag keys accepted by the arc API
+var tagKeyRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// parseTags returns the tags from the tags file and the arguments, the arguments take precedence. Invalid tags fail
+// listing all problems. Without strict mode they are reported and skipped.
+func parseTags(cmd *cobra.Command, args []string) (map[string]string, error) {
+	tags := map[string]string{}
+	problems := []string{}
+	add := func(source, key, value string) {
+		if err := validateTag(key, value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", source, err))
+			return
+		}
+		tags[key] = value
+	}
+
+	if file := viper.GetString("arc-tag-add-from-file"); len(file) > 0 {
+		data, err := helpers.ReadFromFileWithLineBreaks(file)
+		if err != nil {
+			return nil, err
+		}
+		// YAML includes JSON
+		fileTags := map[string]interface{}{}
+		err = helpers.YAMLStringToStructure(data, &fileTags)
+		if err != nil {
+			return nil, err
+		}
+		keys := []string{}
+		for key := range fileTags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, err := tagValue(fileTags[key])
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", key, err))
+				continue
+			}
+			add(key, key, value)
+		}
+	}
+
 	for _, element := range args {
 		data := regexp.MustCompile("=|:").Split(element, 2)
 		if len(data) != 2 {
+			problems = append(problems, fmt.Sprintf(`%s: missing "=" or ":" between key and value`, element))
 			continue
 		}
-		keyValuePairs[data[0]] = data[1]
+		add(element, data[0], data[1])
 	}
 
-	jsonString, err := json.Marshal(keyValuePairs)
-	if err != nil {
-		return "", err
+	if len(problems) == 0 {
+		return tags, nil
+	}
+	if !viper.GetBool("arc-tag-add-strict") {
+		for _, problem := range problems {
+			cmd.Println("Skipping invalid tag", problem)
+		}
+		return tags, nil
+	}
+	return nil, invalidTagsError(problems)
+}
+
+// invalidTagsError lists the problems of the invalid tags
+func invalidTagsError(problems []string) error {
+	lines := []string{locales.ErrorMessages("node-tag-invalid")}
+	for _, problem := range problems {
+		lines = append(lines, fmt.Sprint("  ", problem))
 	}
+	return errors.New(strings.Join(lines, "\n"))
+}
 
-	return string(jsonString), nil
+// tagValue converts a tag value read from a YAML or JSON file to a string. Numbers are written without exponent.
+func tagValue(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case bool, int, int64, uint64:
+		return fmt.Sprint(value), nil
+	}
+	return "", errors.New("value has to be a string or number")
+}
+
+// validateTag checks the tag against the charset and length allowed by the arc API
+func validateTag(key, value string) error {
+	switch {
+	case len(key) == 0:
+		return errors.New("key is empty")
+	case len(key) > tagKeyMaxLength:
+		return fmt.Errorf("key is longer than %d characters", tagKeyMaxLength)
+	case !tagKeyRegexp.MatchString(key):
+		return errors.New("key may only contain letters, digits, '_' and '-'")
+	case len(value) == 0:
+		return errors.New("value is empty")
+	case len(value) > tagValueMaxLength:
+		return fmt.Errorf("value is longer than %d characters", tagValueMaxLength)
+	case strings.IndexFunc(value, unicode.IsControl) >= 0:
+		return errors.New("value contains control characters")
+	}
+	return nil
+}
+
+// tagStatus returns whether adding the tag creates, overwrites or doesn't change it
+func tagStatus(key, value string, previous map[string]string) string {
+	old, ok := previous[key]
+	switch {
+	case ok && old == value:
+		return TagUnchanged
+	case ok:
+		return TagOverwritten
+	}
+	return TagCreated
+}
+
+// printTagAddReport prints which tags were created, overwritten or left unchanged
+func printTagAddReport(tags, previous map[string]string) error {
+	keys := []string{}
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rows := []interface{}{}
+	for _, key := range keys {
+		rows = append(rows, map[string]interface{}{"key": key, "value": tags[key], "previous": previous[key], "status": tagStatus(key, tags[key], previous)})
+	}
+
+	var bodyPrint string
+	var err error
+	printer := print.Print{Data: rows}
+	if viper.GetBool("json") {
+		bodyPrint, err = printer.JSON()
+	} else {
+		bodyPrint, err = printer.TableList([]string{"key", "value", "previous", "status"})
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(bodyPrint)
+	return nil
 }
 
 func nodeTagAdd(id, body string) (string, error) {
@@ -1,14 +1,17 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 
 	auth "github.com/sapcc/go-openstack-auth"
+	"github.com/sapcc/lyra-cli/locales"
 )
 
 func TestNodeTagAddCmdWithNoEnvEndpointAndTokenSet(t *testing.T) {
@@ -37,6 +40,11 @@ func TestNodeTagAddCmdWithUserAuthenticationFlags(t *testing.T) {
 func TestNodeTagAddCmdSuccessRequestBodyCreation(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
+		// the current tags are read first to report overwritten tags
+		if r.Method == "GET" {
+			fmt.Fprintln(w, `{}`)
+			return
+		}
 		data, _ := io.ReadAll(r.Body)
 		defer r.Body.Close()
 
@@ -77,6 +85,11 @@ func TestNodeTagAddCmdRightParams(t *testing.T) {
 		w.Header().Set("Content-Type", "application/json")
 		method := r.Method
 		path := r.URL
+		// the current tags are read first to report overwritten tags
+		if method == "GET" {
+			fmt.Fprintln(w, `{}`)
+			return
+		}
 		if !strings.Contains(method, "POST") {
 			diffString := StringDiff(method, "POST")
 			t.Errorf("Command API method doesn't match. \n \n %s", diffString)
@@ -92,3 +105,155 @@ func TestNodeTagAddCmdRightParams(t *testing.T) {
 	// run commando
 	FullCmdTester(RootCmd, fmt.Sprintf("lyra node tag add --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --node-id=%s test:test", "https://somewhere.com", server.URL, "token123", "123456789"))
 }
+
+func TestNodeTagAddCmdSelector(t *testing.T) {
+	server := newNodeTagServer(t, "")
+	defer server.Close()
+
+	resulter := nodeTagBulkTester(t, server, "", "lyra node tag add --selector=@os='linux' --concurrency=2 pool=blue")
+	if resulter.Error != nil {
+		t.Errorf("Command expected to not get an error: %s", resulter.Error)
+	}
+	for id, tags := range server.tags {
+		if tags["pool"] != "blue" {
+			t.Errorf("Expected node %s to have the tag pool=blue, got %v", id, tags)
+		}
+	}
+	for _, line := range []string{"| node1   | ok     | overwritten: pool |", "| node2   | ok     | created: pool     |"} {
+		if !strings.Contains(resulter.Output, line) {
+			t.Errorf("Expected per node results, got %s", resulter.Output)
+		}
+	}
+}
+
+func TestNodeTagAddCmdFromFileAtomic(t *testing.T) {
+	server := newNodeTagServer(t, "node3")
+	defer server.Close()
+
+	resulter := nodeTagBulkTester(t, server, "# web nodes\nnode1\nnode2\n\nnode3\n", "lyra node tag add --node-ids-from-file=- --atomic --json pool=blue")
+	errorMsg := fmt.Sprintf(locales.ErrorMessages("node-tag-rolled-back"), 1, 3)
+	if resulter.Error == nil || resulter.Error.Error() != errorMsg {
+		t.Errorf("Expected error %q, got %v", errorMsg, resulter.Error)
+	}
+
+	// the overwritten tag is restored and the added one removed
+	want := map[string]map[string]string{
+		"node1": {"pool": "green", "name": "web1"},
+		"node2": {"name": "web2"},
+		"node3": {"pool": "red", "name": "db1"},
+	}
+	if !reflect.DeepEqual(server.tags, want) {
+		t.Errorf("Expected tags %v, got %v", want, server.tags)
+	}
+
+	results := []NodeResult{}
+	if err := json.Unmarshal([]byte(resulter.Output), &results); err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, result := range results {
+		got = append(got, result.NodeId+"="+result.Result)
+	}
+	if want := []string{"node1=rolled-back", "node2=rolled-back", "node3=failed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected results %q, got %q", want, got)
+	}
+}
+
+func TestNodeTagAddCmdInvalidTags(t *testing.T) {
+	server := newNodeTagServer(t, "")
+	defer server.Close()
+
+	resulter := nodeTagBulkTester(t, server, "", "lyra node tag add --node-id=node2 poolgreen pool=green bad.key=x empty=")
+	errorMsg := strings.Join([]string{
+		locales.ErrorMessages("node-tag-invalid"),
+		`  poolgreen: missing "=" or ":" between key and value`,
+		"  bad.key=x: key may only contain letters, digits, '_' and '-'",
+		"  empty=: value is empty",
+	}, "\n")
+	if resulter.Error == nil || resulter.Error.Error() != errorMsg {
+		t.Errorf("Expected error %q, got %v", errorMsg, resulter.Error)
+	}
+	if _, ok := server.tags["node2"]["pool"]; ok {
+		t.Errorf("Expected no tags to be added, got %v", server.tags["node2"])
+	}
+}
+
+func TestNodeTagAddCmdNotStrict(t *testing.T) {
+	server := newNodeTagServer(t, "")
+	defer server.Close()
+
+	resulter := nodeTagBulkTester(t, server, "", "lyra node tag add --node-id=node2 --strict=false poolgreen pool=green")
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	if server.tags["node2"]["pool"] != "green" {
+		t.Errorf("Expected the valid tag to be added, got %v", server.tags["node2"])
+	}
+	if !strings.Contains(resulter.ErrorOutput, `Skipping invalid tag poolgreen: missing "=" or ":" between key and value`) {
+		t.Errorf("Expected the invalid tag to be reported, got %s", resulter.ErrorOutput)
+	}
+}
+
+func TestNodeTagAddCmdFromFileReport(t *testing.T) {
+	server := newNodeTagServer(t, "")
+	defer server.Close()
+
+	file := writeTestFile(t, "tags.yaml", "pool: blue\nname: web1\nport: 8080\n")
+	resulter := nodeTagBulkTester(t, server, "", fmt.Sprintf("lyra node tag add --node-id=node1 --from-file=%s --json port=9090", file))
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	want := map[string]string{"pool": "blue", "name": "web1", "port": "9090"}
+	if !reflect.DeepEqual(server.tags["node1"], want) {
+		t.Errorf("Expected tags %v, got %v", want, server.tags["node1"])
+	}
+
+	report := []map[string]string{}
+	if err := json.Unmarshal([]byte(resulter.Output), &report); err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, entry := range report {
+		got = append(got, entry["key"]+"="+entry["status"])
+	}
+	if want := []string{"name=unchanged", "pool=overwritten", "port=created"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected report %q, got %q", want, got)
+	}
+}
+
+func TestNodeTagAddCmdFromFileInvalid(t *testing.T) {
+	server := newNodeTagServer(t, "")
+	defer server.Close()
+
+	resulter := nodeTagBulkTester(t, server, `{"pool": {"nested": true}}`, "lyra node tag add --node-id=node1 --from-file=-")
+	errorMsg := locales.ErrorMessages("node-tag-invalid") + "\n  pool: value has to be a string or number"
+	if resulter.Error == nil || resulter.Error.Error() != errorMsg {
+		t.Errorf("Expected error %q, got %v", errorMsg, resulter.Error)
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	long := strings.Repeat("a", tagKeyMaxLength+1)
+	for _, tag := range [][2]string{{"", "v"}, {long, "v"}, {"k", long}, {"k y", "v"}, {"k", "a\tb"}} {
+		if validateTag(tag[0], tag[1]) == nil {
+			t.Errorf("Expected tag %q to be invalid", tag)
+		}
+	}
+	for _, tag := range [][2]string{{"pool", "green"}, {"Pool_1-a", "test new"}, {"k", "https://x.org:8080"}} {
+		if err := validateTag(tag[0], tag[1]); err != nil {
+			t.Errorf("Expected tag %q to be valid, got %s", tag, err)
+		}
+	}
+}
+
+func TestTagValue(t *testing.T) {
+	tests := map[interface{}]string{"green": "green", 8080.0: "8080", 1000000.0: "1000000", 0.25: "0.25", true: "true"}
+	for value, want := range tests {
+		if got, err := tagValue(value); err != nil || got != want {
+			t.Errorf("Expected %v to be converted to %q, got %q (%v)", value, want, got, err)
+		}
+	}
+	if _, err := tagValue(map[string]interface{}{"nested": true}); err == nil {
+		t.Error("Expected nested values to be invalid")
+	}
+}
@@ -15,12 +15,13 @@
 package cmd
 
 import (
-	"errors"
+	"encoding/json"
 	"net/url"
 	"path"
 
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/restclient"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -31,16 +32,24 @@ var NodeTagDeleteCmd = &cobra.Command{
 	Short: locales.CmdShortDescription("arc-node-tag-delete"),
 	Long:  locales.CmdLongDescription("arc-node-tag-delete"),
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		// check required node id
-		if len(viper.GetString("arc-tag-delete-node-id")) == 0 {
-			return errors.New(locales.ErrorMessages("node-id-missing"))
+		// several nodes are resolved when running
+		if isNodeTagBulk("arc-tag-delete") {
+			return nil
 		}
-		return nil
+		// resolve the node id from the name if no id given
+		return resolveNodeId("arc-tag-delete-node-id", "arc-tag-delete-node-name")
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if isNodeTagBulk("arc-tag-delete") {
+			return nodeTagDeleteBulk(args)
+		}
+
 		// loop over the tag keys to delete
 		for _, element := range args {
 			_, err := nodeTagdelete(viper.GetString("arc-tag-delete-node-id"), element)
+			if restclient.IsDryRun(err) {
+				continue
+			}
 			if err != nil {
 				return err
 			}
@@ -61,6 +70,56 @@ func init() {
 func initNodeTagDeleteCmdFlags() {
 	NodeTagDeleteCmd.Flags().StringP(FLAG_ARC_NODE_ID, "", "", locales.AttributeDescription(FLAG_ARC_NODE_ID))
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-tag-delete-node-id", NodeTagDeleteCmd.Flags().Lookup(FLAG_ARC_NODE_ID)), "BindPFlag:")
+	NodeTagDeleteCmd.Flags().StringP(FLAG_ARC_NODE, "", "", locales.AttributeDescription(FLAG_ARC_NODE))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-tag-delete-node-name", NodeTagDeleteCmd.Flags().Lookup(FLAG_ARC_NODE)), "BindPFlag:")
+	initNodeTagBulkFlags(NodeTagDeleteCmd, "arc-tag-delete")
+}
+
+const (
+	TagDeleted = "deleted"
+	TagAbsent  = "absent"
+)
+
+// nodeTagDeleteBulk deletes the tag keys from several nodes. Keys the node doesn't have are skipped. The rollback
+// adds the deleted tags again.
+func nodeTagDeleteBulk(keys []string) error {
+	apply := func(id string, previous map[string]string) (string, error) {
+		statuses := map[string][]string{}
+		var dryRun error
+		for _, key := range keys {
+			if _, ok := previous[key]; !ok {
+				statuses[TagAbsent] = append(statuses[TagAbsent], key)
+				continue
+			}
+			_, err := nodeTagdelete(id, key)
+			if restclient.IsDryRun(err) {
+				dryRun = err
+			} else if err != nil {
+				return "", err
+			}
+			statuses[TagDeleted] = append(statuses[TagDeleted], key)
+		}
+		return tagChanges(statuses, TagDeleted, TagAbsent), dryRun
+	}
+	rollback := func(id string, previous map[string]string, result NodeResult) error {
+		// failed nodes may have lost some of the tags as well
+		restore := map[string]string{}
+		for _, key := range keys {
+			if value, ok := previous[key]; ok {
+				restore[key] = value
+			}
+		}
+		if len(restore) == 0 {
+			return nil
+		}
+		body, err := json.Marshal(restore)
+		if err != nil {
+			return err
+		}
+		_, err = nodeTagAdd(id, string(body))
+		return err
+	}
+	return nodeTagBulk("arc-tag-delete", apply, rollback)
 }
 
 func nodeTagdelete(id, tagKey string) (string, error) {
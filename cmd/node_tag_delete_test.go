@@ -1,13 +1,16 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 
 	auth "github.com/sapcc/go-openstack-auth"
+	"github.com/sapcc/lyra-cli/locales"
 )
 
 func TestNodeTagDeleteCmdWithNoEnvEndpointAndTokenSet(t *testing.T) {
@@ -53,3 +56,69 @@ func TestNodeTagDeleteCmdRightParams(t *testing.T) {
 	// run commando
 	FullCmdTester(RootCmd, fmt.Sprintf("lyra node tag delete --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --node-id=%s test123", "https://somewhere.com", server.URL, "token123", "123456789"))
 }
+
+func TestNodeTagDeleteCmdSelector(t *testing.T) {
+	server := newNodeTagServer(t, "node2")
+	defer server.Close()
+
+	resulter := nodeTagBulkTester(t, server, "", "lyra node tag delete --selector=@os='linux' name")
+	errorMsg := fmt.Sprintf(locales.ErrorMessages("node-tag-failed"), 1, 3)
+	if resulter.Error == nil || resulter.Error.Error() != errorMsg {
+		t.Errorf("Expected error %q, got %v", errorMsg, resulter.Error)
+	}
+	for _, id := range []string{"node1", "node3"} {
+		if _, ok := server.tags[id]["name"]; ok {
+			t.Errorf("Expected the tag name to be deleted from %s, got %v", id, server.tags[id])
+		}
+	}
+	if !strings.Contains(resulter.Output, "| node2   | failed |") {
+		t.Errorf("Expected per node results, got %s", resulter.Output)
+	}
+}
+
+func TestNodeTagDeleteCmdAtomic(t *testing.T) {
+	server := newNodeTagServer(t, "node2")
+	defer server.Close()
+
+	resulter := nodeTagBulkTester(t, server, "", "lyra node tag delete --selector=@os='linux' --atomic pool name")
+	errorMsg := fmt.Sprintf(locales.ErrorMessages("node-tag-rolled-back"), 1, 3)
+	if resulter.Error == nil || resulter.Error.Error() != errorMsg {
+		t.Errorf("Expected error %q, got %v", errorMsg, resulter.Error)
+	}
+	want := map[string]map[string]string{
+		"node1": {"pool": "green", "name": "web1"},
+		"node2": {"name": "web2"},
+		"node3": {"pool": "red", "name": "db1"},
+	}
+	if !reflect.DeepEqual(server.tags, want) {
+		t.Errorf("Expected tags %v, got %v", want, server.tags)
+	}
+}
+
+func TestNodeTagDeleteCmdAtomicMissingKey(t *testing.T) {
+	server := newNodeTagServer(t, "")
+	defer server.Close()
+
+	// node2 has no pool tag
+	resulter := nodeTagBulkTester(t, server, "", "lyra node tag delete --selector=@os='linux' --atomic --json pool")
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	for id, tags := range server.tags {
+		if _, ok := tags["pool"]; ok {
+			t.Errorf("Expected the tag pool to be deleted from %s, got %v", id, tags)
+		}
+	}
+
+	results := []NodeResult{}
+	if err := json.Unmarshal([]byte(resulter.Output), &results); err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, result := range results {
+		got = append(got, fmt.Sprintf("%s=%s (%s)", result.NodeId, result.Result, result.Changes))
+	}
+	if want := []string{"node1=ok (deleted: pool)", "node2=ok (absent: pool)", "node3=ok (deleted: pool)"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected results %q, got %q", want, got)
+	}
+}
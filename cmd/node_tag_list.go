@@ -15,61 +15,38 @@
 package cmd
 
 import (
-	"errors"
-	"fmt"
 	"net/url"
 	"path"
 
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
-	"github.com/sapcc/lyra-cli/print"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var NodeTagListCmd = &cobra.Command{
-	Use:   "list",
-	Short: locales.CmdShortDescription("arc-node-tag-list"),
-	PreRunE: func(cmd *cobra.Command, args []string) error {
-		// check required node id
-		if len(viper.GetString("arc-tag-list-node-id")) == 0 {
-			return errors.New(locales.ErrorMessages("node-id-missing"))
-		}
-		return nil
-	},
+	Use:               "list [NODE_ID]...",
+	Short:             locales.CmdShortDescription("arc-node-tag-list"),
+	ValidArgsFunction: nodeIdCompletion,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// list automation
-		response, err := nodeTagList(viper.GetString("arc-tag-list-node-id"))
-		if err != nil {
-			return err
-		}
-
-		// convert data to struct
-		var dataStruct map[string]interface{}
-		err = helpers.JSONStringToStructure(response, &dataStruct)
+		// resolve the node ids from the name
+		ids, err := resolveNodeIds("arc-tag-list-node-id", "arc-tag-list-node-name", args)
 		if err != nil {
 			return err
 		}
 
-		// print the data out
-		printer := print.Print{Data: dataStruct}
-		var bodyPrint string
-		if viper.GetBool("json") {
-			bodyPrint, err = printer.JSON()
-			if err != nil {
-				return err
-			}
-		} else {
-			bodyPrint, err = printer.Table()
+		// list the tags of the nodes
+		responses := []string{}
+		for _, id := range ids {
+			response, err := nodeTagList(id)
 			if err != nil {
 				return err
 			}
+			responses = append(responses, response)
 		}
 
-		// print response
-		fmt.Println(bodyPrint)
-
-		return nil
+		// print the data out
+		return printShowResults(responses)
 	},
 }
 
@@ -81,6 +58,8 @@ func init() {
 func initNodeTagListCmdFlags() {
 	NodeTagListCmd.Flags().StringP(FLAG_ARC_NODE_ID, "", "", locales.AttributeDescription(FLAG_ARC_NODE_ID))
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-tag-list-node-id", NodeTagListCmd.Flags().Lookup(FLAG_ARC_NODE_ID)), "BindPFlag:")
+	NodeTagListCmd.Flags().StringP(FLAG_ARC_NODE, "", "", locales.AttributeDescription(FLAG_ARC_NODE))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-tag-list-node-name", NodeTagListCmd.Flags().Lookup(FLAG_ARC_NODE)), "BindPFlag:")
 }
 
 func nodeTagList(id string) (string, error) {
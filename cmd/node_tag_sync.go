@@ -0,0 +1,365 @@
+// Copyright © 2016 Arturo Reuschenbach Puncernau <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/print"
+	"github.com/sapcc/lyra-cli/restclient"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	TagActionAdd    = "add"
+	TagActionUpdate = "update"
+	TagActionDelete = "delete"
+)
+
+// TagChange describes a planned change of a node tag
+type TagChange struct {
+	NodeId string `json:"node_id"`
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	Key    string `json:"key"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+}
+
+var NodeTagSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: locales.CmdShortDescription("arc-node-tag-sync"),
+	Long:  locales.CmdLongDescription("arc-node-tag-sync"),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(viper.GetString("arc-tag-sync-file")) == 0 {
+			return errors.New(locales.ErrorMessages("node-tag-inventory-missing"))
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file := viper.GetString("arc-tag-sync-file")
+		data, err := helpers.ReadFromFileWithLineBreaks(file)
+		if err != nil {
+			return err
+		}
+		format := viper.GetString("arc-tag-sync-format")
+		if len(format) == 0 {
+			format = "yaml"
+			if strings.EqualFold(filepath.Ext(file), ".csv") {
+				format = "csv"
+			}
+		}
+		inventory, err := decodeTagInventory(data, format, viper.GetString("arc-tag-sync-key-column"))
+		if err != nil {
+			return err
+		}
+
+		// map the inventory rows to the nodes
+		nodes, err := tagInventoryNodes(cmd, inventory, viper.GetString("arc-tag-sync-match-fact"))
+		if err != nil {
+			return err
+		}
+		ids := make([]string, 0, len(nodes))
+		for id := range nodes {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		// get the current tags to compute the plan
+		current := make([]map[string]string, len(ids))
+		errs := make([]error, len(ids))
+		forEachConcurrently(len(ids), viper.GetInt("arc-tag-sync-concurrency"), func(i int) {
+			current[i], errs[i] = nodeTags(ids[i])
+		})
+		plan := []TagChange{}
+		for i, id := range ids {
+			if errs[i] != nil {
+				return fmt.Errorf("%s: %s", id, errs[i])
+			}
+			plan = append(plan, tagSyncPlan(id, nodes[id].name, current[i], nodes[id].tags, viper.GetBool("arc-tag-sync-prune"))...)
+		}
+
+		return nodeTagSync(cmd, plan)
+	},
+}
+
+func init() {
+	NodeTagCmd.AddCommand(NodeTagSyncCmd)
+	initNodeTagSyncCmdFlags()
+}
+
+func initNodeTagSyncCmdFlags() {
+	NodeTagSyncCmd.Flags().StringP("file", "f", "", locales.AttributeDescription("node-tag-inventory"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-tag-sync-file", NodeTagSyncCmd.Flags().Lookup("file")), "BindPFlag:")
+	NodeTagSyncCmd.Flags().StringP("format", "", "", locales.AttributeDescription("node-tag-inventory-format"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-tag-sync-format", NodeTagSyncCmd.Flags().Lookup("format")), "BindPFlag:")
+	NodeTagSyncCmd.Flags().StringP("key-column", "", "", locales.AttributeDescription("node-tag-key-column"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-tag-sync-key-column", NodeTagSyncCmd.Flags().Lookup("key-column")), "BindPFlag:")
+	NodeTagSyncCmd.Flags().StringP("match-fact", "", "hostname", locales.AttributeDescription("node-tag-match-fact"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-tag-sync-match-fact", NodeTagSyncCmd.Flags().Lookup("match-fact")), "BindPFlag:")
+	NodeTagSyncCmd.Flags().BoolP("prune", "", false, locales.AttributeDescription("node-tag-prune"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-tag-sync-prune", NodeTagSyncCmd.Flags().Lookup("prune")), "BindPFlag:")
+	NodeTagSyncCmd.Flags().IntP("concurrency", "", 5, locales.AttributeDescription("concurrency"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-tag-sync-concurrency", NodeTagSyncCmd.Flags().Lookup("concurrency")), "BindPFlag:")
+	NodeTagSyncCmd.Flags().BoolP("yes", "y", false, locales.AttributeDescription("yes"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("arc-tag-sync-yes", NodeTagSyncCmd.Flags().Lookup("yes")), "BindPFlag:")
+}
+
+// tagInventoryRow holds the tags of an inventory row and the name identifying the node
+type tagInventoryRow struct {
+	name string
+	tags map[string]string
+}
+
+// decodeTagInventory returns the tags per node name. CSV files have a header row, the key column names the node and
+// the other columns are tags. Empty cells are not listed. YAML and JSON files map the node names to their tags.
+// Invalid tags fail listing all problems.
+func decodeTagInventory(data, format, keyColumn string) ([]tagInventoryRow, error) {
+	rows := []tagInventoryRow{}
+	problems := []string{}
+	switch format {
+	case "csv":
+		records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return rows, nil
+		}
+		header := records[0]
+		key := 0
+		if len(keyColumn) > 0 {
+			key = -1
+			for i, column := range header {
+				if strings.TrimSpace(column) == keyColumn {
+					key = i
+				}
+			}
+			if key < 0 {
+				return nil, fmt.Errorf(locales.ErrorMessages("node-tag-key-column-missing"), keyColumn)
+			}
+		}
+		for _, record := range records[1:] {
+			row := tagInventoryRow{name: strings.TrimSpace(record[key]), tags: map[string]string{}}
+			for i, value := range record {
+				value = strings.TrimSpace(value)
+				if i == key || len(value) == 0 {
+					continue
+				}
+				row.tags[strings.TrimSpace(header[i])] = value
+			}
+			if len(row.name) > 0 {
+				rows = append(rows, row)
+			}
+		}
+	case "yaml", "json":
+		inventory := map[string]map[string]interface{}{}
+		err := helpers.YAMLStringToStructure(data, &inventory)
+		if err != nil {
+			return nil, err
+		}
+		for name, tags := range inventory {
+			row := tagInventoryRow{name: name, tags: map[string]string{}}
+			for key, value := range tags {
+				if value == nil {
+					continue
+				}
+				tag, err := tagValue(value)
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("%s: %s: %s", name, key, err))
+					continue
+				}
+				row.tags[key] = tag
+			}
+			rows = append(rows, row)
+		}
+		sort.Slice(rows, func(i, j int) bool { return rows[i].name < rows[j].name })
+	default:
+		return nil, fmt.Errorf(locales.ErrorMessages("node-tag-inventory-format"), format)
+	}
+
+	// check the tags like node tag add
+	for _, row := range rows {
+		for key, value := range row.tags {
+			if err := validateTag(key, value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s=%s: %s", row.name, key, value, err))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, invalidTagsError(problems)
+	}
+	return rows, nil
+}
+
+// tagInventoryNodes returns the inventory rows by node id. Rows are matched by node id, display name or the given
+// fact. Rows without a matching node are reported and skipped.
+func tagInventoryNodes(cmd *cobra.Command, inventory []tagInventoryRow, fact string) (map[string]tagInventoryRow, error) {
+	arcService := RestClient.Services["arc"]
+	nodes, _, err := arcService.GetList("agents", url.Values{"facts": []string{fact}})
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]tagInventoryRow{}
+	for _, row := range inventory {
+		ids := []string{}
+		for _, node := range nodes {
+			nodeMap, ok := node.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			id := fmt.Sprint(nodeMap["agent_id"])
+			facts, _ := nodeMap["facts"].(map[string]interface{})
+			if id == row.name || nodeMap["display_name"] == row.name || (facts != nil && facts[fact] != nil && fmt.Sprint(facts[fact]) == row.name) {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+		switch {
+		case len(ids) == 0:
+			cmd.Printf("No node found for %s.\n", row.name)
+			continue
+		case len(ids) > 1:
+			return nil, fmt.Errorf(locales.ErrorMessages("node-name-ambiguous"), row.name, strings.Join(ids, ", "))
+		}
+		if previous, ok := result[ids[0]]; ok {
+			return nil, fmt.Errorf(locales.ErrorMessages("node-tag-inventory-duplicate"), previous.name, row.name, ids[0])
+		}
+		result[ids[0]] = row
+	}
+	return result, nil
+}
+
+// tagSyncPlan returns the changes needed to get from the current to the wanted tags. Tags not wanted are only
+// deleted when pruning.
+func tagSyncPlan(id, name string, current, wanted map[string]string, prune bool) []TagChange {
+	plan := []TagChange{}
+	for key, value := range wanted {
+		old, ok := current[key]
+		switch {
+		case !ok:
+			plan = append(plan, TagChange{NodeId: id, Name: name, Action: TagActionAdd, Key: key, New: value})
+		case old != value:
+			plan = append(plan, TagChange{NodeId: id, Name: name, Action: TagActionUpdate, Key: key, Old: old, New: value})
+		}
+	}
+	if prune {
+		for key, old := range current {
+			if _, ok := wanted[key]; !ok {
+				plan = append(plan, TagChange{NodeId: id, Name: name, Action: TagActionDelete, Key: key, Old: old})
+			}
+		}
+	}
+	sort.Slice(plan, func(i, j int) bool { return plan[i].Key < plan[j].Key })
+	return plan
+}
+
+// nodeTagSync shows the plan and applies it after confirmation
+func nodeTagSync(cmd *cobra.Command, plan []TagChange) error {
+	if len(plan) == 0 {
+		cmd.Println("Tags are in sync.")
+		return nil
+	}
+
+	rows := []interface{}{}
+	counts := map[string]int{}
+	ids := []string{}
+	changes := map[string][]TagChange{}
+	for _, change := range plan {
+		rows = append(rows, map[string]interface{}{"node_id": change.NodeId, "name": change.Name, "action": change.Action, "key": change.Key, "old": change.Old, "new": change.New})
+		counts[change.Action]++
+		if _, ok := changes[change.NodeId]; !ok {
+			ids = append(ids, change.NodeId)
+		}
+		changes[change.NodeId] = append(changes[change.NodeId], change)
+	}
+	printer := print.Print{Data: rows}
+	tablePrint, err := printer.TableList([]string{"node_id", "name", "action", "key", "old", "new"})
+	if err != nil {
+		return err
+	}
+	// the plan goes to the stderr to keep the standard output for the results
+	cmd.Println(tablePrint)
+	cmd.Printf("Plan: %d to add, %d to update, %d to delete on %d nodes.\n", counts[TagActionAdd], counts[TagActionUpdate], counts[TagActionDelete], len(ids))
+
+	if !viper.GetBool("arc-tag-sync-yes") && !viper.GetBool(FLAG_DRY_RUN) {
+		confirmed, err := confirm(cmd, "Apply the tag changes?")
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			cmd.Println("Tag sync cancelled.")
+			return nil
+		}
+	}
+
+	results := make([]NodeResult, len(ids))
+	forEachConcurrently(len(ids), viper.GetInt("arc-tag-sync-concurrency"), func(i int) {
+		results[i] = newNodeResult(ids[i], applyTagChanges(ids[i], changes[ids[i]]))
+	})
+	failed, err := printNodeResults(results)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf(locales.ErrorMessages("node-tag-failed"), failed, len(ids))
+	}
+	return nil
+}
+
+// applyTagChanges adds and updates the tags with one request and deletes the rest one by one
+func applyTagChanges(id string, changes []TagChange) error {
+	// with dry run all requests are printed
+	var dryRun error
+	tags := map[string]string{}
+	for _, change := range changes {
+		if change.Action != TagActionDelete {
+			tags[change.Key] = change.New
+		}
+	}
+	if len(tags) > 0 {
+		body, err := json.Marshal(tags)
+		if err != nil {
+			return err
+		}
+		if _, err = nodeTagAdd(id, string(body)); restclient.IsDryRun(err) {
+			dryRun = err
+		} else if err != nil {
+			return err
+		}
+	}
+	for _, change := range changes {
+		if change.Action == TagActionDelete {
+			if _, err := nodeTagdelete(id, change.Key); restclient.IsDryRun(err) {
+				dryRun = err
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+	return dryRun
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sapcc/lyra-cli/locales"
+)
+
+func TestNodeTagSyncCmdWithoutFile(t *testing.T) {
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, "lyra node tag sync --token=token123 --lyra-service-endpoint=http://lyra --arc-service-endpoint=http://arc")
+	if resulter.Error == nil || resulter.Error.Error() != locales.ErrorMessages("node-tag-inventory-missing") {
+		t.Errorf("Expected error %q, got %v", locales.ErrorMessages("node-tag-inventory-missing"), resulter.Error)
+	}
+}
+
+func TestNodeTagSyncCmdCSV(t *testing.T) {
+	server := newNodeTagServer(t, "")
+	defer server.Close()
+
+	inventory := "hostname,pool,owner\nweb1,blue,team-a\nweb1.example.com-missing,red,\ndb1,red,\nnode2,,team-b\n"
+	file := writeTestFile(t, "inventory.csv", inventory)
+	resulter := nodeTagBulkTester(t, server, "", fmt.Sprintf("lyra node tag sync -f %s --yes", file))
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+
+	want := map[string]map[string]string{
+		"node1": {"pool": "blue", "name": "web1", "owner": "team-a"},
+		"node2": {"name": "web2", "owner": "team-b"},
+		"node3": {"pool": "red", "name": "db1"},
+	}
+	if !reflect.DeepEqual(server.tags, want) {
+		t.Errorf("Expected tags %v, got %v", want, server.tags)
+	}
+	for _, line := range []string{
+		"| node1   | web1  | add    | owner | ",
+		"| node1   | web1  | update | pool  | green | blue   |",
+		"| node2   | node2 | add    | owner | ",
+	} {
+		if !strings.Contains(resulter.ErrorOutput, line) {
+			t.Errorf("Expected plan line %q, got %s", line, resulter.ErrorOutput)
+		}
+	}
+	if !strings.Contains(resulter.ErrorOutput, "No node found for web1.example.com-missing.") {
+		t.Errorf("Expected unmatched row to be reported, got %s", resulter.ErrorOutput)
+	}
+	if !strings.Contains(resulter.ErrorOutput, "Plan: 2 to add, 1 to update, 0 to delete on 2 nodes.") {
+		t.Errorf("Expected plan summary, got %s", resulter.ErrorOutput)
+	}
+}
+
+func TestNodeTagSyncCmdYAMLPrune(t *testing.T) {
+	server := newNodeTagServer(t, "")
+	defer server.Close()
+
+	inventory := "web1.example.com:\n  pool: green\ndb1:\n  pool: red\n  name: db1\n"
+	resulter := nodeTagBulkTester(t, server, inventory, "lyra node tag sync -f - --prune -y")
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	want := map[string]map[string]string{
+		"node1": {"pool": "green"},
+		"node2": {"name": "web2"},
+		"node3": {"pool": "red", "name": "db1"},
+	}
+	if !reflect.DeepEqual(server.tags, want) {
+		t.Errorf("Expected tags %v, got %v", want, server.tags)
+	}
+}
+
+func TestNodeTagSyncCmdInSync(t *testing.T) {
+	server := newNodeTagServer(t, "")
+	defer server.Close()
+
+	resulter := nodeTagBulkTester(t, server, "node,pool\nnode3,red\n", "lyra node tag sync -f - --format csv --key-column node")
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	if !strings.Contains(resulter.ErrorOutput, "Tags are in sync.") {
+		t.Errorf("Expected tags to be in sync, got %s", resulter.ErrorOutput)
+	}
+}
+
+func TestNodeTagSyncCmdCancelled(t *testing.T) {
+	server := newNodeTagServer(t, "")
+	defer server.Close()
+
+	file := writeTestFile(t, "inventory.csv", "hostname,pool\nweb2,blue\n")
+	resulter := nodeTagBulkTester(t, server, "n\n", fmt.Sprintf("lyra node tag sync -f %s", file))
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	if _, ok := server.tags["node2"]["pool"]; ok {
+		t.Errorf("Expected no changes, got %v", server.tags["node2"])
+	}
+	if !strings.Contains(resulter.ErrorOutput, "Tag sync cancelled.") {
+		t.Errorf("Expected cancellation, got %s", resulter.ErrorOutput)
+	}
+}
+
+func TestDecodeTagInventory(t *testing.T) {
+	if _, err := decodeTagInventory("hostname,pool\n", "csv", "node"); err == nil || err.Error() != fmt.Sprintf(locales.ErrorMessages("node-tag-key-column-missing"), "node") {
+		t.Errorf("Expected missing column error, got %v", err)
+	}
+	if _, err := decodeTagInventory("", "xml", ""); err == nil {
+		t.Error("Expected unknown format error")
+	}
+	rows, err := decodeTagInventory(`{"web1": {"port": 8080, "pool": "green"}}`, "json", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []tagInventoryRow{{name: "web1", tags: map[string]string{"port": "8080", "pool": "green"}}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Expected %v, got %v", want, rows)
+	}
+
+	// large numbers are not written in exponent notation
+	rows, err = decodeTagInventory("web1:\n  quota: 1000000\n  ratio: 0.5\n", "yaml", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []tagInventoryRow{{name: "web1", tags: map[string]string{"quota": "1000000", "ratio": "0.5"}}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Expected %v, got %v", want, rows)
+	}
+
+	// the tags are checked like with node tag add
+	_, err = decodeTagInventory("hostname,bad key,pool\nweb1,x,green\n", "csv", "")
+	errorMsg := locales.ErrorMessages("node-tag-invalid") + "\n  web1: bad key=x: key may only contain letters, digits, '_' and '-'"
+	if err == nil || err.Error() != errorMsg {
+		t.Errorf("Expected error %q, got %v", errorMsg, err)
+	}
+	_, err = decodeTagInventory("web1:\n  pool:\n    nested: true\n", "yaml", "")
+	errorMsg = locales.ErrorMessages("node-tag-invalid") + "\n  web1: pool: value has to be a string or number"
+	if err == nil || err.Error() != errorMsg {
+		t.Errorf("Expected error %q, got %v", errorMsg, err)
+	}
+}
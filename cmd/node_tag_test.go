@@ -1,7 +1,15 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -16,3 +24,87 @@ func TestNodeTagCmdShouldDoNothing(t *testing.T) {
 		t.Error(`Command response body doesn't match.'`)
 	}
 }
+
+// nodeTagServer keeps the tags of the nodes in memory. Changes of the failing node and deleting missing tags return
+// an error.
+type nodeTagServer struct {
+	*httptest.Server
+	mutex   sync.Mutex
+	tags    map[string]map[string]string
+	failing string
+}
+
+func newNodeTagServer(t *testing.T, failing string) *nodeTagServer {
+	server := &nodeTagServer{
+		failing: failing,
+		tags: map[string]map[string]string{
+			"node1": {"pool": "green", "name": "web1"},
+			"node2": {"name": "web2"},
+			"node3": {"pool": "red", "name": "db1"},
+		},
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+
+		// agents/{id}/tags[/{key}]
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/agents"), "/")
+		if len(parts) == 1 || parts[1] == "" {
+			// the tag sync lists all nodes with the hostname fact
+			if r.URL.Query().Get("facts") == "hostname" {
+				fmt.Fprintln(w, `[{"agent_id":"node1","display_name":"web1.example.com","facts":{"hostname":"web1"}},{"agent_id":"node2","facts":{"hostname":"web2"}},{"agent_id":"node3","facts":{"hostname":"db1"}}]`)
+				return
+			}
+			if r.URL.Query().Get("q") != "@os='linux'" {
+				t.Errorf("Unexpected selector %q", r.URL.Query().Get("q"))
+			}
+			fmt.Fprintln(w, `[{"agent_id":"node1"},{"agent_id":"node2"},{"agent_id":"node3"}]`)
+			return
+		}
+		id := parts[1]
+		tags, ok := server.tags[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method != "GET" && id == server.failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintln(w, `{"error":"boom"}`)
+			return
+		}
+		switch r.Method {
+		case "GET":
+			body, _ := json.Marshal(tags)
+			fmt.Fprintln(w, string(body))
+		case "POST":
+			data, _ := io.ReadAll(r.Body)
+			newTags := map[string]string{}
+			if err := json.Unmarshal(data, &newTags); err != nil {
+				t.Error(err)
+			}
+			for key, value := range newTags {
+				tags[key] = value
+			}
+		case "DELETE":
+			if _, ok := tags[path.Base(r.URL.Path)]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(tags, path.Base(r.URL.Path))
+		}
+	}))
+	return server
+}
+
+func nodeTagBulkTester(t *testing.T, server *nodeTagServer, stdin, command string) resulter {
+	// keep backup of the real stdin
+	oldStdin := os.Stdin
+	defer func() { os.Stdin = oldStdin }()
+	if _, err := pipeToStdin(stdin); err != nil {
+		t.Fatal(err)
+	}
+
+	ResetFlags()
+	return FullCmdTester(RootCmd, fmt.Sprintf("%s --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s", command, server.URL, server.URL, "token123"))
+}
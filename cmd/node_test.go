@@ -1,8 +1,14 @@
 package cmd
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/sapcc/lyra-cli/locales"
 )
 
 func TestNodeCmdShouldDoNothing(t *testing.T) {
@@ -16,3 +22,71 @@ func TestNodeCmdShouldDoNothing(t *testing.T) {
 		t.Error(`Command response body doesn't match.'`)
 	}
 }
+
+func newNodeNameServer(t *testing.T, requests *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, fmt.Sprint(r.Method, " ", r.URL.Path))
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/agents" {
+			if r.URL.Query().Get("facts") != "hostname" {
+				t.Errorf("Expected the hostname fact to be requested, got %q", r.URL.RawQuery)
+			}
+			fmt.Fprintln(w, `[{"agent_id":"node1","display_name":"web","facts":{"hostname":"web-01"}},{"agent_id":"node2","display_name":"db","facts":{"hostname":"db-01"}},{"agent_id":"node3","display_name":"db-01","facts":{"hostname":"db-02"}}]`)
+			return
+		}
+		fmt.Fprintln(w, `{}`)
+	}))
+}
+
+func TestNodeResolveName(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+	}{
+		{"display name", "lyra node show --node web", []string{"GET /api/v1/agents", "GET /api/v1/agents/node1"}},
+		{"hostname", "lyra node fact list --node web-01", []string{"GET /api/v1/agents", "GET /api/v1/agents/node1/facts"}},
+		{"id and name", "lyra node tag list --node-id node7 --node web", []string{"GET /api/v1/agents", "GET /api/v1/agents/node7/tags", "GET /api/v1/agents/node1/tags"}},
+		{"id before name", "lyra node fact list --node-id node7 --node web", []string{"GET /api/v1/agents/node7/facts"}},
+	}
+	for _, test := range tests {
+		requests := []string{}
+		server := newNodeNameServer(t, &requests)
+
+		ResetFlags()
+		resulter := FullCmdTester(RootCmd, fmt.Sprintf("%s --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s", test.command, server.URL, server.URL, "token123"))
+		server.Close()
+		if resulter.Error != nil {
+			t.Errorf("%s: command expected to not get an error: %s", test.name, resulter.Error)
+		}
+		if !reflect.DeepEqual(requests, test.want) {
+			t.Errorf("%s: expected requests %q, got %q", test.name, test.want, requests)
+		}
+	}
+}
+
+func TestNodeResolveNameErrors(t *testing.T) {
+	requests := []string{}
+	server := newNodeNameServer(t, &requests)
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf("lyra node show --node unknown --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s", server.URL, server.URL, "token123"))
+	errorMsg := fmt.Sprintf(locales.ErrorMessages("node-name-not-found"), "unknown")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+
+	// display name of one node and hostname of another
+	ResetFlags()
+	resulter = FullCmdTester(RootCmd, fmt.Sprintf("lyra node delete --node db-01 --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s", server.URL, server.URL, "token123"))
+	errorMsg = fmt.Sprintf(locales.ErrorMessages("node-name-ambiguous"), "db-01", "node2, node3")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+	for _, request := range requests {
+		if strings.HasPrefix(request, "DELETE") {
+			t.Errorf("Expected no node to be deleted, got %s", request)
+		}
+	}
+}
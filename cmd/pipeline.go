@@ -0,0 +1,48 @@
+// Copyright © 2016 Arturo Reuschenbach <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/spf13/cobra"
+)
+
+// Pipeline describes automations executed one after the other
+type Pipeline struct {
+	Selector string          `json:"selector"` // default for all stages
+	Stages   []PipelineStage `json:"stages"`
+}
+
+type PipelineStage struct {
+	Name              string `json:"name"`
+	Automation        string `json:"automation"` // name or id
+	Selector          string `json:"selector"`
+	ContinueOnFailure bool   `json:"continue_on_failure"`
+	Timeout           string `json:"timeout"` // duration Ex: 30m
+}
+
+// pipelineCmd represents the pipeline command
+var PipelineCmd = &cobra.Command{
+	Use:   "pipeline",
+	Short: locales.CmdShortDescription("pipeline"),
+}
+
+func init() {
+	RootCmd.AddCommand(PipelineCmd)
+	initPipelineCmdFlags()
+}
+
+func initPipelineCmdFlags() {
+}
@@ -0,0 +1,314 @@
+// Copyright © 2016 Arturo Reuschenbach <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/print"
+	"github.com/sapcc/lyra-cli/restclient"
+	"github.com/sapcc/lyra-cli/selector"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	StageCompleted = "completed"
+	StageFailed    = "failed"
+	StageTimeout   = "timeout"
+	StageSkipped   = "skipped"
+	StageDryRun    = "dry-run"
+)
+
+// StageResult describes the outcome of a pipeline stage
+type StageResult struct {
+	Stage        int    `json:"stage"`
+	Name         string `json:"name"`
+	AutomationId string `json:"automation_id"`
+	RunId        string `json:"run_id"`
+	State        string `json:"state"`
+	FailedJobs   int    `json:"failed_jobs"`
+	Duration     string `json:"duration"`
+}
+
+var PipelineRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: locales.CmdShortDescription("pipeline-run"),
+	Long:  locales.CmdLongDescription("pipeline-run"),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// DO NOT REMOVE. SHOULD OVERRIDE THE ROOT PersistentPreRunE
+		return nil
+	},
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// check required pipeline file
+		if len(viper.GetString("pipeline-run-file")) == 0 {
+			return errors.New(locales.ErrorMessages("pipeline-file-missing"))
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := helpers.ReadFromFileWithLineBreaks(viper.GetString("pipeline-run-file"))
+		if err != nil {
+			return err
+		}
+
+		// check the pipeline before running anything
+		pipeline, err := decodePipeline(data)
+		if err != nil {
+			return err
+		}
+		err = pipeline.Validate()
+		if err != nil {
+			return err
+		}
+
+		// stages are watched, keep the auth options for reauthentication
+		err = setupExecuteReauthentication(cmd)
+		if err != nil {
+			return err
+		}
+
+		return pipelineRun(cmd, pipeline)
+	},
+}
+
+func init() {
+	PipelineCmd.AddCommand(PipelineRunCmd)
+	initPipelineRunCmdFlags()
+}
+
+func initPipelineRunCmdFlags() {
+	PipelineRunCmd.Flags().StringP("file", "f", "", locales.AttributeDescription("pipeline-file"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("pipeline-run-file", PipelineRunCmd.Flags().Lookup("file")), "BindPFlag:")
+}
+
+// decodePipeline decodes a YAML or JSON pipeline. Unknown attributes are not allowed.
+func decodePipeline(data string) (*Pipeline, error) {
+	var structure interface{}
+	if err := helpers.YAMLStringToStructure(data, &structure); err != nil {
+		return nil, err
+	}
+	numericAutomationIds(structure)
+	jsonString, err := helpers.StructureToJSON(structure)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := Pipeline{}
+	decoder := json.NewDecoder(bytes.NewBufferString(jsonString))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&pipeline); err != nil {
+		return nil, fmt.Errorf("%s %s", locales.ErrorMessages("pipeline-invalid"), err)
+	}
+
+	return &pipeline, nil
+}
+
+// numericAutomationIds converts stage automations given as plain numbers to strings so `automation: 42` works
+func numericAutomationIds(structure interface{}) {
+	pipeline, ok := structure.(map[string]interface{})
+	if !ok {
+		return
+	}
+	stages, ok := pipeline["stages"].([]interface{})
+	if !ok {
+		return
+	}
+	for _, stage := range stages {
+		if stage, ok := stage.(map[string]interface{}); ok {
+			switch automation := stage["automation"].(type) {
+			case int, int64, uint64, float64:
+				stage["automation"] = fmt.Sprint(automation)
+			}
+		}
+	}
+}
+
+// Validate checks the pipeline and returns all problems found
+func (p *Pipeline) Validate() error {
+	errs := ValidationErrors{}
+	if err := selector.Validate(p.Selector); len(p.Selector) > 0 && err != nil {
+		errs.add("selector", err.Error())
+	}
+	if len(p.Stages) == 0 {
+		errs.add("stages", "is required")
+	}
+	for i, stage := range p.Stages {
+		field := fmt.Sprintf("stages[%d]", i)
+		if len(stage.Automation) == 0 {
+			errs.add(fmt.Sprint(field, ".automation"), "is required")
+		}
+		if len(stage.Selector) == 0 && len(p.Selector) == 0 {
+			errs.add(fmt.Sprint(field, ".selector"), "is required when no pipeline selector is given")
+		}
+		if err := selector.Validate(stage.Selector); len(stage.Selector) > 0 && err != nil {
+			errs.add(fmt.Sprint(field, ".selector"), err.Error())
+		}
+		if len(stage.Timeout) > 0 {
+			if timeout, err := time.ParseDuration(stage.Timeout); err != nil || timeout <= 0 {
+				errs.add(fmt.Sprint(field, ".timeout"), fmt.Sprintf("%q is not a valid duration. Ex: 30m", stage.Timeout))
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.New(errs.describe(locales.ErrorMessages("pipeline-invalid")))
+}
+
+const (
+	// PipelineExitHalted is the exit code when a failed stage halted the pipeline
+	PipelineExitHalted = 3
+	// PipelineExitDegraded is the exit code when stages failed but the pipeline continued
+	PipelineExitDegraded = 4
+)
+
+// pipelineRun executes the stages one after the other. A failed stage halts the pipeline unless it may
+// continue on failure. Failed stages let the command exit with PipelineExitHalted or PipelineExitDegraded.
+func pipelineRun(cmd *cobra.Command, pipeline *Pipeline) error {
+	// resolve all automations before starting
+	automationIds := []string{}
+	for _, stage := range pipeline.Stages {
+		id, err := findAutomationId(stage.Automation)
+		if err != nil {
+			return err
+		}
+		automationIds = append(automationIds, id)
+	}
+
+	results := []StageResult{}
+	var failed *StageResult
+	continued := []string{}
+	for i, stage := range pipeline.Stages {
+		result := StageResult{Stage: i + 1, Name: stage.Name, AutomationId: automationIds[i]}
+		if len(result.Name) == 0 {
+			result.Name = stage.Automation
+		}
+		if failed != nil {
+			result.State = StageSkipped
+			results = append(results, result)
+			continue
+		}
+
+		selector := stage.Selector
+		if len(selector) == 0 {
+			selector = pipeline.Selector
+		}
+		timeout, _ := time.ParseDuration(stage.Timeout)
+		cmd.Printf("Stage %d of %d %s\n", result.Stage, len(pipeline.Stages), result.Name)
+
+		started := time.Now()
+		err := pipelineRunStage(cmd, &result, selector, timeout)
+		result.Duration = time.Since(started).Round(time.Second).String()
+		results = append(results, result)
+		if err != nil {
+			// unexpected errors stop everything
+			if printErr := printPipelineSummary(results); printErr != nil {
+				return printErr
+			}
+			return err
+		}
+
+		if result.State == StageFailed || result.State == StageTimeout {
+			if !stage.ContinueOnFailure {
+				failed = &results[len(results)-1]
+				continue
+			}
+			continued = append(continued, fmt.Sprintf("%d %s", result.Stage, result.Name))
+		}
+	}
+
+	err := printPipelineSummary(results)
+	if err != nil {
+		return err
+	}
+	if failed != nil {
+		return &ExitError{Code: PipelineExitHalted, Err: fmt.Errorf(locales.ErrorMessages("pipeline-failed"), failed.Stage, failed.Name)}
+	}
+	if len(continued) > 0 {
+		return &ExitError{Code: PipelineExitDegraded, Err: fmt.Errorf(locales.ErrorMessages("pipeline-degraded"), strings.Join(continued, ", "))}
+	}
+	return nil
+}
+
+// pipelineRunStage runs the automation of the stage and waits for it. Failed runs and timeouts are reported
+// in the result, any other problem as error.
+func pipelineRunStage(cmd *cobra.Command, result *StageResult, selector string, timeout time.Duration) error {
+	runData, err := automationRunRetry(result.AutomationId, selector)
+	if restclient.IsDryRun(err) {
+		result.State = StageDryRun
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	runStr, failedJobs, err := waitAutomationRun(cmd, runData, timeout)
+	result.FailedJobs = failedJobs
+	runState := AutomationRun{}
+	if jsonErr := helpers.JSONStringToStructure(runStr, &runState); jsonErr == nil {
+		result.RunId = runState.Id
+	}
+
+	switch {
+	case err == nil:
+		result.State = StageCompleted
+	case errors.Is(err, ErrRunTimeout):
+		result.State = StageTimeout
+	case errors.Is(err, ErrRunFailed):
+		result.State = StageFailed
+	default:
+		return err
+	}
+	return nil
+}
+
+func printPipelineSummary(results []StageResult) error {
+	var bodyPrint string
+	var err error
+	if viper.GetBool("json") {
+		printer := print.Print{Data: results}
+		bodyPrint, err = printer.JSON()
+	} else {
+		rows := []interface{}{}
+		for _, result := range results {
+			rows = append(rows, map[string]interface{}{
+				"stage":         result.Stage,
+				"name":          result.Name,
+				"automation_id": result.AutomationId,
+				"run_id":        result.RunId,
+				"state":         result.State,
+				"failed_jobs":   result.FailedJobs,
+				"duration":      result.Duration,
+			})
+		}
+		printer := print.Print{Data: rows}
+		bodyPrint, err = printer.TableList([]string{"stage", "name", "automation_id", "run_id", "state", "failed_jobs", "duration"})
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(bodyPrint)
+	return nil
+}
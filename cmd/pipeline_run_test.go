@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	auth "github.com/sapcc/go-openstack-auth"
+	"github.com/sapcc/lyra-cli/locales"
+)
+
+type pipelineServer struct {
+	*httptest.Server
+	mutex sync.Mutex
+	runs  []Run
+}
+
+// newPipelineServer fails the runs of the given automation ids
+func newPipelineServer(t *testing.T, failedAutomations ...string) *pipelineServer {
+	server := &pipelineServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/automations", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `[{"id":1,"name":"chef-base"},{"id":2,"name":"deploy-app"},{"id":3,"name":"smoke-test"},{"id":4,"name":"smoke-test"}]`)
+	})
+	mux.HandleFunc("/api/v1/runs", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		run := Run{}
+		if err := json.Unmarshal(data, &run); err != nil {
+			t.Error(err.Error())
+		}
+		server.mutex.Lock()
+		server.runs = append(server.runs, run)
+		server.mutex.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"run%s","state":"preparing","jobs":null}`, run.AutomationId)
+	})
+	mux.HandleFunc("/api/v1/runs/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/api/v1/runs/")
+		state := RunCompleted
+		for _, failed := range failedAutomations {
+			if id == fmt.Sprint("run", failed) {
+				state = RunFailed
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"%s","state":"%s","jobs":["job1"]}`, id, state)
+	})
+	server.Server = httptest.NewServer(mux)
+	return server
+}
+
+func pipelineRunTester(t *testing.T, server *pipelineServer, pipeline string, flags string) resulter {
+	file := writeTestFile(t, "pipeline.yaml", pipeline)
+
+	// mock interface for authenticationt test to return mocked endopoints and tokens and test method can use user authentication params to run
+	auth.AuthenticationV3 = newMockAuthenticationV3(server.Server)
+
+	ResetFlags()
+	return FullCmdTester(RootCmd, fmt.Sprintf("lyra pipeline run --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=%s --auth-url=%s --user-id=%s --project-id=%s --password=%s -f %s %s", server.URL, server.URL, "token123", "some_test_url", "miau", "bup", "123456789", file, flags))
+}
+
+const testPipeline = `selector: "pool='green'"
+stages:
+  - name: base
+    automation: chef-base
+    timeout: 30m
+  - automation: deploy-app
+    continue_on_failure: %t
+  - name: smoke
+    automation: 3
+    selector: "@identity='node1'"
+`
+
+func TestPipelineRun(t *testing.T) {
+	server := newPipelineServer(t)
+	defer server.Close()
+
+	resulter := pipelineRunTester(t, server, fmt.Sprintf(testPipeline, false), "")
+	if resulter.Error != nil {
+		t.Errorf(`Command expected to not get an error: %s`, resulter.Error)
+	}
+	want := []Run{{AutomationId: "1", Selector: "pool='green'"}, {AutomationId: "2", Selector: "pool='green'"}, {AutomationId: "3", Selector: "@identity='node1'"}}
+	if !reflect.DeepEqual(server.runs, want) {
+		t.Errorf("Expected runs %#v, Got %#v", want, server.runs)
+	}
+	for _, name := range []string{"base", "deploy-app", "smoke"} {
+		if !strings.Contains(resulter.Output, name) {
+			t.Errorf("Command summary doesn't match. \n \n %s", StringDiff(resulter.Output, name))
+		}
+	}
+}
+
+func TestPipelineRunStageFailed(t *testing.T) {
+	server := newPipelineServer(t, "2")
+	defer server.Close()
+
+	resulter := pipelineRunTester(t, server, fmt.Sprintf(testPipeline, false), "--json")
+	var exitErr *ExitError
+	if !errors.As(resulter.Error, &exitErr) || exitErr.Code != PipelineExitHalted {
+		t.Errorf("Command expected to exit with code %d, got %#v", PipelineExitHalted, resulter.Error)
+	}
+	errorMsg := fmt.Sprintf(locales.ErrorMessages("pipeline-failed"), 2, "deploy-app")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+
+	results := []StageResult{}
+	if err := json.Unmarshal([]byte(resulter.Output), &results); err != nil {
+		t.Fatal(err)
+	}
+	states := []string{}
+	for _, result := range results {
+		states = append(states, result.State)
+	}
+	if want := []string{StageCompleted, StageFailed, StageSkipped}; !reflect.DeepEqual(states, want) {
+		t.Errorf("Expected states %#v, Got %#v", want, states)
+	}
+}
+
+func TestPipelineRunContinueOnFailure(t *testing.T) {
+	server := newPipelineServer(t, "2")
+	defer server.Close()
+
+	resulter := pipelineRunTester(t, server, fmt.Sprintf(testPipeline, true), "")
+	var exitErr *ExitError
+	if !errors.As(resulter.Error, &exitErr) || exitErr.Code != PipelineExitDegraded {
+		t.Errorf("Command expected to exit with code %d, got %#v", PipelineExitDegraded, resulter.Error)
+	}
+	errorMsg := fmt.Sprintf(locales.ErrorMessages("pipeline-degraded"), "2 deploy-app")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+	if len(server.runs) != 3 {
+		t.Errorf("Expected 3 runs to be created, got %d", len(server.runs))
+	}
+}
+
+func TestPipelineRunAmbiguousAutomation(t *testing.T) {
+	server := newPipelineServer(t)
+	defer server.Close()
+
+	resulter := pipelineRunTester(t, server, "selector: pool=green\nstages:\n  - automation: smoke-test\n", "")
+	errorMsg := fmt.Sprintf(locales.ErrorMessages("automation-name-ambiguous"), "smoke-test", "3, 4")
+	if !strings.Contains(resulter.ErrorOutput, errorMsg) {
+		t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, errorMsg))
+	}
+	if len(server.runs) != 0 {
+		t.Errorf("Expected no run to be created, got %d", len(server.runs))
+	}
+}
+
+func TestPipelineRunInvalid(t *testing.T) {
+	server := newPipelineServer(t)
+	defer server.Close()
+
+	resulter := pipelineRunTester(t, server, "stages:\n  - name: base\n    timeout: soon\n", "")
+	for _, line := range []string{"stages[0].automation: is required", "stages[0].selector: is required", "stages[0].timeout:"} {
+		if !strings.Contains(resulter.ErrorOutput, line) {
+			t.Errorf("Command error doesn't match. \n \n %s", StringDiff(resulter.ErrorOutput, line))
+		}
+	}
+}
@@ -15,6 +15,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -41,6 +42,10 @@ var RootCmd = &cobra.Command{
 	Long:         locales.CmdLongDescription("root"),
 	SilenceUsage: true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// completion requests set up the client when needed
+		if cmd.Name() == cobra.ShellCompRequestCmd {
+			return nil
+		}
 		// setup rest client
 		return setupRestClient(cmd, nil, false)
 	},
@@ -50,10 +55,24 @@ var RootCmd = &cobra.Command{
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := RootCmd.Execute(); err != nil {
+		var exitErr *ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
 
+// ExitError lets a command exit with a specific code
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
 func init() {
 	cobra.OnInitialize(initConfig)
 	initRootCmdFlags()
@@ -135,6 +154,9 @@ func initRootCmdFlags() {
 	// debug flag
 	RootCmd.PersistentFlags().BoolP(FLAG_DEBUG, "", false, "Print out request and response objects.")
 	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag(FLAG_DEBUG, RootCmd.PersistentFlags().Lookup(FLAG_DEBUG)), "BindPFlag:")
+	// dry run flag
+	RootCmd.PersistentFlags().BoolP(FLAG_DRY_RUN, "", false, "Print out the requests which would change data instead of sending them.")
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag(FLAG_DRY_RUN, RootCmd.PersistentFlags().Lookup(FLAG_DRY_RUN)), "BindPFlag:")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -220,6 +242,7 @@ func setupRestClient(cmd *cobra.Command, authV3 *auth.Authentication, forceReaut
 
 	// init rest client
 	RestClient = restclient.NewClient(endpoints, viper.GetString(ENV_VAR_TOKEN_NAME), viper.GetBool(FLAG_DEBUG))
+	RestClient.SetDryRun(viper.GetBool(FLAG_DRY_RUN))
 
 	return nil
 }
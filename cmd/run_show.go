@@ -16,61 +16,40 @@ package cmd
 
 import (
 	"errors"
-	"fmt"
 	"net/url"
 	"path"
 
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/locales"
-	"github.com/sapcc/lyra-cli/print"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var RunShowCmd = &cobra.Command{
-	Use:   "show",
-	Short: locales.CmdShortDescription("run-show"),
+	Use:               "show [RUN_ID]...",
+	Short:             locales.CmdShortDescription("run-show"),
+	ValidArgsFunction: runIdCompletion,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		// check required automation id
-		if len(viper.GetString(FLAG_RUN_ID)) == 0 {
+		// check required run id
+		if len(commandIds(FLAG_RUN_ID, args)) == 0 {
 			return errors.New(locales.ErrorMessages("run-id-missing"))
 		}
 
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// show automation
-		response, err := runShow(viper.GetString(FLAG_RUN_ID))
-		if err != nil {
-			return err
-		}
-
-		// convert data to struct
-		var dataStruct map[string]interface{}
-		err = helpers.JSONStringToStructure(response, &dataStruct)
-		if err != nil {
-			return err
-		}
-
-		// print the data out
-		printer := print.Print{Data: dataStruct}
-		var bodyPrint string
-		if viper.GetBool("json") {
-			bodyPrint, err = printer.JSON()
-			if err != nil {
-				return err
-			}
-		} else {
-			bodyPrint, err = printer.Table()
+		// show runs
+		responses := []string{}
+		for _, id := range commandIds(FLAG_RUN_ID, args) {
+			response, err := runShow(id)
 			if err != nil {
 				return err
 			}
+			responses = append(responses, response)
 		}
 
-		// Print response
-		fmt.Println(bodyPrint)
-
-		return nil
+		// print the data out
+		return printShowResults(responses)
 	},
 }
 
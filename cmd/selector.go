@@ -0,0 +1,52 @@
+// Copyright © 2016 Arturo Reuschenbach Puncernau <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/selector"
+	"github.com/spf13/cobra"
+)
+
+var SelectorCmd = &cobra.Command{
+	Use:   "selector",
+	Short: locales.CmdShortDescription("selector"),
+}
+
+func init() {
+	RootCmd.AddCommand(SelectorCmd)
+}
+
+// checkSelector validates the selector locally so syntax errors are shown before any request is sent
+func checkSelector(expression string) error {
+	err := selector.Validate(expression)
+	if err == nil {
+		return nil
+	}
+	return selectorError(err)
+}
+
+// selectorError adds the position marker to syntax errors
+func selectorError(err error) error {
+	syntaxErr, ok := err.(*selector.SyntaxError)
+	if !ok {
+		return err
+	}
+	context := strings.ReplaceAll(syntaxErr.Context(), "\n", "\n  ")
+	return fmt.Errorf("%s %s\n  %s", locales.ErrorMessages("selector-invalid"), syntaxErr, context)
+}
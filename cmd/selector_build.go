@@ -0,0 +1,76 @@
+// Copyright © 2016 Arturo Reuschenbach Puncernau <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/selector"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var SelectorBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: locales.CmdShortDescription("selector-build"),
+	Long:  locales.CmdLongDescription("selector-build"),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// DO NOT REMOVE. SHOULD OVERRIDE THE ROOT PersistentPreRunE
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		nodes := []selector.Node{}
+		for _, condition := range []struct {
+			key  string
+			fact bool
+		}{{"selector-build-tag", false}, {"selector-build-fact", true}} {
+			for _, value := range viper.GetStringSlice(condition.key) {
+				comparison, err := selector.ParseCondition(value, condition.fact)
+				if err != nil {
+					return selectorError(err)
+				}
+				nodes = append(nodes, comparison)
+			}
+		}
+		if len(nodes) == 0 {
+			return errors.New(locales.ErrorMessages("selector-conditions-missing"))
+		}
+
+		op := selector.And
+		if viper.GetBool("selector-build-or") {
+			op = selector.Or
+		}
+		printSelector(selector.Build(op, nodes...), viper.GetBool("selector-build-pretty"))
+		return nil
+	},
+}
+
+func init() {
+	SelectorCmd.AddCommand(SelectorBuildCmd)
+	initSelectorBuildCmdFlags()
+}
+
+func initSelectorBuildCmdFlags() {
+	SelectorBuildCmd.Flags().StringArray("tag", nil, locales.AttributeDescription("selector-tag"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("selector-build-tag", SelectorBuildCmd.Flags().Lookup("tag")), "BindPFlag:")
+	SelectorBuildCmd.Flags().StringArray("fact", nil, locales.AttributeDescription("selector-fact"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("selector-build-fact", SelectorBuildCmd.Flags().Lookup("fact")), "BindPFlag:")
+	SelectorBuildCmd.Flags().BoolP("or", "", false, locales.AttributeDescription("selector-or"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("selector-build-or", SelectorBuildCmd.Flags().Lookup("or")), "BindPFlag:")
+	SelectorBuildCmd.Flags().BoolP("pretty", "", false, locales.AttributeDescription("selector-pretty"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("selector-build-pretty", SelectorBuildCmd.Flags().Lookup("pretty")), "BindPFlag:")
+}
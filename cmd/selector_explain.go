@@ -0,0 +1,106 @@
+// Copyright © 2016 Arturo Reuschenbach Puncernau <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/print"
+	"github.com/sapcc/lyra-cli/selector"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var SelectorExplainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: locales.CmdShortDescription("selector-explain"),
+	Long:  locales.CmdLongDescription("selector-explain"),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(viper.GetString("selector-explain-selector")) == 0 {
+			return errors.New(locales.ErrorMessages("selector-missing"))
+		}
+		// resolve the node id from the name if no id given
+		return resolveNodeId("selector-explain-node-id", "selector-explain-node-name")
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		node, err := selector.Parse(viper.GetString("selector-explain-selector"))
+		if err != nil {
+			return selectorError(err)
+		}
+		maxAge, err := helpers.ParseDuration(viper.GetString("selector-explain-max-age"))
+		if err != nil {
+			return err
+		}
+		snapshot, err := findNodeSnapshot(viper.GetString("selector-explain-node-id"), maxAge)
+		if err != nil {
+			return err
+		}
+
+		explanation := selector.Explain(node, snapshot.Target())
+		if viper.GetBool("json") {
+			printer := print.Print{Data: explanation}
+			bodyPrint, err := printer.JSON()
+			if err != nil {
+				return err
+			}
+			fmt.Println(bodyPrint)
+		} else {
+			fmt.Println(strings.Join(explanationLines(explanation, ""), "\n"))
+		}
+
+		if explanation.Result {
+			cmd.Printf("Node %s matches the selector.\n", snapshot.Id())
+		} else {
+			cmd.Printf("Node %s does not match the selector.\n", snapshot.Id())
+		}
+		return nil
+	},
+}
+
+func init() {
+	SelectorCmd.AddCommand(SelectorExplainCmd)
+	initSelectorExplainCmdFlags()
+}
+
+func initSelectorExplainCmdFlags() {
+	SelectorExplainCmd.Flags().StringP(FLAG_ARC_NODE_ID, "", "", locales.AttributeDescription(FLAG_ARC_NODE_ID))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("selector-explain-node-id", SelectorExplainCmd.Flags().Lookup(FLAG_ARC_NODE_ID)), "BindPFlag:")
+	SelectorExplainCmd.Flags().StringP(FLAG_ARC_NODE, "", "", locales.AttributeDescription(FLAG_ARC_NODE))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("selector-explain-node-name", SelectorExplainCmd.Flags().Lookup(FLAG_ARC_NODE)), "BindPFlag:")
+	SelectorExplainCmd.Flags().StringP(FLAG_SELECTOR, "", "", locales.AttributeDescription("node-selector"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("selector-explain-selector", SelectorExplainCmd.Flags().Lookup(FLAG_SELECTOR)), "BindPFlag:")
+	SelectorExplainCmd.Flags().StringP("max-age", "", "10m", locales.AttributeDescription("node-snapshot-max-age"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("selector-explain-max-age", SelectorExplainCmd.Flags().Lookup("max-age")), "BindPFlag:")
+}
+
+// explanationLines returns one line per clause with its truth value, operands are indented below their operator
+func explanationLines(explanation selector.Explanation, indent string) []string {
+	line := fmt.Sprintf("%-5t  %s%s", explanation.Result, indent, explanation.Clause)
+	switch {
+	case explanation.Missing:
+		line += "  (missing)"
+	case len(explanation.Children) == 0:
+		line += fmt.Sprintf("  (value: %s)", explanation.Value)
+	}
+	lines := []string{line}
+	for _, child := range explanation.Children {
+		lines = append(lines, explanationLines(child, indent+"  ")...)
+	}
+	return lines
+}
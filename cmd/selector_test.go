@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sapcc/lyra-cli/locales"
+)
+
+func TestSelectorValidateCmd(t *testing.T) {
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, `lyra selector validate "@os = 'linux' and (pool='green' or pool=blue)"`)
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	want := "@os='linux' AND (pool='green' OR pool=blue)\n"
+	if resulter.Output != want {
+		t.Errorf("Expected %q, got %q", want, resulter.Output)
+	}
+}
+
+func TestSelectorValidateCmdPretty(t *testing.T) {
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, `lyra selector validate --pretty "@os='linux' AND (pool='green' OR pool='blue')"`)
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	want := "@os='linux'\nAND (\n  pool='green'\n  OR pool='blue'\n)\n"
+	if resulter.Output != want {
+		t.Errorf("Expected %q, got %q", want, resulter.Output)
+	}
+}
+
+func TestSelectorValidateCmdInvalid(t *testing.T) {
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, `lyra selector validate "@os='linux' AND pool"`)
+	want := locales.ErrorMessages("selector-invalid") + " expected an operator (!= ^= $= = ~ IN) after pool but found end of selector at position 21\n  @os='linux' AND pool\n                      ^"
+	if resulter.Error == nil || resulter.Error.Error() != want {
+		t.Errorf("Expected error %q, got %v", want, resulter.Error)
+	}
+}
+
+func TestSelectorBuildCmd(t *testing.T) {
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, "lyra selector build --tag pool=green --fact os=linux --fact hostname^=web")
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	want := "pool='green' AND @os='linux' AND @hostname^='web'\n"
+	if resulter.Output != want {
+		t.Errorf("Expected %q, got %q", want, resulter.Output)
+	}
+
+	ResetFlags()
+	resulter = FullCmdTester(RootCmd, "lyra selector build --or --tag pool=green --tag pool=blue")
+	if want := "pool='green' OR pool='blue'\n"; resulter.Output != want {
+		t.Errorf("Expected %q, got %q", want, resulter.Output)
+	}
+
+	ResetFlags()
+	resulter = FullCmdTester(RootCmd, "lyra selector build")
+	if resulter.Error == nil || resulter.Error.Error() != locales.ErrorMessages("selector-conditions-missing") {
+		t.Errorf("Expected missing conditions error, got %v", resulter.Error)
+	}
+
+	ResetFlags()
+	resulter = FullCmdTester(RootCmd, "lyra selector build --tag poolgreen")
+	if resulter.Error == nil || !strings.HasPrefix(resulter.Error.Error(), locales.ErrorMessages("selector-invalid")) {
+		t.Errorf("Expected invalid selector error, got %v", resulter.Error)
+	}
+}
+
+func TestNodeListCmdInvalidSelector(t *testing.T) {
+	server := TestServer(200, "[]", map[string]string{})
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, "lyra node list --lyra-service-endpoint="+server.URL+" --arc-service-endpoint="+server.URL+" --token=token123 --selector=@os=='linux'")
+	if resulter.Error == nil || !strings.HasPrefix(resulter.Error.Error(), locales.ErrorMessages("selector-invalid")+" expected a value after = but found \"=\" at position 5") {
+		t.Errorf("Expected invalid selector error, got %v", resulter.Error)
+	}
+}
+
+func TestSelectorExplainCmd(t *testing.T) {
+	var listRequests int32
+	server := newNodeSnapshotServer(t, &listRequests)
+	defer server.Close()
+
+	ResetFlags()
+	resulter := FullCmdTester(RootCmd, fmt.Sprintf(`lyra selector explain --node-id=node2 --selector "@os='linux' AND (pool=green OR owner!=x)" --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123`, server.URL, server.URL))
+	if resulter.Error != nil {
+		t.Fatalf("Command expected to not get an error: %s", resulter.Error)
+	}
+	want := `false  @os='linux' AND (pool=green OR owner!=x)
+true     @os='linux'  (value: linux)
+false    pool=green OR owner!=x
+false      pool=green  (value: blue)
+false      owner!=x  (missing)
+`
+	if resulter.Output != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, resulter.Output)
+	}
+	if !strings.Contains(resulter.ErrorOutput, "Node node2 does not match the selector.") {
+		t.Errorf("Expected the result, got %s", resulter.ErrorOutput)
+	}
+
+	ResetFlags()
+	resulter = FullCmdTester(RootCmd, fmt.Sprintf(`lyra selector explain --node-id=node9 --selector=pool=green --lyra-service-endpoint=%s --arc-service-endpoint=%s --token=token123`, server.URL, server.URL))
+	if want := fmt.Sprintf(locales.ErrorMessages("node-snapshot-missing"), "node9"); resulter.Error == nil || resulter.Error.Error() != want {
+		t.Errorf("Expected error %q, got %v", want, resulter.Error)
+	}
+	if listRequests != 2 {
+		t.Errorf("Expected the snapshot to be fetched again for a missing node, got %d requests", listRequests)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright © 2016 Arturo Reuschenbach Puncernau <a.reuschenbach.puncernau@sap.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sapcc/lyra-cli/helpers"
+	"github.com/sapcc/lyra-cli/locales"
+	"github.com/sapcc/lyra-cli/selector"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var SelectorValidateCmd = &cobra.Command{
+	Use:   "validate SELECTOR",
+	Args:  cobra.MinimumNArgs(1),
+	Short: locales.CmdShortDescription("selector-validate"),
+	Long:  locales.CmdLongDescription("selector-validate"),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// DO NOT REMOVE. SHOULD OVERRIDE THE ROOT PersistentPreRunE
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		node, err := selector.Parse(strings.Join(args, " "))
+		if err != nil {
+			return selectorError(err)
+		}
+		printSelector(node, viper.GetBool("selector-validate-pretty"))
+		return nil
+	},
+}
+
+func init() {
+	SelectorCmd.AddCommand(SelectorValidateCmd)
+	initSelectorValidateCmdFlags()
+}
+
+func initSelectorValidateCmdFlags() {
+	SelectorValidateCmd.Flags().BoolP("pretty", "", false, locales.AttributeDescription("selector-pretty"))
+	helpers.CheckErrAndPrintToStdErr(viper.BindPFlag("selector-validate-pretty", SelectorValidateCmd.Flags().Lookup("pretty")), "BindPFlag:")
+}
+
+// printSelector prints the normalized selector on one line or pretty printed
+func printSelector(node selector.Node, pretty bool) {
+	if pretty {
+		fmt.Println(selector.Pretty(node))
+		return
+	}
+	fmt.Println(node.String())
+}
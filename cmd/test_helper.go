@@ -313,10 +313,16 @@ func ResetFlags() {
 	AutomationCreateScriptCmd.ResetFlags()
 	AutomationCreateCmd.ResetFlags()
 	AutomationDeleteCmd.ResetFlags()
+	AutomationEditCmd.ResetFlags()
 	AutomationExecuteCmd.ResetFlags()
 	AutomationListCmd.ResetFlags()
 	AutomationShowCmd.ResetFlags()
+	AutomationValidateCmd.ResetFlags()
 	AutomationUpdateChefAttributesCmd.ResetFlags()
+	AutomationUpdateChefRunlistCmd.ResetFlags()
+	AutomationUpdateScriptCmd.ResetFlags()
+	PipelineRunCmd.ResetFlags()
+	NodeHealthCmd.ResetFlags()
 	AutomationUpdateChefCmd.ResetFlags()
 	AutomationUpdateCmd.ResetFlags()
 	AutomationCmd.ResetFlags()
@@ -329,10 +335,18 @@ func ResetFlags() {
 	NodeInstallCmd.ResetFlags()
 	NodeListCmd.ResetFlags()
 	NodeFactListCmd.ResetFlags()
+	NodeFactExportCmd.ResetFlags()
+	NodeFactSnapshotCmd.ResetFlags()
+	NodeFactDiffCmd.ResetFlags()
+	NodeFactSummaryCmd.ResetFlags()
 	NodeTagCmd.ResetFlags()
 	NodeTagAddCmd.ResetFlags()
 	NodeTagDeleteCmd.ResetFlags()
 	NodeTagListCmd.ResetFlags()
+	NodeTagSyncCmd.ResetFlags()
+	SelectorValidateCmd.ResetFlags()
+	SelectorBuildCmd.ResetFlags()
+	SelectorExplainCmd.ResetFlags()
 	NodeShowCmd.ResetFlags()
 	RunListCmd.ResetFlags()
 	RunShowCmd.ResetFlags()
@@ -344,10 +358,16 @@ func ResetFlags() {
 	initAutomationCreateScriptCmdFlags()
 	initAutomationCreateCmdFlags()
 	initAutomationDeleteCmdFlags()
+	initAutomationEditCmdFlags()
 	initAutomationExecuteCmdFlags()
 	initAutomationListCmdFlags()
 	initAutomationShowCmdFlags()
+	initAutomationValidateCmdFlags()
 	initAutomationUpdateChefAttributesCmdFlags()
+	initAutomationUpdateChefRunlistCmdFlags()
+	initAutomationUpdateScriptCmdFlags()
+	initPipelineRunCmdFlags()
+	initNodeHealthCmdFlags()
 	initAutomationUpdateChefCmdFlags()
 	initAutomationUpdateCmdFlags()
 	initAutomationCmdFlags()
@@ -360,9 +380,17 @@ func ResetFlags() {
 	initNodeListCmdFlags()
 	initNodeShowCmdFlags()
 	initNodeFactListCmdFlags()
+	initNodeFactExportCmdFlags()
+	initNodeFactSnapshotCmdFlags()
+	initNodeFactDiffCmdFlags()
+	initNodeFactSummaryCmdFlags()
 	initNodeTagAddCmdFlags()
 	initNodeTagDeleteCmdFlags()
 	initNodeTagListCmdFlags()
+	initNodeTagSyncCmdFlags()
+	initSelectorValidateCmdFlags()
+	initSelectorBuildCmdFlags()
+	initSelectorExplainCmdFlags()
 	initRunListCmdFlags()
 	initRunShowCmdFlags()
 	initRunCmdFlags()
@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CacheDir returns the directory of the local lyra cache files
+func CacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lyra-cli"), nil
+}
+
+// ReadCache decodes the cache file with the given name into data. It returns false when the file doesn't exist,
+// can not be decoded or is older than maxAge.
+func ReadCache(name string, maxAge time.Duration, data interface{}) bool {
+	dir, err := CacheDir()
+	if err != nil {
+		return false
+	}
+	file := filepath.Join(dir, name)
+	info, err := os.Stat(file)
+	if err != nil || time.Since(info.ModTime()) > maxAge {
+		return false
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(content, data) == nil
+}
+
+// WriteCache saves data as JSON in the cache file with the given name. The file is only readable by the user.
+func WriteCache(name string, data interface{}) error {
+	dir, err := CacheDir()
+	if err != nil {
+		return err
+	}
+	return writeJSONFile(dir, name, data)
+}
+
+// writeJSONFile saves data as JSON in the file of the directory. The file is only readable by the user.
+func writeJSONFile(dir, name string, data interface{}) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	content, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so concurrent readers never see a partial file
+	tmp, err := os.CreateTemp(dir, name+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
@@ -0,0 +1,44 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	data := []string{}
+	if ReadCache("missing.json", time.Minute, &data) {
+		t.Error("Expected a missing cache file not to be read")
+	}
+
+	want := []string{"1\tchef-base", "2"}
+	if err := WriteCache("entries.json", want); err != nil {
+		t.Fatal(err)
+	}
+	if !ReadCache("entries.json", time.Minute, &data) || !reflect.DeepEqual(data, want) {
+		t.Errorf("Expected cached %q, got %q", want, data)
+	}
+
+	// expired entries are ignored
+	dir, _ := CacheDir()
+	old := time.Now().Add(-2 * time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "entries.json"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if ReadCache("entries.json", time.Minute, &data) {
+		t.Error("Expected an expired cache file not to be read")
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "entries.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the cache file to be only readable by the user, got %v", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,142 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// EnvNameRegex matches valid environment variable names: letters, digits and '_', not starting with a digit.
+var EnvNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseDotenv parses environment variables in dotenv format. Empty lines and lines starting with '#' are
+// ignored, values can be single or double quoted and unquoted values may end with a ' #' comment.
+// Double quoted values support the escapes \n, \r, \t, \" and \\ and may span several lines.
+func ParseDotenv(content string) (map[string]string, error) {
+	result := map[string]string{}
+	lines := strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimSpace(lines[i])
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: %q is not a valid NAME=VALUE pair", lineNumber, line)
+		}
+		name := strings.TrimSpace(parts[0])
+		if !EnvNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("line %d: %q is not a valid variable name", lineNumber, name)
+		}
+		value := strings.TrimSpace(parts[1])
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			// collect the following lines until the closing quote
+			for !closedDoubleQuote(value) && i+1 < len(lines) {
+				i++
+				value = fmt.Sprint(value, "\n", lines[i])
+			}
+			end := closingDoubleQuote(value)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: missing closing quote", lineNumber)
+			}
+			if rest := strings.TrimSpace(value[end+1:]); len(rest) > 0 && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf("line %d: unexpected characters after the closing quote", lineNumber)
+			}
+			value = unescapeDoubleQuoted(value[1:end])
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: missing closing quote", lineNumber)
+			}
+			if rest := strings.TrimSpace(value[end+2:]); len(rest) > 0 && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf("line %d: unexpected characters after the closing quote", lineNumber)
+			}
+			value = value[1 : end+1]
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+
+		result[name] = value
+	}
+
+	return result, nil
+}
+
+// ParseArgumentList parses arguments given as a JSON array of strings or one argument per line.
+// In the line format empty lines are ignored.
+func ParseArgumentList(content string) ([]string, error) {
+	if strings.HasPrefix(strings.TrimSpace(content), "[") {
+		result := []string{}
+		if err := JSONStringToStructure(content, &result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	result := []string{}
+	for _, line := range strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n") {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		result = append(result, line)
+	}
+	return result, nil
+}
+
+// JSONToEnvironment converts a flat JSON object to environment variables. Numbers and booleans are
+// converted to strings, nested objects and arrays are not allowed.
+func JSONToEnvironment(content string) (map[string]string, error) {
+	var structure map[string]interface{}
+	if err := JSONStringToStructure(content, &structure); err != nil {
+		return nil, err
+	}
+
+	result := map[string]string{}
+	for name, value := range structure {
+		switch value := value.(type) {
+		case string:
+			result[name] = value
+		case float64:
+			result[name] = strconv.FormatFloat(value, 'f', -1, 64)
+		case bool:
+			result[name] = strconv.FormatBool(value)
+		case nil:
+			result[name] = ""
+		default:
+			return nil, fmt.Errorf("environment variable %s should be a string, number or boolean", name)
+		}
+	}
+	return result, nil
+}
+
+// private
+
+func closingDoubleQuote(value string) int {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func closedDoubleQuote(value string) bool {
+	return closingDoubleQuote(value) >= 0
+}
+
+func unescapeDoubleQuoted(value string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+	return replacer.Replace(value)
+}
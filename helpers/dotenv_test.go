@@ -0,0 +1,86 @@
+package helpers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	content := `# database settings
+DB_HOST=localhost
+export DB_PORT=5432
+EMPTY=
+UNQUOTED=some value # comment
+HASH=value#1
+SINGLE='single # quoted $HOME'
+DOUBLE="double \"quoted\"\tvalue" # comment
+MULTI="first
+second"
+ESCAPED="line\nbreak"
+`
+	want := map[string]string{
+		"DB_HOST":  "localhost",
+		"DB_PORT":  "5432",
+		"EMPTY":    "",
+		"UNQUOTED": "some value",
+		"HASH":     "value#1",
+		"SINGLE":   "single # quoted $HOME",
+		"DOUBLE":   "double \"quoted\"\tvalue",
+		"MULTI":    "first\nsecond",
+		"ESCAPED":  "line\nbreak",
+	}
+
+	result, err := ParseDotenv(content)
+	if err != nil {
+		t.Errorf("ParseDotenv expected to not get an error. err=%s", err)
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Expected %#v, Got %#v", want, result)
+	}
+
+	for i, invalid := range []string{"NO_VALUE", "1NAME=value", "A.B=value", `OPEN="value`, "OPEN='value", `AFTER="value" rest`} {
+		if _, err := ParseDotenv(invalid); err == nil {
+			t.Errorf("Case %d expected to get an error", i)
+		}
+	}
+}
+
+func TestParseArgumentList(t *testing.T) {
+	table := []struct {
+		input  string
+		output []string
+	}{
+		{"--verbose\n\n--name=some value\r\n", []string{"--verbose", "--name=some value"}},
+		{`["--verbose", "--name=some value"]`, []string{"--verbose", "--name=some value"}},
+		{"", []string{}},
+	}
+
+	for i, testCase := range table {
+		result, err := ParseArgumentList(testCase.input)
+		if err != nil {
+			t.Errorf("Case %d failed. No error expected. err=%s", i, err)
+		}
+		if !reflect.DeepEqual(result, testCase.output) {
+			t.Errorf("Case %d, Expected %#v, Got %#v", i, testCase.output, result)
+		}
+	}
+
+	if _, err := ParseArgumentList(`["--verbose", 1]`); err == nil {
+		t.Error("ParseArgumentList expected to get an error")
+	}
+}
+
+func TestJSONToEnvironment(t *testing.T) {
+	result, err := JSONToEnvironment(`{"NAME":"value","PORT":8080,"DEBUG":true}`)
+	if err != nil {
+		t.Errorf("JSONToEnvironment expected to not get an error. err=%s", err)
+	}
+	want := map[string]string{"NAME": "value", "PORT": "8080", "DEBUG": "true"}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Expected %#v, Got %#v", want, result)
+	}
+
+	if _, err := JSONToEnvironment(`{"NESTED":{"a":1}}`); err == nil {
+		t.Error("JSONToEnvironment expected to get an error")
+	}
+}
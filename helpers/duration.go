@@ -0,0 +1,31 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var dayDurationRegex = regexp.MustCompile(`^(\d+)([dw])$`)
+
+// ParseDuration parses a duration like time.ParseDuration and additionally accepts days and weeks. Ex: 30d, 2w.
+func ParseDuration(value string) (time.Duration, error) {
+	if match := dayDurationRegex.FindStringSubmatch(value); match != nil {
+		amount, err := strconv.Atoi(match[1])
+		if err != nil {
+			return 0, err
+		}
+		days := amount
+		if match[2] == "w" {
+			days = amount * 7
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a valid duration. Ex: 30d, 12h, 90m", value)
+	}
+	return duration, nil
+}
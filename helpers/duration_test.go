@@ -0,0 +1,33 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"30d", 30 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"12h", 12 * time.Hour},
+		{"1h30m", 90 * time.Minute},
+	}
+	for _, test := range tests {
+		got, err := ParseDuration(test.value)
+		if err != nil {
+			t.Errorf("%s: expected no error, got %s", test.value, err)
+		}
+		if got != test.want {
+			t.Errorf("%s: expected %s, got %s", test.value, test.want, got)
+		}
+	}
+
+	for _, value := range []string{"", "d", "30 days", "-d"} {
+		if _, err := ParseDuration(value); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
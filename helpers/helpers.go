@@ -6,11 +6,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"reflect"
 	"regexp"
 	"strings"
+
+	yaml "gopkg.in/yaml.v3"
 )
 
 func KeyValueSplit(data string) (string, string, error) {
@@ -60,6 +63,30 @@ func StructureToJSON(structure interface{}) (string, error) {
 	return string(bin), err
 }
 
+func YAMLStringToStructure(yamlString string, structure interface{}) error {
+	err := yaml.Unmarshal([]byte(yamlString), structure)
+	if err != nil {
+		return errors.New(fmt.Sprint("Invalid YAML:: ", err))
+	}
+	return nil
+}
+
+// StructureToYAML converts the structure to YAML using the JSON field names
+func StructureToYAML(structure interface{}) (string, error) {
+	// use the JSON representation so the json tags are respected
+	jsonString, err := StructureToJSON(structure)
+	if err != nil {
+		return "", err
+	}
+	var data interface{}
+	err = JSONStringToStructure(jsonString, &data)
+	if err != nil {
+		return "", err
+	}
+	bin, err := yaml.Marshal(data)
+	return string(bin), err
+}
+
 // read content from file
 // path containing a dash will mean read from std in
 func ReadFromFile(path string) (string, error) {
@@ -88,6 +115,19 @@ func ReadFromFile(path string) (string, error) {
 	return "", nil
 }
 
+// ReadFromFileWithLineBreaks reads the content like ReadFromFile but keeps the line breaks of the standard input.
+// Line based formats like YAML, CSV or dotenv need them.
+func ReadFromFileWithLineBreaks(path string) (string, error) {
+	if path == "-" {
+		dat, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		return string(dat), nil
+	}
+	return ReadFromFile(path)
+}
+
 func MapMerge(dst, src interface{}) {
 	dv, sv := reflect.ValueOf(dst), reflect.ValueOf(src)
 
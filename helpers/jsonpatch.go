@@ -0,0 +1,408 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONPatchOperation is one operation of a JSON patch document (RFC 6902)
+type JSONPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value"`
+}
+
+// JSONCopy returns a deep copy of a structure decoded from JSON
+func JSONCopy(doc interface{}) (interface{}, error) {
+	if doc == nil {
+		return nil, nil
+	}
+	bin, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	err = json.Unmarshal(bin, &result)
+	return result, err
+}
+
+// MergePatch applies a JSON merge patch (RFC 7386) to a copy of the target
+func MergePatch(target, patch interface{}) (interface{}, error) {
+	doc, err := JSONCopy(target)
+	if err != nil {
+		return nil, err
+	}
+	return mergePatch(doc, patch), nil
+}
+
+// JSONPatch applies the given operations (RFC 6902) to a copy of the document
+func JSONPatch(target interface{}, operations []JSONPatchOperation) (interface{}, error) {
+	doc, err := JSONCopy(target)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, operation := range operations {
+		tokens, err := parseJSONPointer(operation.Path)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %s", i, err)
+		}
+
+		switch operation.Op {
+		case "add":
+			doc, err = addJSONValue(doc, tokens, operation.Value, false)
+		case "replace":
+			doc, err = addJSONValue(doc, tokens, operation.Value, true)
+		case "remove":
+			doc, _, err = removeJSONValue(doc, tokens)
+		case "move", "copy":
+			var from []string
+			from, err = parseJSONPointer(operation.From)
+			if err != nil {
+				break
+			}
+			var value interface{}
+			if operation.Op == "move" {
+				doc, value, err = removeJSONValue(doc, from)
+			} else {
+				value, err = getJSONValue(doc, from)
+				if err == nil {
+					value, err = JSONCopy(value)
+				}
+			}
+			if err != nil {
+				break
+			}
+			doc, err = addJSONValue(doc, tokens, value, false)
+		case "test":
+			var value interface{}
+			value, err = getJSONValue(doc, tokens)
+			if err == nil && !jsonEqual(value, operation.Value) {
+				err = fmt.Errorf("test failed for path %q", operation.Path)
+			}
+		default:
+			err = fmt.Errorf("unknown operation %q", operation.Op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %s", i, err)
+		}
+	}
+
+	return doc, nil
+}
+
+// SetPath sets the value on the given dotted path. Missing objects on the way are created.
+func SetPath(target interface{}, path string, value interface{}) (interface{}, error) {
+	doc, err := JSONCopy(target)
+	if err != nil {
+		return nil, err
+	}
+	return setJSONValue(doc, strings.Split(path, "."), value)
+}
+
+// UnsetPath removes the value on the given dotted path
+func UnsetPath(target interface{}, path string) (interface{}, error) {
+	doc, err := JSONCopy(target)
+	if err != nil {
+		return nil, err
+	}
+	doc, _, err = removeJSONValue(doc, strings.Split(path, "."))
+	if err != nil {
+		return nil, fmt.Errorf("unset %q: %s", path, err)
+	}
+	return doc, nil
+}
+
+// JSONValueFromString converts the given string to a JSON value. Strings which are not valid JSON are returned as they are.
+func JSONValueFromString(data string) interface{} {
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return data
+	}
+	return value
+}
+
+// FlattenJSON converts a nested structure to a map of dotted paths to leaf values
+func FlattenJSON(doc interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	flattenJSON("", doc, result)
+	return result
+}
+
+// JSONDiff lists the differences between two structures. Each entry is prefixed with
+// '+' for added, '-' for removed and '~' for changed values.
+func JSONDiff(oldDoc, newDoc interface{}) []string {
+	oldFlat := FlattenJSON(oldDoc)
+	newFlat := FlattenJSON(newDoc)
+
+	keys := []string{}
+	for k := range oldFlat {
+		keys = append(keys, k)
+	}
+	for k := range newFlat {
+		if _, ok := oldFlat[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	diff := []string{}
+	for _, k := range keys {
+		oldValue, inOld := oldFlat[k]
+		newValue, inNew := newFlat[k]
+		switch {
+		case !inOld:
+			diff = append(diff, fmt.Sprintf("+ %s: %s", k, jsonString(newValue)))
+		case !inNew:
+			diff = append(diff, fmt.Sprintf("- %s: %s", k, jsonString(oldValue)))
+		case !jsonEqual(oldValue, newValue):
+			diff = append(diff, fmt.Sprintf("~ %s: %s => %s", k, jsonString(oldValue), jsonString(newValue)))
+		}
+	}
+
+	return diff
+}
+
+// private
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetMap, ok := target.(map[string]interface{})
+	if !ok {
+		targetMap = map[string]interface{}{}
+	}
+	for k, v := range patchMap {
+		if v == nil {
+			delete(targetMap, k)
+			continue
+		}
+		targetMap[k] = mergePatch(targetMap[k], v)
+	}
+	return targetMap
+}
+
+func parseJSONPointer(pointer string) ([]string, error) {
+	if len(pointer) == 0 {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// arrayIndex resolves the token to an index of an array with the given length.
+// When insert is set the index may point right after the last element.
+func arrayIndex(token string, length int, insert bool) (int, error) {
+	if token == "-" && insert {
+		return length, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > length || (i == length && !insert) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return i, nil
+}
+
+func getJSONValue(doc interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("path %q not found", token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("path %q not found", token)
+		}
+	}
+	return doc, nil
+}
+
+func addJSONValue(doc interface{}, tokens []string, value interface{}, replace bool) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	token := tokens[0]
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		if len(tokens) == 1 {
+			if _, ok := node[token]; replace && !ok {
+				return nil, fmt.Errorf("path %q not found", token)
+			}
+			node[token] = value
+			return node, nil
+		}
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("path %q not found", token)
+		}
+		newChild, err := addJSONValue(child, tokens[1:], value, replace)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = newChild
+		return node, nil
+	case []interface{}:
+		if len(tokens) == 1 && !replace {
+			i, err := arrayIndex(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		}
+		i, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		newChild, err := addJSONValue(node[i], tokens[1:], value, replace)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = newChild
+		return node, nil
+	}
+
+	return nil, fmt.Errorf("path %q not found", token)
+}
+
+func removeJSONValue(doc interface{}, tokens []string) (interface{}, interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+	token := tokens[0]
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("path %q not found", token)
+		}
+		if len(tokens) == 1 {
+			delete(node, token)
+			return node, child, nil
+		}
+		newChild, removed, err := removeJSONValue(child, tokens[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		node[token] = newChild
+		return node, removed, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(tokens) == 1 {
+			removed := node[i]
+			return append(node[:i], node[i+1:]...), removed, nil
+		}
+		newChild, removed, err := removeJSONValue(node[i], tokens[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		node[i] = newChild
+		return node, removed, nil
+	}
+
+	return nil, nil, fmt.Errorf("path %q not found", token)
+}
+
+func setJSONValue(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	token := tokens[0]
+
+	switch node := doc.(type) {
+	case []interface{}:
+		i, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		newChild, err := setJSONValue(node[i], tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = newChild
+		return node, nil
+	case map[string]interface{}:
+		newChild, err := setJSONValue(node[token], tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = newChild
+		return node, nil
+	}
+
+	// replace scalars and missing values with an object
+	newChild, err := setJSONValue(nil, tokens[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{token: newChild}, nil
+}
+
+func flattenJSON(prefix string, doc interface{}, result map[string]interface{}) {
+	join := func(key string) string {
+		if len(prefix) == 0 {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		if len(node) == 0 && len(prefix) > 0 {
+			result[prefix] = node
+		}
+		for k, v := range node {
+			flattenJSON(join(k), v, result)
+		}
+	case []interface{}:
+		if len(node) == 0 && len(prefix) > 0 {
+			result[prefix] = node
+		}
+		for i, v := range node {
+			flattenJSON(join(strconv.Itoa(i)), v, result)
+		}
+	default:
+		if len(prefix) > 0 || doc != nil {
+			result[prefix] = doc
+		}
+	}
+}
+
+func jsonString(value interface{}) string {
+	bin, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(bin)
+}
+
+func jsonEqual(a, b interface{}) bool {
+	return jsonString(a) == jsonString(b)
+}
@@ -0,0 +1,129 @@
+package helpers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	var target, patch, want interface{}
+	_ = JSONStringToStructure(`{"a":"b","c":{"d":"e","f":"g"},"keep":[1,2]}`, &target)
+	_ = JSONStringToStructure(`{"a":"z","c":{"f":null,"h":"i"}}`, &patch)
+	_ = JSONStringToStructure(`{"a":"z","c":{"d":"e","h":"i"},"keep":[1,2]}`, &want)
+
+	result, err := MergePatch(target, patch)
+	if err != nil {
+		t.Errorf("MergePatch expected to not get an error. err=%s", err)
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Expected %#v, Got %#v", want, result)
+	}
+	// the original stays untouched
+	if target.(map[string]interface{})["a"] != "b" {
+		t.Error("MergePatch expected to not modify the target")
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	table := []struct {
+		target string
+		patch  string
+		output string
+	}{
+		{`{"a":1}`, `[{"op":"add","path":"/b","value":2}]`, `{"a":1,"b":2}`},
+		{`{"a":[1,3]}`, `[{"op":"add","path":"/a/1","value":2}]`, `{"a":[1,2,3]}`},
+		{`{"a":[1,2]}`, `[{"op":"add","path":"/a/-","value":3}]`, `{"a":[1,2,3]}`},
+		{`{"a":1,"b":2}`, `[{"op":"remove","path":"/b"}]`, `{"a":1}`},
+		{`{"a":{"b":1}}`, `[{"op":"replace","path":"/a/b","value":"x"}]`, `{"a":{"b":"x"}}`},
+		{`{"a":{"b":1}}`, `[{"op":"move","from":"/a/b","path":"/c"}]`, `{"a":{},"c":1}`},
+		{`{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`},
+		{`{"a/b":1,"c~d":2}`, `[{"op":"remove","path":"/a~1b"},{"op":"remove","path":"/c~0d"}]`, `{}`},
+		{`{"a":1}`, `[{"op":"test","path":"/a","value":1},{"op":"add","path":"/b","value":true}]`, `{"a":1,"b":true}`},
+		{`{"a":1}`, `[{"op":"add","path":"/b","value":null}]`, `{"a":1,"b":null}`},
+	}
+
+	for i, testCase := range table {
+		var target, want interface{}
+		var operations []JSONPatchOperation
+		_ = JSONStringToStructure(testCase.target, &target)
+		_ = JSONStringToStructure(testCase.patch, &operations)
+		_ = JSONStringToStructure(testCase.output, &want)
+
+		result, err := JSONPatch(target, operations)
+		if err != nil {
+			t.Errorf("Case %d failed. No error expected. err=%s", i, err)
+		}
+		if !reflect.DeepEqual(result, want) {
+			t.Errorf("Case %d, Expected %#v, Got %#v", i, want, result)
+		}
+	}
+
+	// null values are kept when encoding the operations
+	bin, err := json.Marshal(JSONPatchOperation{Op: "add", Path: "/b"})
+	if err != nil || string(bin) != `{"op":"add","path":"/b","value":null}` {
+		t.Errorf("Expected the null value to be encoded, got %s", bin)
+	}
+
+	errors := []string{
+		`[{"op":"replace","path":"/missing","value":1}]`,
+		`[{"op":"remove","path":"/missing"}]`,
+		`[{"op":"test","path":"/a","value":2}]`,
+		`[{"op":"add","path":"a","value":2}]`,
+		`[{"op":"unknown","path":"/a"}]`,
+	}
+	for i, patch := range errors {
+		var operations []JSONPatchOperation
+		_ = JSONStringToStructure(patch, &operations)
+		if _, err := JSONPatch(map[string]interface{}{"a": 1.0}, operations); err == nil {
+			t.Errorf("Case %d expected to get an error", i)
+		}
+	}
+}
+
+func TestSetAndUnsetPath(t *testing.T) {
+	var target, want interface{}
+	_ = JSONStringToStructure(`{"nginx":{"port":80}}`, &target)
+
+	result, err := SetPath(target, "nginx.worker.count", JSONValueFromString("4"))
+	if err != nil {
+		t.Errorf("SetPath expected to not get an error. err=%s", err)
+	}
+	result, err = SetPath(result, "nginx.name", JSONValueFromString("web"))
+	if err != nil {
+		t.Errorf("SetPath expected to not get an error. err=%s", err)
+	}
+	_ = JSONStringToStructure(`{"nginx":{"port":80,"name":"web","worker":{"count":4}}}`, &want)
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Expected %#v, Got %#v", want, result)
+	}
+
+	result, err = UnsetPath(result, "nginx.worker")
+	if err != nil {
+		t.Errorf("UnsetPath expected to not get an error. err=%s", err)
+	}
+	_ = JSONStringToStructure(`{"nginx":{"port":80,"name":"web"}}`, &want)
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Expected %#v, Got %#v", want, result)
+	}
+
+	if _, err = UnsetPath(result, "nginx.missing"); err == nil {
+		t.Error("UnsetPath expected to get an error")
+	}
+}
+
+func TestJSONDiff(t *testing.T) {
+	var oldDoc, newDoc interface{}
+	_ = JSONStringToStructure(`{"a":1,"b":{"c":"d"},"e":[1]}`, &oldDoc)
+	_ = JSONStringToStructure(`{"a":2,"b":{"c":"d","f":true},"e":[]}`, &newDoc)
+
+	want := []string{`~ a: 1 => 2`, `+ b.f: true`, `+ e: []`, `- e.0: 1`}
+	result := JSONDiff(oldDoc, newDoc)
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Expected %#v, Got %#v", want, result)
+	}
+
+	if len(JSONDiff(oldDoc, oldDoc)) != 0 {
+		t.Error("JSONDiff expected to find no differences")
+	}
+}
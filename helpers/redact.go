@@ -0,0 +1,93 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RedactedValue replaces sensitive values in the output
+const RedactedValue = "[REDACTED]"
+
+// SensitiveKeys are the attributes which should never be shown. Use AddSensitiveKeys to extend them.
+var SensitiveKeys = []string{}
+
+var sensitiveJSONRegex *regexp.Regexp
+
+// AddSensitiveKeys adds attributes to the sensitive keys
+func AddSensitiveKeys(keys ...string) {
+	for _, key := range keys {
+		if !isSensitiveKey(key) {
+			SensitiveKeys = append(SensitiveKeys, key)
+		}
+	}
+	quoted := []string{}
+	for _, key := range SensitiveKeys {
+		quoted = append(quoted, regexp.QuoteMeta(key))
+	}
+	sensitiveJSONRegex = regexp.MustCompile(fmt.Sprintf(`("(?:%s)"\s*:\s*)("(?:[^"\\]|\\.)*"|[^,}\]\s]+)`, strings.Join(quoted, "|")))
+}
+
+// Redact returns a copy of the structure with the values of the sensitive keys replaced
+func Redact(data interface{}) interface{} {
+	switch node := data.(type) {
+	case map[string]interface{}:
+		result := map[string]interface{}{}
+		for k, v := range node {
+			if isSensitiveKey(k) && v != nil {
+				result[k] = RedactedValue
+				continue
+			}
+			result[k] = Redact(v)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(node))
+		for i, v := range node {
+			result[i] = Redact(v)
+		}
+		return result
+	}
+	return data
+}
+
+// Unredact returns a copy of the edited structure with the redacted values taken from the original structure
+func Unredact(edited, original interface{}) interface{} {
+	editedMap, ok := edited.(map[string]interface{})
+	if !ok {
+		return edited
+	}
+	originalMap, _ := original.(map[string]interface{})
+	result := map[string]interface{}{}
+	for k, v := range editedMap {
+		if isSensitiveKey(k) && v == RedactedValue {
+			result[k] = originalMap[k]
+			continue
+		}
+		result[k] = Unredact(v, originalMap[k])
+	}
+	return result
+}
+
+// RedactString replaces the values of the sensitive keys in a JSON string or a HTTP dump containing JSON
+func RedactString(data string) string {
+	if sensitiveJSONRegex == nil {
+		return data
+	}
+	return sensitiveJSONRegex.ReplaceAllStringFunc(data, func(match string) string {
+		parts := sensitiveJSONRegex.FindStringSubmatch(match)
+		if parts[2] == "null" {
+			return match
+		}
+		return fmt.Sprintf(`%s"%s"`, parts[1], RedactedValue)
+	})
+}
+
+func isSensitiveKey(key string) bool {
+	for _, k := range SensitiveKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func init() {
+	AddSensitiveKeys("repository_credentials")
+}
+
+func TestRedact(t *testing.T) {
+	var data, want interface{}
+	_ = JSONStringToStructure(`[{"name":"test","repository_credentials":"secret"},{"name":"other","repository_credentials":null}]`, &data)
+	_ = JSONStringToStructure(`[{"name":"test","repository_credentials":"[REDACTED]"},{"name":"other","repository_credentials":null}]`, &want)
+
+	result := Redact(data)
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Expected %#v, Got %#v", want, result)
+	}
+	// the original stays untouched
+	if data.([]interface{})[0].(map[string]interface{})["repository_credentials"] != "secret" {
+		t.Error("Redact expected to not modify the data")
+	}
+}
+
+func TestUnredact(t *testing.T) {
+	var edited, original, want interface{}
+	_ = JSONStringToStructure(`{"name":"new","repository_credentials":"[REDACTED]","nested":{"repository_credentials":"changed"}}`, &edited)
+	_ = JSONStringToStructure(`{"name":"old","repository_credentials":"secret","nested":{"repository_credentials":"other"}}`, &original)
+	_ = JSONStringToStructure(`{"name":"new","repository_credentials":"secret","nested":{"repository_credentials":"changed"}}`, &want)
+
+	result := Unredact(edited, original)
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Expected %#v, Got %#v", want, result)
+	}
+}
+
+func TestAddSensitiveKeys(t *testing.T) {
+	AddSensitiveKeys("repository_credentials", "api.key")
+	defer func() {
+		SensitiveKeys = SensitiveKeys[:1]
+		AddSensitiveKeys()
+	}()
+
+	if len(SensitiveKeys) != 2 {
+		t.Errorf("Expected the keys to be added once. Got %v", SensitiveKeys)
+	}
+	input := `{"api.key":"secret","apixkey":"visible"}`
+	if result := RedactString(input); result != `{"api.key":"[REDACTED]","apixkey":"visible"}` {
+		t.Errorf("Expected the new key to be redacted. Got %q", result)
+	}
+}
+
+func TestRedactString(t *testing.T) {
+	table := []struct {
+		input  string
+		output string
+	}{
+		{`{"name":"test","repository_credentials":"sec\"ret"}`, `{"name":"test","repository_credentials":"[REDACTED]"}`},
+		{`{"repository_credentials" : "secret", "a": 1}`, `{"repository_credentials" : "[REDACTED]", "a": 1}`},
+		{`{"repository_credentials":null}`, `{"repository_credentials":null}`},
+		{"POST /api/v1/automations HTTP/1.1\r\n\r\n{\"repository_credentials\":\"secret\"}", "POST /api/v1/automations HTTP/1.1\r\n\r\n{\"repository_credentials\":\"[REDACTED]\"}"},
+	}
+
+	for i, testCase := range table {
+		if result := RedactString(testCase.input); result != testCase.output {
+			t.Errorf("Case %d, Expected %q, Got %q", i, testCase.output, result)
+		}
+	}
+}
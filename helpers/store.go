@@ -0,0 +1,63 @@
+package helpers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DataDir returns the directory of the local lyra data. Other than the cache the data is kept until deleted.
+func DataDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lyra-cli"), nil
+}
+
+// WriteData saves data as JSON in the file with the given name of the data sub directory
+func WriteData(subDir, name string, data interface{}) error {
+	dir, err := DataDir()
+	if err != nil {
+		return err
+	}
+	return writeJSONFile(filepath.Join(dir, subDir), name, data)
+}
+
+// ReadData decodes the file with the given name of the data sub directory into data
+func ReadData(subDir, name string, data interface{}) error {
+	dir, err := DataDir()
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(filepath.Join(dir, subDir, name))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, data)
+}
+
+// ListData returns the sorted names of the files of the data sub directory with the given suffix
+func ListData(subDir, suffix string) ([]string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, subDir))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), suffix) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
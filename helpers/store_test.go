@@ -0,0 +1,35 @@
+package helpers
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	names, err := ListData("snapshots", ".json")
+	if err != nil || len(names) != 0 {
+		t.Errorf("Expected no data, got %q %v", names, err)
+	}
+
+	want := map[string]string{"os": "linux"}
+	for _, name := range []string{"b.json", "a.json", "c.txt"} {
+		if err := WriteData("snapshots", name, want); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, err = ListData("snapshots", ".json")
+	if err != nil || !reflect.DeepEqual(names, []string{"a.json", "b.json"}) {
+		t.Errorf("Expected the json files, got %q %v", names, err)
+	}
+
+	data := map[string]string{}
+	if err := ReadData("snapshots", "a.json", &data); err != nil || !reflect.DeepEqual(data, want) {
+		t.Errorf("Expected %v, got %v %v", want, data, err)
+	}
+	if err := ReadData("snapshots", "missing.json", &data); !os.IsNotExist(err) {
+		t.Errorf("Expected a not exist error, got %v", err)
+	}
+}
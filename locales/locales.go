@@ -5,42 +5,162 @@ import (
 )
 
 var attrDesc = map[string]string{
-	"json":                              `Attributes are JSON format.`,
-	"selector":                          `Filter used to select on which nodes should the automation be executed. Basic ex: @identity='{node_id}'.`,
-	"run-id":                            `Automation run identity.`,
-	"job-id":                            `Job identity.`,
-	"watch":                             `Keep track of the running process.`,
-	"automation-id":                     `Automation identity.`,
-	"automation-name":                   `Describes the template. Should be short and alphanumeric without white spaces.`,
-	"automation-repository":             `Describes the place where the automation is being described. Git is the only supported repository type. Ex: https://github.com/userId0123456789/automation-test.git.`,
-	"automation-repository-revision":    `Describes the repository branch.`,
-	"automation-repository-credentials": `Describes the authentication when using git reposititories.`,
-	"automation-timeout":                `Describes the time elapsed before a timeout is being triggered.`,
-	"automation-log-level":              `Describes the level should be used when logging.`,
-	"automation-debug":                  `Debug mode will not delete the temporary working directory on the instance when the automation job exists. This allows you to inspect the bundled automation artifacts, modify them and run the automation manually. Enabling debug mode for an extended period of time can exhaust  your instances disk space as each automation run will leave a directory behind. Also be aware that the payload may contain secrets which are persisted to disk indefinitely when debug mode is enabled. (default false)`,
-	"automation-tags":                   `"Are key value pairs. Key-value pairs are separated by ':' or '='. Following this pattern: 'key1:value1,key2=value2...'."`,
-	"automation-runlist":                `Describes the sequence of recipes should be executed. Runlist is an array of strings. Array of strings are separated by ','.`,
-	"automation-chef-version":           `Specifies the Chef version should be installed in case no Chef is already been installed. (default latest)`,
-	"automation-attributes":             `Attributes are JSON based.`,
-	"automation-attributes-from-file":   `Path to the file containing the chef attributes in JSON format. Giving a dash '-' will be read from standard input.`,
-	"automation-path":                   `Path to the script`,
-	"automation-argument":               `Specify a positional argument for the command. Can by specified multiple times.`,
-	"automation-environment":            `Specify an environment variable (NAME=VALUE). Can by specified multiple times.`,
-	"install-format":                    `Installation script format. Supported: linux,windows,cloud-config,json.`,
-	"node-id":                           `Node identity.`,
-	"node-selector":                     `Filter nodes. Basic ex: @identity='{node_id}'.`,
+	"json":                                 `Attributes are JSON format.`,
+	"selector":                             `Filter used to select on which nodes should the automation be executed. Basic ex: @identity='{node_id}'.`,
+	"run-id":                               `Automation run identity.`,
+	"job-id":                               `Job identity.`,
+	"watch":                                `Keep track of the running process.`,
+	"yes":                                  `Do not ask for confirmation.`,
+	"automation-execute-preview":           `Show the nodes matching the selector and ask for confirmation before executing the automation.`,
+	"automation-execute-batch-size":        `Execute the automation in batches of the given amount of nodes. Each batch waits until the run finished.`,
+	"automation-execute-canary":            `Execute the automation first on the given amount of nodes. Any failure on the canary batch halts the rollout.`,
+	"automation-execute-max-failures":      `Halt the rollout when the failed jobs of all batches exceed the given amount.`,
+	"automation-execute-pause":             `Time to wait between batches. Ex: 30s, 5m.`,
+	"automation-execute-confirm-threshold": `Ask for confirmation when the selector matches more nodes than the given amount. Can also be set as automation-execute-confirm-threshold in the config file. Zero disables the confirmation.`,
+	"automation-id":                        `Automation identity.`,
+	"automation":                           `Automation name. Used when no automation identity is given.`,
+	"automation-name":                      `Describes the template. Should be short and alphanumeric without white spaces.`,
+	"automation-repository":                `Describes the place where the automation is being described. Git is the only supported repository type. Ex: https://github.com/userId0123456789/automation-test.git.`,
+	"automation-repository-revision":       `Describes the repository branch.`,
+	"automation-repository-credentials":    `Describes the authentication when using git reposititories. The value is visible in the process list and the shell history, prefer the other repository-credentials options. Giving a dash '-' will be read from standard input.`,
+	"automation-timeout":                   `Describes the time elapsed before a timeout is being triggered.`,
+	"automation-log-level":                 `Describes the level should be used when logging.`,
+	"automation-debug":                     `Debug mode will not delete the temporary working directory on the instance when the automation job exists. This allows you to inspect the bundled automation artifacts, modify them and run the automation manually. Enabling debug mode for an extended period of time can exhaust  your instances disk space as each automation run will leave a directory behind. Also be aware that the payload may contain secrets which are persisted to disk indefinitely when debug mode is enabled. (default false)`,
+	"automation-tags":                      `"Are key value pairs. Key-value pairs are separated by ':' or '='. Following this pattern: 'key1:value1,key2=value2...'."`,
+	"automation-runlist":                   `Describes the sequence of recipes should be executed. Runlist is an array of strings. Array of strings are separated by ','.`,
+	"automation-runlist-add":               `Add an entry to the runlist. Can by specified multiple times.`,
+	"automation-runlist-remove":            `Remove an entry from the runlist. Can by specified multiple times.`,
+	"automation-runlist-move":              `Move an existing entry of the runlist to the position given by insert-before or insert-after. Can by specified multiple times.`,
+	"automation-runlist-insert-before":     `Entry of the runlist before which the added or moved entries are inserted. (default end of the runlist)`,
+	"automation-runlist-insert-after":      `Entry of the runlist after which the added or moved entries are inserted. (default end of the runlist)`,
+	"automation-chef-version":              `Specifies the Chef version should be installed in case no Chef is already been installed. (default latest)`,
+	"automation-attributes":                `Attributes are JSON based.`,
+	"automation-attributes-from-file":      `Path to the file containing the chef attributes in JSON format. Giving a dash '-' will be read from standard input.`,
+	"automation-attributes-merge":          `JSON merge patch (RFC 7386) applied to the current chef attributes. Null values remove keys.`,
+	"automation-attributes-patch":          `JSON patch (RFC 6902) operations applied to the current chef attributes. Ex: '[{"op":"replace","path":"/nginx/port","value":8080}]'.`,
+	"automation-attributes-set":            `Set a chef attribute (path.to.key=value). Values are parsed as JSON when possible. Can by specified multiple times.`,
+	"automation-attributes-unset":          `Remove a chef attribute (path.to.key). Can by specified multiple times.`,
+	"automation-attributes-preview":        `Ask for confirmation after showing the changes of the chef attributes.`,
+	"automation-path":                      `Path to the script`,
+	"automation-argument":                  `Specify a positional argument for the command. Can by specified multiple times.`,
+	"automation-environment":               `Specify an environment variable (NAME=VALUE). Can by specified multiple times.`,
+	"automation-arguments-file":            `Path to the file containing the arguments, one per line or as JSON array of strings. Arguments given with --arg are appended. Giving a dash '-' will be read from standard input.`,
+	"automation-environment-file":          `Path to the file containing environment variables in dotenv format (NAME=VALUE). Variables given with --env take precedence. Giving a dash '-' will be read from standard input.`,
+	"automation-environment-from-json":     `Path to the file containing environment variables as JSON object. Variables given with --env take precedence over the JSON and the --env-file. Giving a dash '-' will be read from standard input.`,
+	"pipeline-file":                        `Path to the pipeline file in YAML or JSON format. Giving a dash '-' will be read from standard input.`,
+	"automation-manifest":                  `Path to the file containing the automation in JSON or YAML format. Giving a dash '-' will be read from standard input.`,
+	"install-format":                       `Installation script format. Supported: linux,windows,cloud-config,json.`,
+	"node-id":                              `Node identity.`,
+	"node":                                 `Node display name or hostname. Used when no node identity is given.`,
+	"node-selector":                        `Filter nodes. Basic ex: @identity='{node_id}'.`,
+	"node-delete-selector":                 `Delete all nodes matching the selector after confirmation. Ex: @os='linux'.`,
+	"node-delete-stale-since":              `Delete all nodes not updated within the given time after confirmation. Can be combined with the selector. Ex: 30d, 12h.`,
+	"concurrency":                          `Amount of nodes processed at the same time.`,
+	"node-tag-selector":                    `Change the tags of all nodes matching the selector. Ex: @os='linux'.`,
+	"node-ids-from-file":                   `Path to the file containing one node id per line. Giving a dash '-' will be read from standard input.`,
+	"node-tag-from-file":                   `Path to a JSON or YAML file with the tags to add. Tags given as arguments take precedence. Giving a dash '-' will be read from standard input.`,
+	"node-tag-strict":                      `Fail on invalid tags. With --strict=false invalid tags are reported and skipped.`,
+	"node-tag-atomic":                      `Roll back the tags of all nodes when any node fails.`,
+	"node-tag-inventory":                   `Path to the CSV, YAML or JSON inventory with the tags per node. Giving a dash '-' will be read from standard input.`,
+	"node-tag-inventory-format":            `Format of the inventory, csv or yaml. Default depends on the file extension.`,
+	"node-tag-key-column":                  `CSV column naming the node. Default is the first column.`,
+	"node-tag-match-fact":                  `Fact matched against the node names of the inventory besides the node id and display name.`,
+	"node-tag-prune":                       `Delete the tags of the listed nodes which are not in the inventory.`,
+	"node-list-local":                      `Evaluate the selector locally on a cached snapshot of the nodes with their tags and facts.`,
+	"node-snapshot-max-age":                `Age after which the cached snapshot of the nodes is fetched again. Zero always fetches it. Ex: 10m, 1h.`,
+	"inventory-format":                     `Inventory format: ansible-ini, ansible-yaml, json or csv.`,
+	"inventory-group-by":                   `Group the nodes by the value of the tag or, with '@' prefix, the fact. Ex: pool, @os. Can be given several times.`,
+	"inventory-fact":                       `Export only the given fact. Can be given several times. Default are all facts.`,
+	"inventory-host-fact":                  `Fact used as host name. Nodes without it or with a name containing white space, brackets, '=', '#' or ';' use the display name or the node id.`,
+	"fact-snapshot-list":                   `List the stored snapshots of the node or of all nodes.`,
+	"fact-diff-node-id":                    `Node identity. Give it twice to compare two nodes.`,
+	"fact-diff-node":                       `Node display name or hostname. Can be given twice.`,
+	"fact-diff-since":                      `Compare the current facts with the snapshot with the given id.`,
+	"fact-summary-fact":                    `Fact to group the nodes by. Can be given multiple times.`,
+	"install-output-file":                  `Write the script to the file instead of printing it. The files are only readable by the owner, linux scripts are made executable. With several nodes the name is a template, Ex: node-{{.Index}}.sh`,
+	"install-count":                        `Amount of install scripts to create for new nodes.`,
+	"install-export":                       `Print the token and url of the json install format as ARC_TOKEN and ARC_URL shell variables.`,
+	"install-yaml":                         `Print the result of the json install format in YAML format.`,
+	"install-table":                        `Print the result of the json install format as table.`,
+	"install-template":                     `Path to a Go template file wrapping the script. Giving a dash '-' will be read from standard input.`,
+	"selector-pretty":                      `Print one condition per line.`,
+	"selector-tag":                         `Tag condition as name, operator and value. Ex: pool=green, pool!=red. Can be given several times.`,
+	"selector-fact":                        `Fact condition as name, operator and value. Ex: os=linux, hostname^=web. Can be given several times.`,
+	"selector-or":                          `Combine the conditions with OR instead of AND.`,
+	"node-health-stale-after":              `Nodes not updated within this time are stale. Ex: 15m, 2h, 1d.`,
+	"node-health-offline-after":            `Nodes not updated within this time are offline. Ex: 24h, 7d.`,
+	"node-health-max-offline":              `Exit with code 2 when more nodes are offline. Negative disables the check.`,
+
+	"automation-repository-credentials-from-file": `Path to the file containing the repository credentials. Giving a dash '-' will be read from standard input.`,
+	"automation-repository-credentials-from-env":  `Name of the environment variable containing the repository credentials.`,
+	"automation-repository-credentials-prompt":    `Prompt for the repository credentials.`,
 }
 
 var errMsg = map[string]string{
-	"automation-id-missing":       "No automation identity provided.",
-	"automation-selector-missing": "No automation selector given.",
-	"automation-run-failed":       "Automation failed.",
-	"run-id-missing":              "No automation run identity given.",
-	"job-id-missing":              "No job identity provided.",
-	"node-id-missing":             "No node identity provided.",
-	"job-missing":                 fmt.Sprint(jobMissingDesc),
-	"node-missing":                fmt.Sprint(nodeMissingDesc),
-	"flag-missing":                "Please make sure to provide following flags: ",
+	"automation-id-missing":             "No automation identity provided.",
+	"automation-selector-missing":       "No automation selector given.",
+	"selector-no-nodes":                 "Selector %s matches no nodes.",
+	"automation-batch-invalid":          "Batch size, canary and max failures can not be negative.",
+	"automation-batch-size-missing":     "Canary, max failures and pause need a batch size.",
+	"automation-batch-halted":           "Rollout halted after batch %d with %d failed jobs.",
+	"automation-name-not-found":         "No automation found with name %s.",
+	"automation-name-ambiguous":         "Found several automations with name %s. Use one of the ids %s.",
+	"completion-authentication-missing": "Completion needs a token or a password in the environment.",
+	"pipeline-file-missing":             "No pipeline file given.",
+	"pipeline-invalid":                  "Invalid pipeline:",
+	"pipeline-failed":                   "Pipeline failed on stage %d %s.",
+	"pipeline-degraded":                 "Pipeline finished with failed stages %s.",
+	"confirmation-missing":              "Confirmation required. Use --yes to skip the confirmation.",
+	"automation-run-failed":             "Automation failed.",
+	"run-id-missing":                    "No automation run identity given.",
+	"job-id-missing":                    "No job identity provided.",
+	"node-id-missing":                   "No node identity provided.",
+	"node-delete-selector-ids":          "Node ids can not be combined with the selector or stale since options.",
+	"node-delete-failed":                "%d of %d nodes could not be deleted.",
+	"node-health-thresholds-invalid":    "The stale time has to be positive and not greater than the offline time.",
+	"node-health-offline":               "%d nodes are offline, allowed are %d.",
+	"node-tag-invalid":                  "Invalid tags:",
+	"node-tag-failed":                   "Tags of %d of %d nodes could not be changed.",
+	"node-tag-rolled-back":              "Tags of %d of %d nodes could not be changed. The changes were rolled back.",
+	"node-tag-inventory-missing":        "No inventory file given.",
+	"node-tag-inventory-format":         "Unknown inventory format %s. Use csv or yaml.",
+	"node-tag-inventory-duplicate":      "Inventory entries %s and %s match the same node %s.",
+	"node-tag-key-column-missing":       "Column %s not found in the inventory.",
+	"inventory-format-invalid":          "Unknown format %s. Use one of %s.",
+	"fact-snapshot-missing":             "Fact snapshot %s not found. List the snapshots with lyra node fact snapshot --list.",
+	"fact-diff-nodes":                   "Give two nodes or a snapshot and at most one node to compare.",
+	"fact-summary-fact-missing":         "Give at least one fact to group the nodes by with --fact.",
+	"install-count-invalid":             "Invalid count %d. The count can't be negative.",
+	"install-nodes-conflict":            "Give the nodes with only one of --node-id, --node-ids-from-file or --count.",
+	"install-output-file-missing":       "Several install scripts need --output-file with a name template, Ex: --output-file node-{{.Index}}.sh",
+	"install-output-file-duplicate":     "The output file %s is the same for several scripts. Use {{.Index}} or {{.NodeId}} in --output-file.",
+	"install-json-format-only":          "The --export, --yaml and --table options need --install-format json.",
+	"install-json-invalid":              "Invalid install response: %s",
+	"install-template-invalid":          "Invalid template: %s",
+	"selector-missing":                  "No selector given.",
+	"node-snapshot-missing":             "Node %s not found.",
+	"selector-invalid":                  "Invalid selector:",
+	"selector-conditions-missing":       "No tag or fact conditions given.",
+	"node-name-not-found":               "No node found with display name or hostname %s.",
+	"node-name-ambiguous":               "Found several nodes with display name or hostname %s. Use one of the ids %s.",
+	"job-missing":                       fmt.Sprint(jobMissingDesc),
+	"node-missing":                      fmt.Sprint(nodeMissingDesc),
+	"automation-invalid":                "Invalid automation:",
+	"manifest-missing":                  "No manifest file given.",
+	"flag-missing":                      "Please make sure to provide following flags: ",
+
+	"repository-credentials-multiple":    "Please provide the repository credentials just once.",
+	"repository-credentials-env-missing": "Environment variable %s with the repository credentials not set.",
+	"repository-credentials-empty":       "Empty repository credentials given.",
+}
+
+var msg = map[string]string{
+	"chef-attributes-unchanged": "No changes in chef attributes.",
+	"chef-attributes-changes":   "Changes in chef attributes:",
+	"chef-attributes-confirm":   "Update the chef attributes?",
+	"chef-attributes-cancelled": "Chef attributes update cancelled.",
+	"fact-diff-unchanged":       "No differences.",
+	"fact-snapshot-saved":       "Saved %d facts of the node with id %s.",
 }
 
 var cmdShortDescription = map[string]string{
@@ -53,22 +173,28 @@ var cmdShortDescription = map[string]string{
 	"arc-node-tag-list":                 "List all tags from an especific node.",
 	"arc-node-tag-add":                  "Add tags to a given node.",
 	"arc-node-tag-delete":               "Deletes tags from a given node.",
+	"arc-node-tag-sync":                 "Syncs the node tags with an inventory",
 	"arc-node-fact":                     "Node facts.",
 	"arc-node-fact-list":                "List all facts from an especific node.",
 	"authenticate":                      "Get an authentication token and endpoints for the automation and arc service.",
 	"automation-create-chef":            "Create a new chef automation.",
 	"automation-create-script":          "Create a new script automation.",
 	"automation-create":                 "Create a new automation.",
+	"automation-edit":                   "Edit an existing automation in your editor",
 	"automation-execute":                "Runs an existing automation",
 	"automation-list":                   "List all available automations",
 	"automation-show":                   "Show a specific automation",
 	"automation-delete":                 "Deletes a specific automation.",
+	"automation-validate":               "Validates an automation manifest",
 	"automation-update-chef-attributes": "Updates chef attributes",
 	"automation-update-chef-runlist":    "Updates chef runlist",
 	"automation-update-chef":            "Updates a chef automation",
+	"automation-update-script":          "Updates a script automation",
 	"automation-update":                 "Updates an existing automation",
 	"automation":                        "Automation service.",
 	"bash-completion":                   "Generate completions for bash",
+	"completion":                        "Generate completions for bash, zsh, fish or powershell",
+	"arc-node-health":                   "Reports online, stale and offline nodes",
 	"job-list":                          "List all jobs",
 	"job-log":                           "Shows job log",
 	"job-show":                          "Shows an especific job",
@@ -77,17 +203,42 @@ var cmdShortDescription = map[string]string{
 	"run-list":                          "List all automation runs",
 	"run-show":                          "Show a specific automation run",
 	"run":                               "Automation run service.",
+	"pipeline-run":                      "Runs automations as an ordered pipeline",
+	"selector":                          "Node selectors.",
+	"selector-validate":                 "Validates and normalizes a selector",
+	"selector-build":                    "Builds a selector from tag and fact conditions",
+	"selector-explain":                  "Explains why a node matches a selector or not",
+	"arc-node-fact-export":              "Exports the facts of the nodes as inventory",
+	"arc-node-fact-snapshot":            "Stores the current facts of a node locally",
+	"arc-node-fact-diff":                "Shows the facts differing between two nodes or a snapshot",
+	"arc-node-fact-summary":             "Counts the nodes per fact value",
+	"pipeline":                          "Automation pipelines.",
 	"version":                           "Show program's version number and exit.",
 }
 
 var cmdLongDescription = map[string]string{
 	"bash-completion":                   `Add $(lyra bash-completion) to your .bashrc to enable tab completion for lyra`,
 	"root":                              `Execute ad-hoc jobs using scripts, Chef and Ansible to configure machines and install the open source IaC service into any other OpenStack.`,
-	"arc-node-delete":                   "Deletes an especific node. \nThis will just delete the entry in the data base. For a permanent deletion you have to remove the node itself from the instance.",
+	"arc-node-delete":                   "Deletes an especific node. \nThis will just delete the entry in the data base. For a permanent deletion you have to remove the node itself from the instance.\n\nWith --selector or --stale-since all matching nodes are listed and deleted after confirmation. Use --yes to skip the confirmation.",
 	"arc-node-tag-add":                  fmt.Sprint(nodeTagAddCmdLongDescription),
 	"arc-node-tag-delete":               fmt.Sprint(nodeTagDeleteCmdLongDescription),
+	"arc-node-tag-sync":                 fmt.Sprint(nodeTagSyncLongDescription),
+	"automation-edit":                   fmt.Sprint(automationEditLongDescription),
+	"automation-validate":               fmt.Sprint(automationValidateLongDescription),
 	"automation-update-chef-attributes": fmt.Sprint(automationUpdateChefAttributesLongDescription),
 	"automation-update-chef-runlist":    fmt.Sprint(automationUpdateChefRunlistLongDescription),
+	"automation-update-script":          fmt.Sprint(automationUpdateScriptLongDescription),
+	"pipeline-run":                      fmt.Sprint(pipelineRunLongDescription),
+	"completion":                        fmt.Sprint(completionLongDescription),
+	"arc-node-health":                   fmt.Sprint(nodeHealthLongDescription),
+	"selector-validate":                 fmt.Sprint(selectorValidateLongDescription),
+	"selector-build":                    fmt.Sprint(selectorBuildLongDescription),
+	"selector-explain":                  fmt.Sprint(selectorExplainLongDescription),
+	"arc-node-fact-export":              fmt.Sprint(nodeFactExportLongDescription),
+	"arc-node-fact-snapshot":            fmt.Sprint(nodeFactSnapshotLongDescription),
+	"arc-node-fact-diff":                fmt.Sprint(nodeFactDiffLongDescription),
+	"arc-node-fact-summary":             fmt.Sprint(nodeFactSummaryLongDescription),
+	"arc-node-install":                  fmt.Sprint(nodeInstallLongDescription),
 }
 
 func AttributeDescription(id string) string {
@@ -98,6 +249,10 @@ func ErrorMessages(id string) string {
 	return errMsg[id]
 }
 
+func Messages(id string) string {
+	return msg[id]
+}
+
 func CmdShortDescription(id string) string {
 	return cmdShortDescription[id]
 }
@@ -105,21 +260,184 @@ func CmdLongDescription(id string) string {
 	return cmdLongDescription[id]
 }
 
-var automationUpdateChefAttributesLongDescription = fmt.Sprint(CmdShortDescription("automation-update-chef-attributes"), "\n\n", `Example: lyra automation update chef attributes --automation-id=34 --attributes='{"test":"test2"}'`)
-var automationUpdateChefRunlistLongDescription = fmt.Sprint(CmdShortDescription("automation-update-chef-runlist"), "\n\n", `Example: lyra automation update chef runlist --automation-id=34 --runlist='recipe[nginx::default],role[staging]'`)
+var automationUpdateChefAttributesLongDescription = fmt.Sprint(CmdShortDescription("automation-update-chef-attributes"), "\n\n", `Attributes given with --attributes or --attributes-from-file replace the current ones. The --merge, --patch, --set and --unset options edit the current attributes and are applied in this order. The resulting changes are printed before the update is sent, with --preview the update has to be confirmed. Without changes nothing is sent.
+
+Example: lyra automation update chef attributes --automation-id=34 --attributes='{"test":"test2"}'
+Example: lyra automation update chef attributes --automation-id=34 --merge='{"nginx":{"port":8080}}'
+Example: lyra automation update chef attributes --automation-id=34 --set nginx.port=8080 --unset nginx.ssl`)
+var nodeHealthLongDescription = fmt.Sprint(CmdShortDescription("arc-node-health"), "\n\n", `Nodes reporting the fact online=false or not updated within --offline-after are offline, nodes not updated within --stale-after are stale. The states are counted per organization and project.
+
+Use --max-offline to run the command as monitoring probe. It exits with code 2 when more nodes are offline than allowed.
+
+Example:
+  lyra node health --stale-after 30m --offline-after 2d --max-offline 0`)
+
+var selectorValidateLongDescription = fmt.Sprint(CmdShortDescription("selector-validate"), "\n\n", `Selectors compare tags and facts, facts start with '@'. The operators are = (equal), != (not equal), ^= (starts with), $= (ends with) and ~ (regular expression). IN compares with a list of values, Ex: @identity IN ('a', 'b'). Comparisons are combined with AND, OR, NOT and parentheses. Values with other characters than letters, digits and "_-./:" have to be quoted.
+
+The selector is checked without contacting the service. Valid selectors are printed normalized, invalid ones fail showing the position of the problem.
+
+Example:
+  lyra selector validate "@os='linux' and (pool='green' or pool='blue')" --pretty`)
+
+var selectorBuildLongDescription = fmt.Sprint(CmdShortDescription("selector-build"), "\n\n", `The values are quoted as needed. Tag conditions come first, followed by the fact conditions.
+
+Example:
+  lyra selector build --tag pool=green --fact os=linux
+  lyra node list --selector "$(lyra selector build --fact hostname^=web --fact os!=windows)"`)
+
+var selectorExplainLongDescription = fmt.Sprint(CmdShortDescription("selector-explain"), "\n\n", `The selector is evaluated locally against the tags and facts of the node and the truth value of every clause is shown. Comparisons show the value of the node or that the node doesn't have the tag or fact. Comparisons with missing tags or facts are false, also for !=.
+
+The nodes with their tags and facts are cached as snapshot which is fetched again after --max-age. The same snapshot is used by lyra node list --local.
+
+Example:
+  lyra selector explain --node web1 --selector "@os='linux' AND (pool='green' OR pool='blue')"`)
+
+var nodeFactExportLongDescription = fmt.Sprint(CmdShortDescription("arc-node-fact-export"), "\n\n", `The facts and tags of all nodes matching the selector are fetched concurrently. The facts are exported as host variables together with arc_node_id. With --group-by the nodes are grouped by the value of a tag or fact, the group names are the key and the value joined with '_', Ex: pool_green or os_linux.
+
+The json format lists the nodes with their groups, tags and facts. The csv format has a row per node and a column per fact.
+
+Example:
+  lyra node fact export --selector "@os='linux'" --group-by pool --group-by @platform > inventory.ini
+  lyra node fact export --format ansible-yaml --fact ipaddress --fact platform_version`)
+
+var nodeFactSnapshotLongDescription = fmt.Sprint(CmdShortDescription("arc-node-fact-snapshot"), "\n\n", `The snapshot is saved in the lyra-cli directory of the user configuration directory and its id is printed. Use the id with lyra node fact diff --since to see how the facts changed.
+
+Example:
+  lyra node fact snapshot --node web1
+  lyra node fact snapshot --list`)
+
+var nodeFactDiffLongDescription = fmt.Sprint(CmdShortDescription("arc-node-fact-diff"), "\n\n", `Added, removed and changed facts are listed. Nested facts are compared by their dotted path, Ex: network.eth0.ipaddress.
+
+With --since the snapshot is compared with the current facts of its node or of the given node.
+
+Example:
+  lyra node fact diff --node-id 886ea868 --node-id 0128e993
+  lyra node fact diff --since 20261019T101500Z-886ea868`)
+
+var nodeFactSummaryLongDescription = fmt.Sprint(CmdShortDescription("arc-node-fact-summary"), "\n\n", `Nodes are grouped by the combination of the values of the given facts. Nodes not reporting a fact are shown with (none). The JSON output includes the node ids of every group.
+
+Example:
+  lyra node fact summary --fact os --fact platform_version
+  lyra node fact summary --fact agent_version --selector "@os='linux'" --json`)
+
+var nodeInstallLongDescription = fmt.Sprint(CmdShortDescription("arc-node-install"), "\n\n", `Several scripts are created with --count or --node-ids-from-file and written to the files named by the --output-file template.
+
+The json format is printed as returned by the server, with --table as table, with --yaml as YAML and with --export as ARC_TOKEN and ARC_URL shell variables.
+
+The --template file and the --output-file name are Go templates with the fields .NodeId, .Index (starting at 1), .Format and .Script and the functions indent, base64 and json. With the json format .Pki.Token and .Pki.Url hold the decoded result.
+
+Example:
+  lyra node install --install-format linux --output-file install.sh
+  eval "$(lyra node install --export)"
+  lyra node install --install-format linux --count 3 --output-file node-{{.Index}}.sh
+  lyra node install --install-format cloud-config --node-ids-from-file nodes.txt --template cloud-init.tmpl --output-file {{.NodeId}}.yaml`)
+
+var completionLongDescription = fmt.Sprint(CmdShortDescription("completion"), "\n\n", `Automation, node, run and job ids complete from the service using the credentials of the environment. The results are cached for a minute. Without a token or password in the environment only the static values complete.
+
+Load the completion in the current shell:
+  bash:       source <(lyra completion bash)
+  zsh:        source <(lyra completion zsh)
+  fish:       lyra completion fish | source
+  powershell: lyra completion powershell | Out-String | Invoke-Expression
+
+To load it in every session add the line to your .bashrc, .zshrc, fish config or powershell profile.`)
+
+var pipelineRunLongDescription = fmt.Sprint(CmdShortDescription("pipeline-run"), "\n\n", `The stages are executed one after the other, each one waits until its automation run finished. A failed or timed out stage skips the following ones unless continue_on_failure is set. The command exits with code 3 when a failed stage halted the pipeline and with code 4 when stages failed but the pipeline continued.
+
+Example pipeline.yaml:
+  selector: "pool='green'"
+  stages:
+    - name: base
+      automation: chef-base
+      timeout: 30m
+    - name: app
+      automation: deploy-app
+    - name: smoke-test
+      automation: 74
+      selector: "@identity='886ea868-ba06-42f8-9bde-eb1a848938'"
+      continue_on_failure: true
+
+Example:
+  lyra pipeline run -f pipeline.yaml`)
+
+var automationUpdateScriptLongDescription = fmt.Sprint(CmdShortDescription("automation-update-script"), "\n\n", `Arguments given with --arg or --args-file replace the current ones. Environment variables are merged into the current environment in the order --env-file, --env-from-json and --env, so explicit flags take precedence.
+
+Example:
+  lyra automation update script --automation-id=34 --env-file=.env --env=LOG_LEVEL=debug`)
+
+var automationUpdateChefRunlistLongDescription = fmt.Sprint(CmdShortDescription("automation-update-chef-runlist"), "\n\n", `A runlist given with --runlist replaces the current one. The --remove, --move and --add options edit the current runlist and are applied in this order. Entries should look like recipe[cookbook::recipe] or role[name].
+
+Example: lyra automation update chef runlist --automation-id=34 --runlist='recipe[nginx::default],role[staging]'
+Example: lyra automation update chef runlist --automation-id=34 --add='recipe[nginx::ssl]' --insert-after='recipe[nginx::default]'
+Example: lyra automation update chef runlist --automation-id=34 --remove='role[staging]' --move='recipe[base]' --insert-before='recipe[nginx::default]'`)
+
+var automationEditLongDescription = `Edit an existing automation in your editor.
+The automation is opened as YAML with the editor defined in the EDITOR environment variable (default vi).
+After saving and closing the editor the automation is validated and updated. When the validation fails
+the file is reopened with the errors as comments on top. Nothing is updated when the content is unchanged
+or the file is empty. Secrets like the repository credentials are shown as [REDACTED] and kept unless
+replaced.
+
+Example:
+lyra automation edit --automation-id 34
+EDITOR="code --wait" lyra automation edit --automation-id 34`
+
+var automationValidateLongDescription = `Validates an automation manifest without sending it.
+The manifest is a JSON or YAML file with the attributes of a chef or script automation. All problems
+are reported at once. The same checks are done before creating or updating an automation.
+
+Example manifest:
+type: Chef
+name: nginx
+repository: https://github.com/userId0123456789/automation-test.git
+repository_revision: master
+timeout: 3600
+run_list:
+  - recipe[nginx]
+
+Example:
+lyra automation validate -f automation.yaml`
+
+var nodeTagSyncLongDescription = fmt.Sprint(CmdShortDescription("arc-node-tag-sync"), "\n\n", `The inventory rows are matched to the nodes by node id, display name or the --match-fact fact. The tags are checked like with node tag add, invalid tags fail the sync before any change. The current tags are compared with the inventory and the planned additions, updates and deletions are shown before they are applied. Tags missing in the inventory are only deleted with --prune. Use --yes to skip the confirmation.
+
+Example inventory.csv:
+  hostname,pool,role,owner
+  web1,green,frontend,team-a
+  db1,red,database,
+
+Example inventory.yaml:
+  web1:
+    pool: green
+    role: frontend
+
+Example:
+  lyra node tag sync -f inventory.csv --prune`)
 
 var nodeTagDeleteCmdLongDescription = `Deletes tags from a given node.
 Add the keys from the desired tags as command arguments.
 
 Example:
 lyra node tag delete --node-id 123456789 pool name plan"
+
+Several nodes can be given with --selector or --node-ids-from-file. The results list the deleted keys and the keys the node doesn't have per node. With --atomic the deleted tags are added again when any node fails.
+
+Example:
+lyra node tag delete --selector "@os='linux'" --atomic pool
 `
 
 var nodeTagAddCmdLongDescription = `Add tags to a given node.
 Tags are key value pairs separated by the first "=" or ":" and added as command arguments. When using spacial characters use quotations.
+Keys may only contain letters, digits, "_" and "-". Keys and values are limited to 255 characters. Invalid tags fail the command unless --strict=false is given.
+After adding the tags they are listed as created, overwritten or unchanged.
+
+Example:
+lyra node tag add --node-id 123456789 pool:green name=db "plan=test new"
+lyra node tag add --node-id 123456789 --from-file tags.yaml
+
+Several nodes can be given with --selector or --node-ids-from-file. The results list the created, overwritten and unchanged tags per node. With --atomic the tags of all nodes are restored when any node fails.
 
 Example:
-lyra node tag add --node-id 123456789 pool:green name=db "plan=test new"`
+lyra node tag add --node-ids-from-file nodes.txt --atomic pool=blue`
 
 var jobMissingDesc = `Job not found.
 
@@ -25,7 +25,7 @@ func (p *Print) TableList(showColumns []string) (string, error) {
 	table.SetAlignment(3)
 	table.SetHeader(showColumns)
 
-	arrayStruct, ok := p.Data.([]interface{})
+	arrayStruct, ok := helpers.Redact(p.Data).([]interface{})
 	if !ok {
 		return "", ErrTypeAssertion
 	}
@@ -51,7 +51,7 @@ func (p *Print) TableList(showColumns []string) (string, error) {
 
 // Table is a table where all keys as columns will be print
 func (p *Print) Table() (string, error) {
-	dataStruct, ok := p.Data.(map[string]interface{})
+	dataStruct, ok := helpers.Redact(p.Data).(map[string]interface{})
 	if !ok {
 		return "", ErrTypeAssertion
 	}
@@ -85,7 +85,7 @@ func (p *Print) Table() (string, error) {
 
 func (p *Print) JSON() (string, error) {
 	// convert data
-	jsonData, err := helpers.StructureToJSON(p.Data)
+	jsonData, err := helpers.StructureToJSON(helpers.Redact(p.Data))
 	if err != nil {
 		return "", err
 	}
@@ -98,3 +98,8 @@ func (p *Print) JSON() (string, error) {
 
 	return out.String(), nil
 }
+
+// YAML prints the data as YAML using the JSON field names
+func (p *Print) YAML() (string, error) {
+	return helpers.StructureToYAML(helpers.Redact(p.Data))
+}
@@ -12,23 +12,29 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 
 	"github.com/sapcc/lyra-cli/helpers"
 	"github.com/sapcc/lyra-cli/version"
 )
 
+// ErrDryRun is returned instead of sending a request which would change data when dry run is enabled
+var ErrDryRun = errors.New("dry run, request not sent")
+
 type Client struct {
 	Services map[string]Endpoint
 	Token    string
 	Debug    bool
+	DryRun   bool
 }
 
 type Endpoint struct {
-	ID    string
-	Url   string
-	token string
-	debug bool
+	ID     string
+	Url    string
+	token  string
+	debug  bool
+	dryRun bool
 }
 
 type Pagination struct {
@@ -57,8 +63,23 @@ func NewClient(endpoints []Endpoint, token string, debug bool) *Client {
 	}
 }
 
+// SetDryRun enables or disables the dry run. With dry run enabled requests which would change data are printed to
+// the standard output instead of being sent and ErrDryRun is returned. GET requests are still sent.
+func (c *Client) SetDryRun(dryRun bool) {
+	c.DryRun = dryRun
+	for id, e := range c.Services {
+		e.dryRun = dryRun
+		c.Services[id] = e
+	}
+}
+
+// IsDryRun returns true if the error reports a request not sent because of the dry run
+func IsDryRun(err error) bool {
+	return errors.Is(err, ErrDryRun)
+}
+
 func (e *Endpoint) Put(pathAction string, params url.Values, body string) (string, int, error) {
-	resp, err := restCall(e.Url, e.token, pathAction, "PUT", params, http.Header{}, bytes.NewBufferString(body), e.debug)
+	resp, err := restCall(e.Url, e.token, pathAction, "PUT", params, http.Header{}, bytes.NewBufferString(body), e.debug, e.dryRun)
 	if err != nil {
 		return "", 0, err
 	}
@@ -78,7 +99,7 @@ func (e *Endpoint) Put(pathAction string, params url.Values, body string) (strin
 }
 
 func (e *Endpoint) Post(pathAction string, params url.Values, header http.Header, body string) (string, int, error) {
-	resp, err := restCall(e.Url, e.token, pathAction, "POST", params, header, bytes.NewBufferString(body), e.debug)
+	resp, err := restCall(e.Url, e.token, pathAction, "POST", params, header, bytes.NewBufferString(body), e.debug, e.dryRun)
 	if err != nil {
 		return "", 0, err
 	}
@@ -130,7 +151,7 @@ func (e *Endpoint) GetList(pathAction string, params url.Values) ([]interface{},
 }
 
 func (e *Endpoint) Get(pathAction string, params url.Values, showPagination bool) (string, int, error) {
-	resp, err := restCall(e.Url, e.token, pathAction, "GET", params, http.Header{}, nil, e.debug)
+	resp, err := restCall(e.Url, e.token, pathAction, "GET", params, http.Header{}, nil, e.debug, e.dryRun)
 	if err != nil {
 		return "", 0, err
 	}
@@ -150,7 +171,7 @@ func (e *Endpoint) Get(pathAction string, params url.Values, showPagination bool
 }
 
 func (e *Endpoint) Delete(pathAction string, params url.Values) (string, int, error) {
-	resp, err := restCall(e.Url, e.token, pathAction, "DELETE", params, http.Header{}, nil, e.debug)
+	resp, err := restCall(e.Url, e.token, pathAction, "DELETE", params, http.Header{}, nil, e.debug, e.dryRun)
 	if err != nil {
 		return "", 0, err
 	}
@@ -172,7 +193,7 @@ func (e *Endpoint) Delete(pathAction string, params url.Values) (string, int, er
 // private
 
 func (e *Endpoint) getListEntry(pathAction string, params url.Values) (*PagResp, int, error) {
-	resp, err := restCall(e.Url, e.token, pathAction, "GET", params, http.Header{}, nil, e.debug)
+	resp, err := restCall(e.Url, e.token, pathAction, "GET", params, http.Header{}, nil, e.debug, e.dryRun)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -200,7 +221,34 @@ func (e *Endpoint) getListEntry(pathAction string, params url.Values) (*PagResp,
 	return &pagData, resp.StatusCode, nil
 }
 
-func restCall(endpoint string, token string, pathAction string, method string, params url.Values, headers http.Header, body *bytes.Buffer, debug bool) (*http.Response, error) {
+func restCall(endpoint string, token string, pathAction string, method string, params url.Values, headers http.Header, body *bytes.Buffer, debug bool, dryRun bool) (*http.Response, error) {
+	req, err := newRequest(endpoint, token, pathAction, method, params, headers, body, debug)
+	if err != nil {
+		return nil, err
+	}
+
+	// print requests changing data instead of sending them
+	if dryRun && method != "GET" {
+		if err = dryRunOutput(req, body); err != nil {
+			return nil, err
+		}
+		return nil, ErrDryRun
+	}
+
+	// send the request
+	httpclient := &http.Client{}
+	resp, err := httpclient.Do(req)
+	if debug {
+		debugOutput(httputil.DumpResponse(resp, true))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func newRequest(endpoint string, token string, pathAction string, method string, params url.Values, headers http.Header, body *bytes.Buffer, debug bool) (*http.Request, error) {
 	// set up the rest url
 	u, err := url.Parse(endpoint)
 	if err != nil {
@@ -212,11 +260,10 @@ func restCall(endpoint string, token string, pathAction string, method string, p
 	// set up body
 	var reqBody io.Reader
 	if body != nil && body.Len() > 0 {
-		reqBody = body
+		reqBody = bytes.NewReader(body.Bytes())
 	}
 
 	// set up the request
-	httpclient := &http.Client{}
 	req, err := http.NewRequest(method, u.String(), reqBody)
 	if debug {
 		debugOutput(httputil.DumpRequestOut(req, true))
@@ -233,16 +280,7 @@ func restCall(endpoint string, token string, pathAction string, method string, p
 	req.Header.Add("X-Auth-Token", token)
 	req.Header.Add("Content-Type", "application/json")
 
-	// send the request
-	resp, err := httpclient.Do(req)
-	if debug {
-		debugOutput(httputil.DumpResponse(resp, true))
-	}
-	if err != nil {
-		return nil, err
-	}
-
-	return resp, nil
+	return req, nil
 }
 
 func extractPagination(header map[string][]string) Pagination {
@@ -282,7 +320,7 @@ func debugOutput(data []byte, err error) {
 	var fmtError error
 	// debug information will send to the stderr so it does not get mixed with the real output
 	if err == nil {
-		_, fmtError = fmt.Fprintf(os.Stderr, "%s\n\n", data)
+		_, fmtError = fmt.Fprintf(os.Stderr, "%s\n\n", helpers.RedactString(string(data)))
 	} else {
 		_, fmtError = fmt.Fprintf(os.Stderr, "%s\n\n", err)
 	}
@@ -290,3 +328,31 @@ func debugOutput(data []byte, err error) {
 		log.Fatal(fmtError)
 	}
 }
+
+// dryRunOutput prints the method, URL, headers and body of the request to the standard output. The token and
+// sensitive attributes are redacted.
+func dryRunOutput(req *http.Request, body *bytes.Buffer) error {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%s %s\n", req.Method, req.URL.String())
+
+	keys := []string{}
+	for k := range req.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range req.Header[k] {
+			if k == "X-Auth-Token" {
+				v = helpers.RedactedValue
+			}
+			fmt.Fprintf(&out, "%s: %s\n", k, v)
+		}
+	}
+
+	if body != nil && body.Len() > 0 {
+		fmt.Fprintf(&out, "\n%s\n", helpers.RedactString(jsonPrettyPrint(body.String())))
+	}
+
+	_, err := fmt.Fprintln(os.Stdout, out.String())
+	return err
+}
@@ -0,0 +1,45 @@
+package selector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseCondition returns the comparison of a tag or fact given as name, operator and value, Ex: pool=green or
+// os!=windows. The value is always quoted.
+func ParseCondition(condition string, fact bool) (*Comparison, error) {
+	// the first operator found wins, longer operators first at the same position
+	index, op := -1, ""
+	for _, candidate := range operators {
+		if i := strings.Index(condition, candidate); i >= 0 && (index < 0 || i < index || (i == index && len(candidate) > len(op))) {
+			index, op = i, candidate
+		}
+	}
+	if index < 0 {
+		return nil, &SyntaxError{Selector: condition, Pos: len([]rune(condition)) + 1, Msg: fmt.Sprintf("expected an operator (%s)", strings.Join(operators, " "))}
+	}
+
+	name := strings.TrimPrefix(condition[:index], "@")
+	if len(name) == 0 || strings.IndexFunc(name, func(r rune) bool { return !isWordRune(r) }) >= 0 {
+		return nil, &SyntaxError{Selector: condition, Pos: 1, Msg: fmt.Sprintf("invalid name %q", name)}
+	}
+	comparison := &Comparison{Field: Field{Name: name, Fact: fact}, Op: op, Value: Value{Text: condition[index+len(op):], Quoted: true}, Pos: 1}
+
+	// check the regular expression
+	if _, err := Parse(comparison.String()); err != nil {
+		return nil, &SyntaxError{Selector: condition, Pos: len([]rune(condition[:index+len(op)])) + 1, Msg: err.(*SyntaxError).Msg}
+	}
+	return comparison, nil
+}
+
+// Build combines the nodes with the logical operator
+func Build(op string, nodes ...Node) Node {
+	if len(nodes) == 0 {
+		return nil
+	}
+	result := nodes[0]
+	for _, node := range nodes[1:] {
+		result = &Binary{Op: op, Left: result, Right: node, Pos: result.Position()}
+	}
+	return result
+}
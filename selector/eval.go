@@ -0,0 +1,101 @@
+package selector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Target holds the tags and facts a selector is evaluated against
+type Target struct {
+	Tags  map[string]string
+	Facts map[string]interface{}
+}
+
+// lookup returns the value of the field as string and false when the node doesn't have it
+func (t Target) lookup(field Field) (string, bool) {
+	if field.Fact {
+		value, ok := t.Facts[field.Name]
+		if !ok || value == nil {
+			return "", false
+		}
+		return fmt.Sprint(value), true
+	}
+	value, ok := t.Tags[field.Name]
+	return value, ok
+}
+
+// Explanation is the truth value of a clause of the selector. Comparisons include the value of the node, AND, OR
+// and NOT include the explanations of their operands.
+type Explanation struct {
+	Clause   string        `json:"clause"`
+	Result   bool          `json:"result"`
+	Value    string        `json:"value,omitempty"`
+	Missing  bool          `json:"missing,omitempty"`
+	Children []Explanation `json:"children,omitempty"`
+}
+
+// Evaluate returns true when the target matches the selector. Comparisons with a tag or fact the target doesn't
+// have are false, also for !=.
+func Evaluate(node Node, target Target) bool {
+	return Explain(node, target).Result
+}
+
+// Explain evaluates all clauses of the selector against the target
+func Explain(node Node, target Target) Explanation {
+	explanation := Explanation{Clause: node.String()}
+	switch node := node.(type) {
+	case *Binary:
+		explanation.Result = node.Op == And
+		for _, operand := range flatten(node, node.Op) {
+			child := Explain(operand, target)
+			if node.Op == And {
+				explanation.Result = explanation.Result && child.Result
+			} else {
+				explanation.Result = explanation.Result || child.Result
+			}
+			explanation.Children = append(explanation.Children, child)
+		}
+	case *Negation:
+		child := Explain(node.Expr, target)
+		explanation.Result = !child.Result
+		explanation.Children = []Explanation{child}
+	case *Comparison:
+		value, ok := target.lookup(node.Field)
+		explanation.Value = value
+		explanation.Missing = !ok
+		explanation.Result = ok && node.matches(value)
+	}
+	return explanation
+}
+
+// matches compares the value of the node with the value or, for IN, with the list of values
+func (c *Comparison) matches(value string) bool {
+	if c.Op != OpIn {
+		return compare(c.Op, value, c.Value.Text)
+	}
+	for _, expected := range c.Values {
+		if value == expected.Text {
+			return true
+		}
+	}
+	return false
+}
+
+func compare(op, value, expected string) bool {
+	switch op {
+	case OpEqual:
+		return value == expected
+	case OpNotEqual:
+		return value != expected
+	case OpStartsWith:
+		return strings.HasPrefix(value, expected)
+	case OpEndsWith:
+		return strings.HasSuffix(value, expected)
+	case OpMatches:
+		// the expression was checked when parsing
+		matcher, err := regexp.Compile(expected)
+		return err == nil && matcher.MatchString(value)
+	}
+	return false
+}
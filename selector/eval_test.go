@@ -0,0 +1,66 @@
+package selector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	target := Target{
+		Tags:  map[string]string{"pool": "green", "name": "web1"},
+		Facts: map[string]interface{}{"os": "linux", "online": true, "memory_total": float64(8080), "hostname": "web1.example.com"},
+	}
+	tests := map[string]bool{
+		"pool=green":                                    true,
+		"pool='red'":                                    false,
+		"@os='linux' AND pool='green'":                  true,
+		"@os='linux' AND pool='red'":                    false,
+		"pool='red' OR name='web1'":                     true,
+		"NOT pool='red'":                                true,
+		"pool!=red":                                     true,
+		"owner!=team-a":                                 false,
+		"@online=true AND @memory_total=8080":           true,
+		"@hostname^=web AND @hostname$=.example.com":    true,
+		"@hostname~'^web[0-9]+\\.'":                     true,
+		"@hostname~'^db'":                               false,
+		"@os='linux' AND (pool='red' OR pool=green)":    true,
+		"NOT (@os='linux' AND (pool=red OR pool=blue))": true,
+		"pool IN ('red', 'green')":                      true,
+		"pool IN (red, blue)":                           false,
+		"owner IN ('team-a')":                           false,
+		"@os IN ('linux') AND NOT name IN ('web2')":     true,
+	}
+	for expression, want := range tests {
+		node, err := Parse(expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Evaluate(node, target); got != want {
+			t.Errorf("Expected %q to be %v, got %v", expression, want, got)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	node, err := Parse("@os='linux' AND (pool='red' OR NOT owner=x)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Explain(node, Target{Tags: map[string]string{"pool": "green"}, Facts: map[string]interface{}{"os": "linux"}})
+	want := Explanation{
+		Clause: "@os='linux' AND (pool='red' OR NOT owner=x)",
+		Result: true,
+		Children: []Explanation{
+			{Clause: "@os='linux'", Result: true, Value: "linux"},
+			{Clause: "pool='red' OR NOT owner=x", Result: true, Children: []Explanation{
+				{Clause: "pool='red'", Result: false, Value: "green"},
+				{Clause: "NOT owner=x", Result: true, Children: []Explanation{
+					{Clause: "owner=x", Result: false, Missing: true},
+				}},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
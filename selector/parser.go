@@ -0,0 +1,310 @@
+package selector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// SyntaxError reports an invalid selector with the position of the problem starting with 1
+type SyntaxError struct {
+	Selector string
+	Pos      int
+	Msg      string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+// Context returns the selector with a marker below the position of the problem
+func (e *SyntaxError) Context() string {
+	return e.Selector + "\n" + strings.Repeat(" ", e.Pos-1) + "^"
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenWord
+	tokenFact
+	tokenString
+	tokenOp
+	tokenLeft
+	tokenRight
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// describe returns the token as used in error messages
+func (t token) describe() string {
+	if t.kind == tokenEnd {
+		return "end of selector"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// keyword returns the logical operator when the token is one
+func (t token) keyword() string {
+	if t.kind != tokenWord {
+		return ""
+	}
+	switch upper := strings.ToUpper(t.text); upper {
+	case And, Or, Not:
+		return upper
+	}
+	return ""
+}
+
+var operators = []string{OpNotEqual, OpStartsWith, OpEndsWith, OpEqual, OpMatches}
+
+// operatorNames lists all operators for error messages including the IN keyword
+var operatorNames = strings.Join(append(append([]string{}, operators...), OpIn), " ")
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-./:", r)
+}
+
+// lex splits the selector into tokens
+func lex(selector string) ([]token, error) {
+	runes := []rune(selector)
+	tokens := []token{}
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenLeft, "(", pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenRight, ")", pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokenComma, ",", pos})
+			i++
+		case r == '\'' || r == '"':
+			var text strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				text.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, &SyntaxError{selector, pos, "unterminated string"}
+			}
+			tokens = append(tokens, token{tokenString, text.String(), pos})
+			i++
+		case r == '@' || isWordRune(r):
+			start := i
+			i++
+			for i < len(runes) && isWordRune(runes[i]) {
+				i++
+			}
+			kind := tokenWord
+			if r == '@' {
+				kind = tokenFact
+				if i == start+1 {
+					return nil, &SyntaxError{selector, pos, "missing fact name after '@'"}
+				}
+			}
+			tokens = append(tokens, token{kind, string(runes[start:i]), pos})
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, &SyntaxError{selector, pos, fmt.Sprintf("unexpected character %q", r)}
+			}
+			tokens = append(tokens, token{tokenOp, op, pos})
+			i += len([]rune(op))
+		}
+	}
+	return append(tokens, token{tokenEnd, "", len(runes) + 1}), nil
+}
+
+type parser struct {
+	selector string
+	tokens   []token
+	current  int
+}
+
+// Parse returns the syntax tree of the selector. Invalid selectors return a *SyntaxError.
+func Parse(selector string) (Node, error) {
+	tokens, err := lex(selector)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{selector: selector, tokens: tokens}
+	if p.peek().kind == tokenEnd {
+		return nil, p.errorf(p.peek(), "empty selector")
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEnd {
+		if next.kind == tokenRight {
+			return nil, p.errorf(next, "unexpected ')' without matching '('")
+		}
+		return nil, p.errorf(next, fmt.Sprintf("expected AND or OR but found %s", next.describe()))
+	}
+	return node, nil
+}
+
+// Validate returns a *SyntaxError when the selector is invalid
+func Validate(selector string) error {
+	_, err := Parse(selector)
+	return err
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.current]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.current]
+	if t.kind != tokenEnd {
+		p.current++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, msg string) error {
+	return &SyntaxError{Selector: p.selector, Pos: t.pos, Msg: msg}
+}
+
+func (p *parser) parseOr() (Node, error) {
+	return p.parseBinary(Or, p.parseAnd)
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	return p.parseBinary(And, p.parseNot)
+}
+
+// parseBinary parses a left associative chain of the operator
+func (p *parser) parseBinary(op string, operand func() (Node, error)) (Node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword() == op {
+		opToken := p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Op: op, Left: left, Right: right, Pos: opToken.pos}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Node, error) {
+	if p.peek().keyword() == Not {
+		notToken := p.next()
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &Negation{Expr: expr, Pos: notToken.pos}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenLeft:
+		p.next()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.peek(); closing.kind != tokenRight {
+			return nil, p.errorf(closing, fmt.Sprintf("expected ')' to close '(' at position %d but found %s", t.pos, closing.describe()))
+		}
+		p.next()
+		return node, nil
+	case t.kind == tokenFact || (t.kind == tokenWord && t.keyword() == ""):
+		return p.parseComparison()
+	}
+	return nil, p.errorf(t, fmt.Sprintf("expected a tag, a fact or '(' but found %s", t.describe()))
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	fieldToken := p.next()
+	field := Field{Name: fieldToken.text}
+	if fieldToken.kind == tokenFact {
+		field = Field{Name: strings.TrimPrefix(fieldToken.text, "@"), Fact: true}
+	}
+
+	opToken := p.next()
+	if opToken.kind == tokenWord && strings.ToUpper(opToken.text) == OpIn {
+		return p.parseIn(fieldToken, field)
+	}
+	if opToken.kind != tokenOp {
+		return nil, p.errorf(opToken, fmt.Sprintf("expected an operator (%s) after %s but found %s", operatorNames, fieldToken.text, opToken.describe()))
+	}
+
+	value, err := p.parseValue(opToken.text)
+	if err != nil {
+		return nil, err
+	}
+	return &Comparison{Field: field, Op: opToken.text, Value: value, Pos: fieldToken.pos}, nil
+}
+
+// parseValue parses the value following the operator
+func (p *parser) parseValue(op string) (Value, error) {
+	valueToken := p.next()
+	var value Value
+	switch {
+	case valueToken.kind == tokenString:
+		value = Value{Text: valueToken.text, Quoted: true}
+	case valueToken.kind == tokenWord && valueToken.keyword() == "":
+		value = Value{Text: valueToken.text}
+	default:
+		return Value{}, p.errorf(valueToken, fmt.Sprintf("expected a value after %s but found %s", op, valueToken.describe()))
+	}
+	if op == OpMatches {
+		if _, err := regexp.Compile(value.Text); err != nil {
+			return Value{}, p.errorf(valueToken, fmt.Sprintf("invalid regular expression: %s", err))
+		}
+	}
+	return value, nil
+}
+
+// parseIn parses the parenthesized and comma separated values of the IN operator
+func (p *parser) parseIn(fieldToken token, field Field) (Node, error) {
+	if left := p.next(); left.kind != tokenLeft {
+		return nil, p.errorf(left, fmt.Sprintf("expected '(' after %s but found %s", OpIn, left.describe()))
+	}
+	comparison := &Comparison{Field: field, Op: OpIn, Pos: fieldToken.pos}
+	for {
+		value, err := p.parseValue(OpIn)
+		if err != nil {
+			return nil, err
+		}
+		comparison.Values = append(comparison.Values, value)
+		switch next := p.next(); next.kind {
+		case tokenComma:
+			continue
+		case tokenRight:
+			return comparison, nil
+		default:
+			return nil, p.errorf(next, fmt.Sprintf("expected ',' or ')' in the %s list but found %s", OpIn, next.describe()))
+		}
+	}
+}
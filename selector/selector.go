@@ -0,0 +1,207 @@
+// Package selector parses, validates and prints the selectors used by arc to filter nodes.
+//
+// A selector compares node tags and facts, facts are prefixed with '@'. Comparisons are combined with AND, OR, NOT
+// and parentheses. Ex: @os='linux' AND (pool='green' OR pool='blue'). IN compares with a list of values,
+// Ex: @identity IN ('a', 'b').
+package selector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Comparison operators
+const (
+	OpEqual      = "="
+	OpNotEqual   = "!="
+	OpStartsWith = "^="
+	OpEndsWith   = "$="
+	OpMatches    = "~"
+	OpIn         = "IN"
+)
+
+// Logical operators
+const (
+	And = "AND"
+	Or  = "OR"
+	Not = "NOT"
+)
+
+// Node is an element of the selector syntax tree
+type Node interface {
+	// String returns the normalized selector
+	String() string
+	// Position returns the position of the node in the parsed selector starting with 1
+	Position() int
+}
+
+// Field is a tag or, with the '@' prefix, a fact
+type Field struct {
+	Name string
+	Fact bool
+}
+
+func (f Field) String() string {
+	if f.Fact {
+		return "@" + f.Name
+	}
+	return f.Name
+}
+
+// Value is the right side of a comparison. Values not quoted in the selector are kept unquoted.
+type Value struct {
+	Text   string
+	Quoted bool
+}
+
+func (v Value) String() string {
+	if !v.Quoted {
+		return v.Text
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v.Text) + "'"
+}
+
+// Comparison compares a field with a value. The IN operator compares with the list of Values instead.
+type Comparison struct {
+	Field  Field
+	Op     string
+	Value  Value
+	Values []Value
+	Pos    int
+}
+
+func (c *Comparison) String() string {
+	if c.Op == OpIn {
+		values := []string{}
+		for _, value := range c.Values {
+			values = append(values, value.String())
+		}
+		return c.Field.String() + " " + OpIn + " (" + strings.Join(values, ", ") + ")"
+	}
+	return c.Field.String() + c.Op + c.Value.String()
+}
+
+func (c *Comparison) Position() int { return c.Pos }
+
+// Binary combines two nodes with AND or OR
+type Binary struct {
+	Op    string
+	Left  Node
+	Right Node
+	Pos   int
+}
+
+func (b *Binary) String() string {
+	return operand(b.Left, b.Op) + " " + b.Op + " " + operand(b.Right, b.Op)
+}
+
+func (b *Binary) Position() int { return b.Pos }
+
+// Negation negates a node
+type Negation struct {
+	Expr Node
+	Pos  int
+}
+
+func (n *Negation) String() string {
+	return Not + " " + operand(n.Expr, Not)
+}
+
+func (n *Negation) Position() int { return n.Pos }
+
+// precedence returns the binding strength of the node, higher binds stronger
+func precedence(node Node) int {
+	switch node := node.(type) {
+	case *Binary:
+		if node.Op == Or {
+			return 1
+		}
+		return 2
+	case *Negation:
+		return 3
+	}
+	return 4
+}
+
+// operand prints the node in parentheses when it binds weaker than the operator
+func operand(node Node, op string) string {
+	var opPrecedence int
+	switch op {
+	case Or:
+		opPrecedence = 1
+	case And:
+		opPrecedence = 2
+	default:
+		opPrecedence = 3
+	}
+	if precedence(node) < opPrecedence {
+		return "(" + node.String() + ")"
+	}
+	return node.String()
+}
+
+// Normalize returns the selector with uppercase keywords, single spaces and only the needed parentheses
+func Normalize(selector string) (string, error) {
+	node, err := Parse(selector)
+	if err != nil {
+		return "", err
+	}
+	return node.String(), nil
+}
+
+// Pretty prints the selector with one operand of AND and OR per line. Parenthesized groups are indented.
+func Pretty(node Node) string {
+	var b strings.Builder
+	pretty(&b, node, "", "")
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func pretty(b *strings.Builder, node Node, indent, prefix string) {
+	switch node := node.(type) {
+	case *Binary:
+		for i, child := range flatten(node, node.Op) {
+			childPrefix := prefix
+			if i > 0 {
+				childPrefix = node.Op + " "
+			}
+			if precedence(child) < precedence(node) {
+				fmt.Fprintf(b, "%s%s(\n", indent, childPrefix)
+				pretty(b, child, indent+"  ", "")
+				fmt.Fprintf(b, "%s)\n", indent)
+				continue
+			}
+			pretty(b, child, indent, childPrefix)
+		}
+	case *Negation:
+		if _, ok := node.Expr.(*Binary); ok {
+			fmt.Fprintf(b, "%s%s%s (\n", indent, prefix, Not)
+			pretty(b, node.Expr, indent+"  ", "")
+			fmt.Fprintf(b, "%s)\n", indent)
+			return
+		}
+		fmt.Fprintf(b, "%s%s%s\n", indent, prefix, node.String())
+	default:
+		fmt.Fprintf(b, "%s%s%s\n", indent, prefix, node.String())
+	}
+}
+
+// flatten returns the operands of a chain of the same operator
+func flatten(node Node, op string) []Node {
+	binary, ok := node.(*Binary)
+	if !ok || binary.Op != op {
+		return []Node{node}
+	}
+	return append(flatten(binary.Left, op), flatten(binary.Right, op)...)
+}
+
+// Walk calls fn for the node and all its children depth first
+func Walk(node Node, fn func(Node)) {
+	fn(node)
+	switch node := node.(type) {
+	case *Binary:
+		Walk(node.Left, fn)
+		Walk(node.Right, fn)
+	case *Negation:
+		Walk(node.Expr, fn)
+	}
+}
@@ -0,0 +1,134 @@
+package selector
+
+import (
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     string
+	}{
+		{"pool=green", "pool=green"},
+		{"@identity = 'x'  and pool='green'", "@identity='x' AND pool='green'"},
+		{`@os="linux" or not (pool='green')`, "@os='linux' OR NOT pool='green'"},
+		{"((a=1 AND b=2)) OR c=3", "a=1 AND b=2 OR c=3"},
+		{"a=1 AND (b=2 OR c=3)", "a=1 AND (b=2 OR c=3)"},
+		{"NOT (a=1 OR b=2)", "NOT (a=1 OR b=2)"},
+		{`name='it\'s' AND @path^='C:\\'`, `name='it\'s' AND @path^='C:\\'`},
+		{"@identity in ('a','b') and pool=green", "@identity IN ('a', 'b') AND pool=green"},
+		{"NOT pool IN (red, \"blue\")", "NOT pool IN (red, 'blue')"},
+		{"@hostname~'^web[0-9]+$' AND @fqdn$=.example.com AND pool!=red", "@hostname~'^web[0-9]+$' AND @fqdn$=.example.com AND pool!=red"},
+	}
+	for _, test := range tests {
+		got, err := Normalize(test.selector)
+		if err != nil {
+			t.Errorf("Expected %q to be valid, got %s", test.selector, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Expected %q to be normalized to %q, got %q", test.selector, test.want, got)
+		}
+		// the normalized selector stays the same
+		if again, _ := Normalize(got); again != got {
+			t.Errorf("Expected %q to stay the same, got %q", got, again)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		selector string
+		pos      int
+		msg      string
+	}{
+		{"", 1, "empty selector"},
+		{"pool", 5, `expected an operator (!= ^= $= = ~ IN) after pool but found end of selector`},
+		{"@identity IN 'a'", 14, `expected '(' after IN but found "a"`},
+		{"@identity IN ()", 15, `expected a value after IN but found ")"`},
+		{"@identity IN ('a' 'b')", 19, `expected ',' or ')' in the IN list but found "b"`},
+		{"@identity IN ('a',", 19, `expected a value after IN but found end of selector`},
+		{"a=1, b=2", 4, `expected AND or OR but found ","`},
+		{"pool='green", 6, "unterminated string"},
+		{"@os='linux' AND", 16, "expected a tag, a fact or '(' but found end of selector"},
+		{"@os='linux' pool=green", 13, `expected AND or OR but found "pool"`},
+		{"(a=1 OR b=2", 12, "expected ')' to close '(' at position 1 but found end of selector"},
+		{"a=1)", 4, "unexpected ')' without matching '('"},
+		{"a=AND", 3, `expected a value after = but found "AND"`},
+		{"a==1", 3, `expected a value after = but found "="`},
+		{"a=1 & b=2", 5, `unexpected character '&'`},
+		{"@ =1", 1, "missing fact name after '@'"},
+		{"a~'('", 3, "invalid regular expression: error parsing regexp: missing closing ): `(`"},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.selector)
+		syntaxErr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("Expected a syntax error for %q, got %v", test.selector, err)
+			continue
+		}
+		if syntaxErr.Pos != test.pos || syntaxErr.Msg != test.msg {
+			t.Errorf("Expected %q to fail at %d with %q, got %d %q", test.selector, test.pos, test.msg, syntaxErr.Pos, syntaxErr.Msg)
+		}
+	}
+}
+
+func TestSyntaxErrorContext(t *testing.T) {
+	err := Validate("@os='linux' AND AND")
+	syntaxErr, ok := err.(*SyntaxError)
+	if !ok {
+		t.Fatalf("Expected a syntax error, got %v", err)
+	}
+	want := "@os='linux' AND AND\n                ^"
+	if syntaxErr.Context() != want {
+		t.Errorf("Expected context\n%s\ngot\n%s", want, syntaxErr.Context())
+	}
+}
+
+func TestPretty(t *testing.T) {
+	node, err := Parse("@os='linux' AND (pool='green' OR pool='blue') AND NOT (a=1 AND b=2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `@os='linux'
+AND (
+  pool='green'
+  OR pool='blue'
+)
+AND NOT (
+  a=1
+  AND b=2
+)`
+	if got := Pretty(node); got != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	pool, err := ParseCondition("pool=green", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os, err := ParseCondition("@os!=windows", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := ParseCondition("name^=it's", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `pool='green' AND @os!='windows' AND name^='it\'s'`
+	if got := Build(And, pool, os, name).String(); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := Build(Or, pool, os).String(); got != "pool='green' OR @os!='windows'" {
+		t.Errorf("Unexpected selector %q", got)
+	}
+
+	for condition, pos := range map[string]int{"poolgreen": 10, "=green": 1, "po ol=green": 1, "name~(": 6} {
+		_, err := ParseCondition(condition, false)
+		if syntaxErr, ok := err.(*SyntaxError); !ok || syntaxErr.Pos != pos {
+			t.Errorf("Expected %q to fail at %d, got %v", condition, pos, err)
+		}
+	}
+}